@@ -25,6 +25,7 @@ func main() {
 	// Start background cleanup
 	globalTracker := api.GetGlobalTracker()
 	globalTracker.StartCleanupRoutine()
+	api.GetExportJobs().StartCleanupRoutine(dataDir)
 
 	// Setup routes
 	router := api.SetupRoutes()
@@ -0,0 +1,98 @@
+// Command verify checks a signed ZIP export against its integrity manifest
+// and signature.
+//
+//	verify -key signing.pub project.zip
+//
+// The key is the PEM public key from GET /api/integrity/key (the server's
+// private key file works too). The exit status is 0 for an intact archive,
+// 1 when verification fails and 2 for usage or read errors.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/user/scrapper/internal/export"
+)
+
+func main() {
+	keyPath := flag.String("key", "", "PEM file with the Ed25519 public key")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s -key <public key> [-json] <archive.zip>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *keyPath == "" || flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	os.Exit(run(*keyPath, flag.Arg(0), *asJSON))
+}
+
+// run verifies the archive and prints the report, returning the exit status
+func run(keyPath, archivePath string, asJSON bool) int {
+	keyData, err := os.ReadFile(keyPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read key: %v\n", err)
+		return 2
+	}
+	key, err := export.ParsePublicKey(keyData)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to parse key %s: %v\n", keyPath, err)
+		return 2
+	}
+
+	file, err := os.Open(archivePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open archive: %v\n", err)
+		return 2
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open archive: %v\n", err)
+		return 2
+	}
+
+	report, err := export.VerifyZip(file, info.Size(), key)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to verify %s: %v\n", archivePath, err)
+		return 2
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+	} else {
+		fmt.Printf("Project:   %s\n", report.ProjectID)
+		fmt.Printf("Created:   %s\n", report.CreatedAt.Format("2006-01-02 15:04:05 MST"))
+		fmt.Printf("Key:       %s\n", report.KeyID)
+		fmt.Printf("Signature: %s\n", map[bool]string{true: "valid", false: "INVALID"}[report.SignatureValid])
+		fmt.Printf("Files:     %d of %d verified\n", report.Verified, report.Files)
+		for _, problem := range report.Problems {
+			if problem.Path != "" {
+				fmt.Printf("  %-9s %s: %s\n", problem.Kind, problem.Path, problem.Message)
+			} else {
+				fmt.Printf("  %-9s %s\n", problem.Kind, problem.Message)
+			}
+		}
+	}
+
+	if !report.Valid {
+		if !asJSON {
+			fmt.Println("FAILED: the archive does not match its signed manifest")
+		}
+		return 1
+	}
+	if !asJSON {
+		fmt.Println("OK: the archive matches its signed manifest")
+	}
+	return 0
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/user/scrapper/internal/export"
+	"github.com/user/scrapper/internal/models"
+)
+
+// HandleExportTarGz streams the project directory as tar.gz; with
+// "deterministic=true" identical content gives identical bytes
+func HandleExportTarGz(w http.ResponseWriter, r *http.Request) {
+	exportTar(w, r, export.ArchiveTarGz)
+}
+
+// HandleExportTarZst streams the project directory as tar.zst; see
+// HandleExportTarGz
+func HandleExportTarZst(w http.ResponseWriter, r *http.Request) {
+	exportTar(w, r, export.ArchiveTarZst)
+}
+
+// HandleStartTarGzExport starts a background tar.gz export
+func HandleStartTarGzExport(w http.ResponseWriter, r *http.Request) {
+	startTarExport(w, r, export.ArchiveTarGz)
+}
+
+// HandleStartTarZstExport starts a background tar.zst export
+func HandleStartTarZstExport(w http.ResponseWriter, r *http.Request) {
+	startTarExport(w, r, export.ArchiveTarZst)
+}
+
+// tarContentTypes are the response types of tar exports
+var tarContentTypes = map[string]string{
+	export.ArchiveTarGz:  "application/gzip",
+	export.ArchiveTarZst: "application/zstd",
+}
+
+// exportTar streams a compressed tar of a completed project
+func exportTar(w http.ResponseWriter, r *http.Request, format string) {
+	projectID := chi.URLParam(r, "id")
+	if refuseUnencrypted(w) {
+		return
+	}
+	if !checkExportable(w, projectID) {
+		return
+	}
+	opts, ok := archiveOptions(w, r, projectID)
+	if !ok {
+		return
+	}
+
+	disableWriteTimeout(w)
+
+	w.Header().Set("Content-Type", tarContentTypes[format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.%s", projectID, format))
+
+	if err := export.StreamTarToWriter(w, projectID, dataDir, format, opts); err != nil {
+		// Can't send error response after streaming started
+		log.Printf("%s export error for project %s: %v", format, projectID, err)
+	}
+}
+
+// startTarExport starts or reuses a background tar export
+func startTarExport(w http.ResponseWriter, r *http.Request, format string) {
+	projectID := chi.URLParam(r, "id")
+	if refuseUnencrypted(w) {
+		return
+	}
+	if !checkExportable(w, projectID) {
+		return
+	}
+
+	opts, ok := archiveOptions(w, r, projectID)
+	if !ok {
+		return
+	}
+	var options any
+	if opts.Deterministic || opts.Selection != nil {
+		options = archiveJobOptions{Deterministic: opts.Deterministic, Selection: opts.Selection}
+	}
+
+	startExport(w, projectID, format, format, options, func(path string, progress export.ProgressFunc) error {
+		return export.CreateTarArchive(path, projectID, dataDir, format, opts, progress)
+	})
+}
+
+// archiveJobOptions tell background archive exports apart
+type archiveJobOptions struct {
+	Signed        string                   `json:"signed,omitempty"` // Key ID of the manifest signature
+	Deterministic bool                     `json:"deterministic,omitempty"`
+	Selection     *models.ArchiveSelection `json:"selection,omitempty"`
+}
+
+// archiveOptions reads the archive query parameters shared by ZIP and tar
+// exports. It writes an error response and returns false for invalid
+// selections, selections matching no page and selections over max_size.
+//
+// Query parameters:
+//   - deterministic: "true" for sorted entries with fixed timestamps and permissions
+//   - include, exclude: page URL globs (repeatable)
+//   - include_pattern, exclude_pattern: page URL regular expressions (repeatable)
+//   - asset_types, exclude_asset_types: comma-separated asset types
+//   - metadata: "false" to leave out files that are not pages or assets
+//   - max_size: limit in bytes on the selected files before compression
+func archiveOptions(w http.ResponseWriter, r *http.Request, projectID string) (export.ArchiveOptions, bool) {
+	query := r.URL.Query()
+	opts := export.ArchiveOptions{Deterministic: query.Get("deterministic") == "true"}
+
+	sel := models.ArchiveSelection{
+		Include:           query["include"],
+		Exclude:           query["exclude"],
+		IncludePatterns:   query["include_pattern"],
+		ExcludePatterns:   query["exclude_pattern"],
+		AssetTypes:        splitList(query.Get("asset_types")),
+		ExcludeAssetTypes: splitList(query.Get("exclude_asset_types")),
+		ExcludeMetadata:   query.Get("metadata") == "false",
+	}
+	if value := query.Get("max_size"); value != "" {
+		size, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || size < 1 {
+			respondError(w, http.StatusBadRequest, "max_size must be a positive number of bytes")
+			return opts, false
+		}
+		sel.MaxSize = size
+	}
+
+	if len(sel.Include)+len(sel.Exclude)+len(sel.IncludePatterns)+len(sel.ExcludePatterns)+
+		len(sel.AssetTypes)+len(sel.ExcludeAssetTypes) == 0 && !sel.ExcludeMetadata && sel.MaxSize == 0 {
+		return opts, true
+	}
+
+	if err := export.ValidateArchiveSelection(&sel); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return opts, false
+	}
+	err := export.CheckArchiveSelection(projectID, dataDir, &sel)
+	switch {
+	case errors.Is(err, export.ErrNoPagesSelected):
+		respondError(w, http.StatusBadRequest, "No pages match the selection")
+		return opts, false
+	case errors.Is(err, export.ErrArchiveTooLarge):
+		respondError(w, http.StatusRequestEntityTooLarge, err.Error())
+		return opts, false
+	case err != nil:
+		log.Printf("Failed to select files of project %s: %v", projectID, err)
+		respondError(w, http.StatusInternalServerError, "Failed to select project files")
+		return opts, false
+	}
+
+	opts.Selection = &sel
+	return opts, true
+}
+
+// splitList splits a comma-separated query value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
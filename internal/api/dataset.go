@@ -0,0 +1,170 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/user/scrapper/internal/export"
+	"github.com/user/scrapper/internal/models"
+	"github.com/user/scrapper/internal/scraper"
+)
+
+// HandleExportDataset downloads structured data extracted by the project's schemas.
+//
+// Query parameters:
+//   - format: "jsonl" (default), "csv" or "xlsx"
+//   - schema: limit to a single schema name
+func HandleExportDataset(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	if refuseUnencrypted(w) {
+		return
+	}
+
+	if !scraper.ProjectExists(projectID, dataDir) {
+		respondError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+
+	project, err := scraper.LoadProject(projectID, dataDir)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load project")
+		return
+	}
+
+	if len(project.Extraction) == 0 {
+		respondError(w, http.StatusNotFound, "Project has no extraction schemas")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "jsonl"
+	}
+
+	schemaName := r.URL.Query().Get("schema")
+	schemas := project.Extraction
+	if schemaName != "" {
+		schemas = nil
+		for _, schema := range project.Extraction {
+			if schema.Name == schemaName {
+				schemas = []models.ExtractionSchema{schema}
+			}
+		}
+		if schemas == nil {
+			respondError(w, http.StatusNotFound, "Schema not found")
+			return
+		}
+	}
+
+	records, err := scraper.LoadDataset(projectID, dataDir, schemaName)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load dataset")
+		return
+	}
+
+	switch format {
+	case "jsonl":
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s-dataset.jsonl", projectID))
+		err = export.WriteDatasetJSONL(w, records)
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s-dataset.csv", projectID))
+		err = export.WriteDatasetCSV(w, schemas, records)
+	case "xlsx":
+		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s-dataset.xlsx", projectID))
+		err = export.WriteDatasetXLSX(w, schemas, records)
+	default:
+		respondError(w, http.StatusBadRequest, "Format must be jsonl, csv or xlsx")
+		return
+	}
+
+	if err != nil {
+		log.Printf("Dataset export error for project %s: %v", projectID, err)
+	}
+}
+
+// maxChunkOptionsBody limits chunk export options; known_hashes may be long
+const maxChunkOptionsBody = 32 << 20
+
+// schemaVersionHeader carries models.PageRecordSchemaVersion on page exports
+const schemaVersionHeader = "X-Schema-Version"
+
+// HandleExportPages streams one JSON object per crawled page as
+// gzip-compressed JSON Lines (see models.PageRecord)
+func HandleExportPages(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	if refuseUnencrypted(w) {
+		return
+	}
+	if !checkExportable(w, projectID) {
+		return
+	}
+
+	disableWriteTimeout(w)
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s-pages.jsonl.gz", projectID))
+	w.Header().Set(schemaVersionHeader, strconv.Itoa(models.PageRecordSchemaVersion))
+
+	gz := gzip.NewWriter(w)
+	err := export.WritePagesJSONL(gz, projectID, dataDir)
+	if closeErr := gz.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Printf("Page export error for project %s: %v", projectID, err)
+	}
+}
+
+// HandleExportChunks streams the main content of every page split into
+// chunks along its headings as gzip-compressed JSON Lines (see
+// models.TextChunk). The optional JSON body holds models.ChunkOptions.
+func HandleExportChunks(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	if refuseUnencrypted(w) {
+		return
+	}
+	if !checkExportable(w, projectID) {
+		return
+	}
+
+	var opts models.ChunkOptions
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxChunkOptionsBody))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &opts); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+	if err := export.ValidateChunkOptions(&opts); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	disableWriteTimeout(w)
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s-chunks.jsonl.gz", projectID))
+
+	gz := gzip.NewWriter(w)
+	err = export.WriteChunksJSONL(gz, projectID, dataDir, opts)
+	if closeErr := gz.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Printf("Chunk export error for project %s: %v", projectID, err)
+	}
+}
@@ -0,0 +1,410 @@
+package api
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/user/scrapper/internal/export"
+	"github.com/user/scrapper/internal/models"
+	"github.com/user/scrapper/internal/scraper"
+)
+
+// maxConcurrentExports limits export jobs generating at the same time
+const maxConcurrentExports = 2
+
+var exportJobs = export.NewJobManager(maxConcurrentExports)
+
+func init() {
+	// Missing from some system MIME tables
+	mime.AddExtensionType(".epub", "application/epub+zip")
+	mime.AddExtensionType(".zst", "application/zstd")
+}
+
+// GetExportJobs returns the export job manager
+func GetExportJobs() *export.JobManager {
+	return exportJobs
+}
+
+// HandleStartPDFExport starts a background PDF export; the body is the same
+// as for HandleExportPDF
+func HandleStartPDFExport(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	if refuseUnencrypted(w) {
+		return
+	}
+	if !checkExportable(w, projectID) {
+		return
+	}
+
+	opts, status, err := parsePDFOptions(r, projectID)
+	if err != nil {
+		respondError(w, status, err.Error())
+		return
+	}
+
+	startExport(w, projectID, "pdf", "pdf", opts, pdfGenerator(projectID, opts))
+}
+
+// HandleStartZipExport starts a background ZIP export; "signed=true" adds
+// a signed integrity manifest as for HandleExportZip. Artifacts are cached
+// on disk, so encrypted archives are only served by HandleExportZip.
+func HandleStartZipExport(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	if !checkExportable(w, projectID) {
+		return
+	}
+
+	if requireZipPassword || r.Header.Get(zipPasswordHeader) != "" || r.URL.Query().Has("password") {
+		respondError(w, http.StatusBadRequest, "Password-protected ZIP archives are only available from export/zip")
+		return
+	}
+	var req zipExportRequest
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxZipOptionsBody)).Decode(&req)
+	if err != nil && !errors.Is(err, io.EOF) {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Password != "" {
+		respondError(w, http.StatusBadRequest, "Password-protected ZIP archives are only available from export/zip")
+		return
+	}
+
+	key, ok := zipSigningKey(w, r)
+	if !ok {
+		return
+	}
+	archive, ok := archiveOptions(w, r, projectID)
+	if !ok {
+		return
+	}
+	opts := export.ZipOptions{ArchiveOptions: archive, SigningKey: key}
+	var options any
+	if key != nil || archive.Deterministic || archive.Selection != nil {
+		jobOptions := archiveJobOptions{Deterministic: archive.Deterministic, Selection: archive.Selection}
+		if key != nil {
+			jobOptions.Signed = export.KeyID(key.Public().(ed25519.PublicKey))
+		}
+		options = jobOptions
+	}
+
+	startExport(w, projectID, "zip", "zip", options, func(path string, progress export.ProgressFunc) error {
+		return export.CreateZipArchive(path, projectID, dataDir, opts, progress)
+	})
+}
+
+// HandleExportMarkdown generates the Markdown ZIP of a project and serves it
+func HandleExportMarkdown(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	if refuseUnencrypted(w) {
+		return
+	}
+	if !checkExportable(w, projectID) {
+		return
+	}
+
+	exportNow(w, r, projectID, "markdown", "markdown.zip", nil, markdownGenerator(projectID))
+}
+
+// HandleStartMarkdownExport starts a background Markdown export
+func HandleStartMarkdownExport(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	if refuseUnencrypted(w) {
+		return
+	}
+	if !checkExportable(w, projectID) {
+		return
+	}
+
+	startExport(w, projectID, "markdown", "markdown.zip", nil, markdownGenerator(projectID))
+}
+
+// HandleExportEPUB generates the EPUB book of a project and serves it
+func HandleExportEPUB(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	if refuseUnencrypted(w) {
+		return
+	}
+	if !checkExportable(w, projectID) {
+		return
+	}
+
+	exportNow(w, r, projectID, "epub", "epub", nil, epubGenerator(projectID))
+}
+
+// HandleStartEPUBExport starts a background EPUB export
+func HandleStartEPUBExport(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	if refuseUnencrypted(w) {
+		return
+	}
+	if !checkExportable(w, projectID) {
+		return
+	}
+
+	startExport(w, projectID, "epub", "epub", nil, epubGenerator(projectID))
+}
+
+// HandleExportSingleFile serves a saved page as one self-contained file, or
+// all pages as a ZIP of such files.
+//
+// Query parameters:
+//   - url: page to export; without it every page goes into a ZIP
+//   - format: "html" (default) or "mhtml"
+func HandleExportSingleFile(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	if refuseUnencrypted(w) {
+		return
+	}
+	if !checkExportable(w, projectID) {
+		return
+	}
+
+	format, ok := singleFileFormat(w, r)
+	if !ok {
+		return
+	}
+
+	pageURL := r.URL.Query().Get("url")
+	if pageURL == "" {
+		exportNow(w, r, projectID, format, format+".zip", nil, singleFileGenerator(projectID, format))
+		return
+	}
+
+	var page bytes.Buffer
+	err := export.WriteSingleFile(&page, projectID, dataDir, pageURL, format)
+	if errors.Is(err, export.ErrPageNotFound) {
+		respondError(w, http.StatusNotFound, "Page not found")
+		return
+	}
+	if err != nil {
+		log.Printf("%s export error for project %s: %v", format, projectID, err)
+		respondError(w, http.StatusInternalServerError, "Failed to generate export")
+		return
+	}
+
+	contentType := "text/html; charset=utf-8"
+	if format == models.SingleFileMHTML {
+		contentType = "application/x-mimearchive" // A whole MIME message, not a bare multipart body
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.SingleFileName(pageURL, format)))
+	w.Write(page.Bytes())
+}
+
+// HandleStartSingleFileExport starts a background export of all pages as
+// self-contained files; format is a query parameter as for
+// HandleExportSingleFile
+func HandleStartSingleFileExport(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	if refuseUnencrypted(w) {
+		return
+	}
+	if !checkExportable(w, projectID) {
+		return
+	}
+
+	format, ok := singleFileFormat(w, r)
+	if !ok {
+		return
+	}
+
+	startExport(w, projectID, format, format+".zip", nil, singleFileGenerator(projectID, format))
+}
+
+// singleFileFormat reads the format query parameter, writing an error
+// response if it is invalid
+func singleFileFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = models.SingleFileHTML
+	}
+	if err := export.ValidateSingleFileFormat(format); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return "", false
+	}
+	return format, true
+}
+
+// HandleExportJobStatus reports the state and progress of an export job
+func HandleExportJobStatus(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	jobID := chi.URLParam(r, "job")
+
+	job, err := exportJobs.Get(projectID, dataDir, jobID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Export job not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, withDownloadURL(job))
+}
+
+// HandleExportDownload serves a finished export artifact with Content-Length,
+// an ETag of its SHA-256 and Range support
+func HandleExportDownload(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	if refuseUnencrypted(w) {
+		return
+	}
+	jobID := chi.URLParam(r, "job")
+
+	file, job, err := exportJobs.Artifact(projectID, dataDir, jobID)
+	if errors.Is(err, export.ErrJobNotFound) {
+		respondError(w, http.StatusNotFound, "Export job not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusConflict, fmt.Sprintf("Export not ready: %v", err))
+		return
+	}
+	defer file.Close()
+
+	disableWriteTimeout(w)
+	serveArtifact(w, r, file.Name(), job, file)
+}
+
+// checkExportable writes an error response unless the project exists and is completed
+func checkExportable(w http.ResponseWriter, projectID string) bool {
+	if !scraper.ProjectExists(projectID, dataDir) {
+		respondError(w, http.StatusNotFound, "Project not found")
+		return false
+	}
+
+	project, err := scraper.LoadProject(projectID, dataDir)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load project")
+		return false
+	}
+
+	if project.Status != models.StatusCompleted {
+		respondError(w, http.StatusBadRequest, "Project is not completed yet")
+		return false
+	}
+
+	return true
+}
+
+// startExport starts or reuses a job and responds with its state:
+// 200 when the artifact is ready, 202 while it is being generated
+func startExport(w http.ResponseWriter, projectID, format, extension string, options any, generate export.GenerateFunc) {
+	job, err := exportJobs.Start(projectID, dataDir, format, extension, options, generate)
+	if err != nil {
+		log.Printf("Failed to start %s export for project %s: %v", format, projectID, err)
+		respondError(w, http.StatusInternalServerError, "Failed to start export")
+		return
+	}
+
+	status := http.StatusAccepted
+	if job.Status == models.ExportCompleted {
+		status = http.StatusOK
+	}
+	respondJSON(w, status, withDownloadURL(job))
+}
+
+// exportNow runs an export through the job manager and serves the artifact.
+// It shares jobs and the cache with background exports and waits for the
+// generator so failures get a proper status.
+func exportNow(w http.ResponseWriter, r *http.Request, projectID, format, extension string, options any, generate export.GenerateFunc) {
+	disableWriteTimeout(w)
+
+	job, err := exportJobs.Start(projectID, dataDir, format, extension, options, generate)
+	if err == nil {
+		err = exportJobs.Wait(r.Context(), projectID, job.ID)
+	}
+	if errors.Is(err, export.ErrNoChapters) {
+		respondError(w, http.StatusBadRequest, "No pages match the export options")
+		return
+	}
+	if err != nil {
+		log.Printf("%s export error for project %s: %v", format, projectID, err)
+		respondError(w, http.StatusInternalServerError, "Failed to generate export")
+		return
+	}
+
+	file, job, err := exportJobs.Artifact(projectID, dataDir, job.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to read export")
+		return
+	}
+	defer file.Close()
+
+	serveArtifact(w, r, file.Name(), job, file)
+}
+
+// disableWriteTimeout lifts the server write timeout for a response that
+// generates or streams an export, which takes longer for large projects
+func disableWriteTimeout(w http.ResponseWriter) {
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+}
+
+// disableReadTimeout lifts the server read timeout for a request that
+// uploads a large file
+func disableReadTimeout(w http.ResponseWriter) {
+	http.NewResponseController(w).SetReadDeadline(time.Time{})
+}
+
+// pdfGenerator renders a project PDF for an export job
+func pdfGenerator(projectID string, opts models.PDFOptions) export.GenerateFunc {
+	return func(path string, progress export.ProgressFunc) error {
+		return export.CreateConsolidatedPDF(path, projectID, dataDir, opts, progress)
+	}
+}
+
+// markdownGenerator converts project pages to a Markdown ZIP for an export job
+func markdownGenerator(projectID string) export.GenerateFunc {
+	return func(path string, progress export.ProgressFunc) error {
+		return export.CreateMarkdownArchive(path, projectID, dataDir, progress)
+	}
+}
+
+// epubGenerator builds a project EPUB for an export job
+func epubGenerator(projectID string) export.GenerateFunc {
+	return func(path string, progress export.ProgressFunc) error {
+		return export.CreateEPUB(path, projectID, dataDir, progress)
+	}
+}
+
+// singleFileGenerator exports project pages as self-contained files for an
+// export job
+func singleFileGenerator(projectID, format string) export.GenerateFunc {
+	return func(path string, progress export.ProgressFunc) error {
+		return export.CreateSingleFileArchive(path, projectID, dataDir, format, progress)
+	}
+}
+
+// withDownloadURL adds the download link to finished jobs
+func withDownloadURL(job models.ExportJob) models.ExportJob {
+	if job.Status == models.ExportCompleted {
+		job.DownloadURL = fmt.Sprintf("/api/project/%s/exports/%s/download", job.ProjectID, job.ID)
+	}
+	return job
+}
+
+// serveArtifact sends an artifact named after its project
+func serveArtifact(w http.ResponseWriter, r *http.Request, path string, job models.ExportJob, content io.ReadSeeker) {
+	ext := strings.TrimPrefix(filepath.Base(path), job.ID) // Keeps ".tar.gz" whole
+	if contentType := mime.TypeByExtension(filepath.Ext(path)); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s%s", job.ProjectID, ext))
+	w.Header().Set("ETag", `"`+job.SHA256+`"`)
+
+	modTime := job.CreatedAt
+	if job.CompletedAt != nil {
+		modTime = *job.CompletedAt
+	}
+
+	// Handles Range, If-None-Match and Content-Length
+	http.ServeContent(w, r, path, modTime, content)
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// writeCompletedProject creates a completed project under a temporary data
+// directory and points the handlers at it
+func writeCompletedProject(t *testing.T, projectID string) {
+	t.Helper()
+	previous := dataDir
+	dataDir = t.TempDir()
+	t.Cleanup(func() { dataDir = previous })
+
+	projectDir := filepath.Join(dataDir, projectID)
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	project := `{"project_id":"` + projectID + `","status":"completed"}`
+	if err := os.WriteFile(filepath.Join(projectDir, "project.json"), []byte(project), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStartZipExportRejectsPasswords(t *testing.T) {
+	const projectID = "project"
+	writeCompletedProject(t, projectID)
+
+	router := chi.NewRouter()
+	router.Post("/project/{id}/exports/zip", HandleStartZipExport)
+
+	tests := []struct {
+		name   string
+		target string
+		header string
+		body   string
+	}{
+		{"header", "/project/" + projectID + "/exports/zip", "secret", ""},
+		{"query", "/project/" + projectID + "/exports/zip?password=secret", "", ""},
+		{"body", "/project/" + projectID + "/exports/zip", "", `{"password":"secret"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+			if tt.header != "" {
+				req.Header.Set(zipPasswordHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body)
+			}
+			if _, err := os.Stat(filepath.Join(dataDir, projectID, "exports")); !os.IsNotExist(err) {
+				t.Errorf("export was started for a password-protected request")
+			}
+		})
+	}
+}
+
+func TestRequireZipPasswordRefusesUnencryptedExports(t *testing.T) {
+	const projectID = "project"
+	writeCompletedProject(t, projectID)
+	requireZipPassword = true
+	t.Cleanup(func() { requireZipPassword = false })
+
+	router := SetupRoutes()
+	targets := []struct{ method, path string }{
+		{http.MethodPost, "/export/pdf"},
+		{http.MethodGet, "/export/markdown"},
+		{http.MethodGet, "/export/epub"},
+		{http.MethodGet, "/export/html"},
+		{http.MethodGet, "/export/tables"},
+		{http.MethodGet, "/export/warc"},
+		{http.MethodGet, "/export/pages"},
+		{http.MethodGet, "/export/tar.gz"},
+		{http.MethodGet, "/export/tar.zst"},
+		{http.MethodPost, "/exports/tar.gz"},
+		{http.MethodPost, "/exports/tar.zst"},
+		{http.MethodPost, "/export/chunks"},
+		{http.MethodGet, "/dataset"},
+		{http.MethodPost, "/exports/pdf"},
+		{http.MethodPost, "/exports/zip"},
+		{http.MethodPost, "/exports/markdown"},
+		{http.MethodPost, "/exports/epub"},
+		{http.MethodPost, "/exports/html"},
+		{http.MethodPost, "/exports/tables"},
+		{http.MethodGet, "/exports/job/download"},
+	}
+	for _, target := range targets {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(target.method, "/api/project/"+projectID+target.path, nil)
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s %s: status = %d, want %d", target.method, target.path, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
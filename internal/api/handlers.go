@@ -1,8 +1,11 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
@@ -32,40 +35,13 @@ func HandleScrape(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate input
-	if req.URL == "" {
-		respondError(w, http.StatusBadRequest, "URL is required")
-		return
-	}
-
-	if req.Depth < 1 || req.Depth > 5 {
-		respondError(w, http.StatusBadRequest, "Depth must be between 1 and 5")
-		return
-	}
-
-	urlPrefix, err := scraper.ValidateAndNormalizeScopePrefix(req.URL, req.URLPrefix)
+	urlPrefix, err := validateScrapeRequest(&req)
 	if err != nil {
 		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// Validate filters
-	if err := scraper.ValidateFilters(req.Filters); err != nil {
-		respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid filters: %v", err))
-		return
-	}
-
-	// Create project
-	project := &models.Project{
-		ID:        uuid.New().String(),
-		URL:       req.URL,
-		URLPrefix: urlPrefix,
-		Depth:     req.Depth,
-		Filters:   req.Filters,
-		Status:    models.StatusStarted,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}
+	project := newProject(&req, urlPrefix)
 
 	// Create scraper
 	s, err := scraper.NewScraper(project, dataDir)
@@ -91,6 +67,72 @@ func HandleScrape(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusAccepted, response)
 }
 
+// validateScrapeRequest checks crawl settings and returns the normalized
+// URL prefix
+func validateScrapeRequest(req *models.ScrapeRequest) (string, error) {
+	// Validate input
+	if req.URL == "" {
+		return "", errors.New("URL is required")
+	}
+
+	if req.Depth < 1 || req.Depth > 5 {
+		return "", errors.New("Depth must be between 1 and 5")
+	}
+
+	urlPrefix, err := scraper.ValidateAndNormalizeScopePrefix(req.URL, req.URLPrefix)
+	if err != nil {
+		return "", err
+	}
+
+	// Validate filters
+	if err := scraper.ValidateFilters(req.Filters); err != nil {
+		return "", fmt.Errorf("Invalid filters: %v", err)
+	}
+
+	// Validate crawl ordering and budgets
+	if err := scraper.ValidateCrawlOptions(req.Strategy, req.PriorityRules, req.MaxPages, req.TimeLimitSeconds); err != nil {
+		return "", fmt.Errorf("Invalid crawl options: %v", err)
+	}
+
+	// Validate extraction schemas
+	if err := scraper.ValidateExtractionSchemas(req.Extraction); err != nil {
+		return "", fmt.Errorf("Invalid extraction schemas: %v", err)
+	}
+
+	// Validate content extraction options
+	if err := scraper.ValidateContentOptions(req.Content); err != nil {
+		return "", fmt.Errorf("Invalid content options: %v", err)
+	}
+
+	// Validate link check options
+	if err := scraper.ValidateLinkCheckOptions(req.LinkCheck); err != nil {
+		return "", fmt.Errorf("Invalid link_check: %v", err)
+	}
+
+	return urlPrefix, nil
+}
+
+// newProject creates a project for validated crawl settings
+func newProject(req *models.ScrapeRequest, urlPrefix string) *models.Project {
+	return &models.Project{
+		ID:               uuid.New().String(),
+		URL:              req.URL,
+		URLPrefix:        urlPrefix,
+		Depth:            req.Depth,
+		Filters:          req.Filters,
+		LinkCheck:        req.LinkCheck,
+		Strategy:         req.Strategy,
+		PriorityRules:    req.PriorityRules,
+		MaxPages:         req.MaxPages,
+		TimeLimitSeconds: req.TimeLimitSeconds,
+		Extraction:       req.Extraction,
+		Content:          req.Content,
+		Status:           models.StatusStarted,
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
+	}
+}
+
 // runScraper executes scraping in background
 func runScraper(s *scraper.Scraper, projectID string) {
 	// Track project
@@ -107,8 +149,7 @@ func runScraper(s *scraper.Scraper, projectID string) {
 
 	// Run scraping with periodic status updates
 	if err := s.Run(); err != nil {
-		s.Project.Status = models.StatusFailed
-		s.Project.Errors = append(s.Project.Errors, err.Error())
+		s.MarkFailed(err)
 		s.SaveProject() // Save error state
 	}
 }
@@ -124,15 +165,7 @@ func HandleStatus(w http.ResponseWriter, r *http.Request) {
 
 	if isActive {
 		// Return live status
-		response := models.StatusResponse{
-			Status:     s.Project.Status,
-			Progress:   calculateProgress(s),
-			Downloaded: s.Project.Downloaded,
-			Total:      s.Project.Total,
-			CurrentURL: s.Project.CurrentURL,
-			Errors:     s.Project.Errors,
-		}
-		respondJSON(w, http.StatusOK, response)
+		respondJSON(w, http.StatusOK, s.Status())
 		return
 	}
 
@@ -150,20 +183,17 @@ func HandleStatus(w http.ResponseWriter, r *http.Request) {
 		Total:      project.Total,
 		CurrentURL: project.CurrentURL,
 		Errors:     project.Errors,
+		LinkCheck:  project.LinkCheckSummary,
 	}
 
 	respondJSON(w, http.StatusOK, response)
 }
 
-// calculateProgress computes progress percentage
-func calculateProgress(s *scraper.Scraper) int {
-	if s.Project.Total == 0 {
-		return 0
-	}
-	return (s.Project.Downloaded * 100) / s.Project.Total
-}
-
-// HandleExportZip exports project as ZIP
+// HandleExportZip exports project as ZIP; with "signed=true" the archive
+// includes an integrity manifest signed with the server key. A password in
+// the X-Export-Password header or, for POST, the JSON body encrypts the
+// files with WinZip AES-256. The selection and "deterministic" parameters
+// are described at archiveOptions.
 func HandleExportZip(w http.ResponseWriter, r *http.Request) {
 	projectID := chi.URLParam(r, "id")
 
@@ -186,20 +216,52 @@ func HandleExportZip(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	key, ok := zipSigningKey(w, r)
+	if !ok {
+		return
+	}
+	password, ok := zipPassword(w, r)
+	if !ok {
+		return
+	}
+	archive, ok := archiveOptions(w, r, projectID)
+	if !ok {
+		return
+	}
+	if password != "" && key != nil {
+		respondError(w, http.StatusBadRequest, "Signed archives cannot be password-protected")
+		return
+	}
+	if password != "" && archive.Deterministic {
+		respondError(w, http.StatusBadRequest, "Deterministic archives cannot be password-protected")
+		return
+	}
+
+	disableWriteTimeout(w)
+
 	// Set response headers
 	w.Header().Set("Content-Type", "application/zip")
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.zip", projectID))
 
 	// Stream ZIP directly to response
-	if err := export.StreamZipToWriter(w, projectID, dataDir); err != nil {
+	if err := export.StreamZipToWriter(w, projectID, dataDir, export.ZipOptions{ArchiveOptions: archive, SigningKey: key, Password: password}); err != nil {
 		// Can't send error response after streaming started
 		log.Printf("ZIP export error for project %s: %v", projectID, err)
 	}
 }
 
-// HandleExportPDF generates and exports project as PDF
+// HandleExportPDF generates and exports project as PDF.
+//
+// The optional JSON body holds models.PDFOptions; "preset" names saved
+// options that the other body fields override.
+//
+// Query parameters:
+//   - order: chapter order, "crawl" (default) or "path"
 func HandleExportPDF(w http.ResponseWriter, r *http.Request) {
 	projectID := chi.URLParam(r, "id")
+	if refuseUnencrypted(w) {
+		return
+	}
 
 	// Check if project exists
 	if !scraper.ProjectExists(projectID, dataDir) {
@@ -220,14 +282,58 @@ func HandleExportPDF(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Set response headers
-	w.Header().Set("Content-Type", "application/pdf")
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.pdf", projectID))
+	opts, status, err := parsePDFOptions(r, projectID)
+	if err != nil {
+		respondError(w, status, err.Error())
+		return
+	}
+
+	exportNow(w, r, projectID, "pdf", "pdf", opts, pdfGenerator(projectID, opts))
+}
 
-	// Generate and stream PDF
-	if err := export.StreamPDFToWriter(w, projectID, dataDir); err != nil {
-		log.Printf("PDF export error for project %s: %v", projectID, err)
+// parsePDFOptions reads PDF options from the request body, an optional saved
+// preset and the order query parameter; it returns an HTTP status on error
+func parsePDFOptions(r *http.Request, projectID string) (models.PDFOptions, int, error) {
+	var opts models.PDFOptions
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxOptionsBody))
+	if err != nil {
+		return opts, http.StatusBadRequest, errors.New("Invalid request body")
 	}
+
+	if len(bytes.TrimSpace(body)) > 0 {
+		var ref struct {
+			Preset string `json:"preset"`
+		}
+		if err := json.Unmarshal(body, &ref); err != nil {
+			return opts, http.StatusBadRequest, errors.New("Invalid request body")
+		}
+
+		if ref.Preset != "" {
+			opts, err = export.LoadPDFPreset(projectID, dataDir, ref.Preset)
+			if errors.Is(err, export.ErrPresetNotFound) {
+				return opts, http.StatusNotFound, errors.New("Preset not found")
+			}
+			if err != nil {
+				return opts, http.StatusInternalServerError, errors.New("Failed to load presets")
+			}
+		}
+
+		// Body fields override the preset
+		if err := json.Unmarshal(body, &opts); err != nil {
+			return opts, http.StatusBadRequest, errors.New("Invalid request body")
+		}
+	}
+
+	if order := r.URL.Query().Get("order"); order != "" {
+		opts.ChapterOrder = order
+	}
+
+	if err := export.ValidatePDFOptions(&opts); err != nil {
+		return opts, http.StatusBadRequest, fmt.Errorf("Invalid PDF options: %v", err)
+	}
+
+	return opts, http.StatusOK, nil
 }
 
 // Helper functions
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"sync"
+
+	"github.com/user/scrapper/internal/export"
+)
+
+// maxVerifyUpload limits the request body of ZIP verification uploads
+const maxVerifyUpload = 2 << 30
+
+// Server signing key for integrity manifests, created on first use
+var (
+	signingKeyFile = getEnvOrDefault("SIGNING_KEY_FILE", filepath.Join(dataDir, "signing.key"))
+	signingKey     ed25519.PrivateKey
+	signingKeyMu   sync.Mutex
+)
+
+// loadSigningKey returns the server signing key
+func loadSigningKey() (ed25519.PrivateKey, error) {
+	signingKeyMu.Lock()
+	defer signingKeyMu.Unlock()
+
+	if signingKey == nil {
+		key, err := export.LoadSigningKey(signingKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		signingKey = key
+	}
+	return signingKey, nil
+}
+
+// zipSigningKey returns the signing key when the request asks for a signed
+// ZIP with "signed=true", and nil otherwise. It writes an error response
+// and returns false if the key cannot be loaded.
+func zipSigningKey(w http.ResponseWriter, r *http.Request) (ed25519.PrivateKey, bool) {
+	if r.URL.Query().Get("signed") != "true" {
+		return nil, true
+	}
+
+	key, err := loadSigningKey()
+	if err != nil {
+		log.Printf("Failed to load signing key %s: %v", signingKeyFile, err)
+		respondError(w, http.StatusInternalServerError, "Failed to load signing key")
+		return nil, false
+	}
+	return key, true
+}
+
+// HandleSigningKey returns the public key that signs integrity manifests
+func HandleSigningKey(w http.ResponseWriter, r *http.Request) {
+	key, err := loadSigningKey()
+	if err != nil {
+		log.Printf("Failed to load signing key %s: %v", signingKeyFile, err)
+		respondError(w, http.StatusInternalServerError, "Failed to load signing key")
+		return
+	}
+
+	public := key.Public().(ed25519.PublicKey)
+	encoded, err := export.EncodePublicKey(public)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to encode public key")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{
+		"algorithm":  "ed25519",
+		"key_id":     export.KeyID(public),
+		"public_key": string(encoded),
+	})
+}
+
+// HandleVerifyZip checks a signed ZIP export, uploaded as "file" of a
+// multipart form, against its integrity manifest and the server key. The
+// report is returned with 200 whether or not the archive is intact.
+func HandleVerifyZip(w http.ResponseWriter, r *http.Request) {
+	// Uploading and hashing large archives takes longer than the server timeouts
+	disableReadTimeout(w)
+	disableWriteTimeout(w)
+	r.Body = http.MaxBytesReader(w, r.Body, maxVerifyUpload)
+	if err := r.ParseMultipartForm(maxImportMemory); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("ZIP upload exceeds %d bytes", int64(maxVerifyUpload)))
+			return
+		}
+		respondError(w, http.StatusBadRequest, "Expected a multipart form with a ZIP file")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	upload, fileHeader, err := r.FormFile("file")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "ZIP file is required")
+		return
+	}
+	defer upload.Close()
+
+	key, err := loadSigningKey()
+	if err != nil {
+		log.Printf("Failed to load signing key %s: %v", signingKeyFile, err)
+		respondError(w, http.StatusInternalServerError, "Failed to load signing key")
+		return
+	}
+
+	report, err := export.VerifyZip(upload, fileHeader.Size, key.Public().(ed25519.PublicKey))
+	if errors.Is(err, export.ErrNoIntegrityManifest) {
+		respondError(w, http.StatusBadRequest, "The archive has no integrity manifest")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ZIP file: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, report)
+}
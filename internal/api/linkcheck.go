@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/user/scrapper/internal/models"
+	"github.com/user/scrapper/internal/scraper"
+)
+
+// HandleLinkCheckReport returns the broken link report as JSON or CSV.
+//
+// Query parameters:
+//   - format: "json" (default) or "csv"
+//   - broken_only: "true" to return only broken links
+func HandleLinkCheckReport(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+
+	if !scraper.ProjectExists(projectID, dataDir) {
+		respondError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		respondError(w, http.StatusBadRequest, "Format must be json or csv")
+		return
+	}
+
+	report, err := scraper.LoadLinkCheckReport(projectID, dataDir)
+	if os.IsNotExist(err) {
+		respondError(w, http.StatusNotFound, "Link check report not available (enable link_check when starting the scrape)")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load link check report")
+		return
+	}
+
+	if r.URL.Query().Get("broken_only") == "true" {
+		broken := make([]models.LinkCheckResult, 0)
+		for _, result := range report.Results {
+			if result.Broken {
+				broken = append(broken, result)
+			}
+		}
+		report.Results = broken
+	}
+
+	if format == "json" {
+		respondJSON(w, http.StatusOK, report)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s-linkcheck.csv", projectID))
+
+	if err := writeLinkCheckCSV(w, report); err != nil {
+		log.Printf("Link check CSV export error for project %s: %v", projectID, err)
+	}
+}
+
+// writeLinkCheckCSV writes one row per checked URL and referring page
+func writeLinkCheckCSV(w http.ResponseWriter, report *models.LinkCheckReport) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{"url", "status_code", "error", "broken", "internal", "method", "referring_page", "anchor_text"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, result := range report.Results {
+		row := []string{
+			result.URL,
+			strconv.Itoa(result.StatusCode),
+			result.Error,
+			strconv.FormatBool(result.Broken),
+			strconv.FormatBool(result.Internal),
+			result.Method,
+			"",
+			"",
+		}
+
+		if len(result.Referrers) == 0 {
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, referrer := range result.Referrers {
+			row[6] = referrer.PageURL
+			row[7] = referrer.Text
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/user/scrapper/internal/export"
+	"github.com/user/scrapper/internal/scraper"
+)
+
+// HandleLinkGraph returns the project link graph as JSON, DOT or GraphML.
+//
+// Query parameters:
+//   - format: "json" (default), "dot" or "graphml"
+//   - internal_only: "true" to drop links to other domains
+func HandleLinkGraph(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+
+	if !scraper.ProjectExists(projectID, dataDir) {
+		respondError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "dot" && format != "graphml" {
+		respondError(w, http.StatusBadRequest, "Format must be json, dot or graphml")
+		return
+	}
+
+	internalOnly := r.URL.Query().Get("internal_only") == "true"
+
+	startURL, ok := projectStartURL(projectID)
+	if !ok {
+		respondError(w, http.StatusConflict, "Project is not ready yet")
+		return
+	}
+
+	graph, err := export.BuildLinkGraph(projectID, dataDir, startURL, internalOnly)
+	if err != nil {
+		log.Printf("Failed to build link graph of project %s: %v", projectID, err)
+		respondError(w, http.StatusInternalServerError, "Failed to build link graph")
+		return
+	}
+
+	switch format {
+	case "dot":
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s-links.dot", projectID))
+		err = export.WriteGraphDOT(w, graph)
+	case "graphml":
+		w.Header().Set("Content-Type", "application/graphml+xml")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s-links.graphml", projectID))
+		err = export.WriteGraphGraphML(w, graph)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		err = export.WriteGraphJSON(w, graph)
+	}
+
+	if err != nil {
+		log.Printf("Link graph export error for project %s: %v", projectID, err)
+	}
+}
+
+// projectStartURL returns the start URL of a running crawl or, once it has
+// finished, of the saved project
+func projectStartURL(projectID string) (string, bool) {
+	projectsMutex.RLock()
+	s, isActive := activeProjects[projectID]
+	projectsMutex.RUnlock()
+	if isActive {
+		return s.Project.URL, true
+	}
+
+	project, err := scraper.LoadProject(projectID, dataDir)
+	if err != nil {
+		return "", false
+	}
+	return project.URL, true
+}
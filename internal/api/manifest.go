@@ -0,0 +1,172 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/user/scrapper/internal/models"
+	"github.com/user/scrapper/internal/scraper"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+// HandleListPages returns paginated page entries from the project manifest
+func HandleListPages(w http.ResponseWriter, r *http.Request) {
+	handleManifestList(w, r, models.ManifestKindPage)
+}
+
+// HandleListAssets returns paginated asset entries from the project manifest
+func HandleListAssets(w http.ResponseWriter, r *http.Request) {
+	handleManifestList(w, r, models.ManifestKindAsset)
+}
+
+// handleManifestList filters and paginates manifest entries of a single kind.
+//
+// Query parameters:
+//   - offset, limit: pagination (limit defaults to 50, max 500)
+//   - q: case-insensitive substring of the URL, page title or description
+//   - status: exact HTTP status code
+//   - has_error: "true" or "false"
+//   - type: asset type (css, js, img, font, other)
+//   - lang: page language prefix (e.g. "pl" matches "pl-PL")
+func handleManifestList(w http.ResponseWriter, r *http.Request, kind string) {
+	projectID := chi.URLParam(r, "id")
+
+	if !scraper.ProjectExists(projectID, dataDir) {
+		respondError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+
+	query := r.URL.Query()
+
+	offset, err := parseIntParam(query.Get("offset"), 0)
+	if err != nil || offset < 0 {
+		respondError(w, http.StatusBadRequest, "Invalid offset")
+		return
+	}
+
+	limit, err := parseIntParam(query.Get("limit"), defaultListLimit)
+	if err != nil || limit < 1 {
+		respondError(w, http.StatusBadRequest, "Invalid limit")
+		return
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+
+	status, err := parseIntParam(query.Get("status"), 0)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid status")
+		return
+	}
+
+	entries, err := scraper.LoadManifest(projectID, dataDir)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load manifest")
+		return
+	}
+
+	search := strings.ToLower(query.Get("q"))
+	assetType := query.Get("type")
+	hasError := query.Get("has_error")
+	lang := strings.ToLower(query.Get("lang"))
+
+	matched := make([]models.ManifestEntry, 0)
+	for _, entry := range entries {
+		if entry.Kind != kind {
+			continue
+		}
+		if search != "" && !matchesSearch(entry, search) {
+			continue
+		}
+		if lang != "" && (entry.Metadata == nil || !strings.HasPrefix(strings.ToLower(entry.Metadata.Language), lang)) {
+			continue
+		}
+		if status != 0 && entry.StatusCode != status {
+			continue
+		}
+		if assetType != "" && entry.Type != assetType {
+			continue
+		}
+		if hasError == "true" && entry.Error == "" {
+			continue
+		}
+		if hasError == "false" && entry.Error != "" {
+			continue
+		}
+		matched = append(matched, entry)
+	}
+
+	response := models.ManifestListResponse{
+		Total:  len(matched),
+		Offset: offset,
+		Limit:  limit,
+		Items:  []models.ManifestEntry{},
+	}
+
+	if offset < len(matched) {
+		end := offset + limit
+		if end > len(matched) {
+			end = len(matched)
+		}
+		response.Items = matched[offset:end]
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// HandlePageDetail returns the manifest entry and metadata of a single page (?url=)
+func HandlePageDetail(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+
+	if !scraper.ProjectExists(projectID, dataDir) {
+		respondError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+
+	pageURL := r.URL.Query().Get("url")
+	if pageURL == "" {
+		respondError(w, http.StatusBadRequest, "url is required")
+		return
+	}
+
+	entries, err := scraper.LoadManifest(projectID, dataDir)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load manifest")
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.Kind == models.ManifestKindPage && entry.URL == pageURL {
+			respondJSON(w, http.StatusOK, entry)
+			return
+		}
+	}
+
+	respondError(w, http.StatusNotFound, "Page not found")
+}
+
+// matchesSearch checks the URL and, for pages, title and description
+func matchesSearch(entry models.ManifestEntry, search string) bool {
+	if strings.Contains(strings.ToLower(entry.URL), search) {
+		return true
+	}
+	if entry.Metadata == nil {
+		return false
+	}
+	return strings.Contains(strings.ToLower(entry.Metadata.Title), search) ||
+		strings.Contains(strings.ToLower(entry.Metadata.Description), search)
+}
+
+// parseIntParam parses an optional integer query parameter
+func parseIntParam(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
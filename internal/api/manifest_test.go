@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/user/scrapper/internal/models"
+	"github.com/user/scrapper/internal/scraper"
+)
+
+func TestManifestLists(t *testing.T) {
+	const projectID = "project"
+	writeCompletedProject(t, projectID)
+
+	manifest, err := scraper.OpenManifest(projectID, dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := []models.ManifestEntry{
+		{Kind: models.ManifestKindPage, URL: "https://example.com/", StatusCode: 500, Error: "server error"},
+		{Kind: models.ManifestKindPage, URL: "https://example.com/docs", StatusCode: 200, Metadata: &models.PageMetadata{Title: "Dokumentacja", Language: "pl-PL"}},
+		{Kind: models.ManifestKindPage, URL: "https://example.com/blog", StatusCode: 404, Error: "not found"},
+		{Kind: models.ManifestKindAsset, URL: "https://example.com/site.css", Type: "css", StatusCode: 200},
+		{Kind: models.ManifestKindAsset, URL: "https://example.com/logo.png", Type: "img", StatusCode: 200},
+		// Supersedes the failed first fetch of the root page
+		{Kind: models.ManifestKindPage, URL: "https://example.com/", StatusCode: 200, Metadata: &models.PageMetadata{Title: "Home", Language: "en"}},
+	}
+	for _, record := range records {
+		if err := manifest.Append(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	manifest.Close()
+
+	router := chi.NewRouter()
+	router.Get("/project/{id}/pages", HandleListPages)
+	router.Get("/project/{id}/assets", HandleListAssets)
+
+	tests := []struct {
+		name   string
+		target string
+		total  int
+		urls   []string
+	}{
+		{"all pages", "pages", 3, []string{"/", "/docs", "/blog"}},
+		{"offset and limit", "pages?offset=1&limit=1", 3, []string{"/docs"}},
+		{"offset past the end", "pages?offset=10", 3, nil},
+		{"search title", "pages?q=DOKUMENT", 1, []string{"/docs"}},
+		{"status", "pages?status=200", 2, []string{"/", "/docs"}},
+		{"errors", "pages?has_error=true", 1, []string{"/blog"}},
+		{"no errors", "pages?has_error=false", 2, []string{"/", "/docs"}},
+		{"language prefix", "pages?lang=pl", 1, []string{"/docs"}},
+		{"assets", "assets", 2, []string{"/site.css", "/logo.png"}},
+		{"asset type", "assets?type=img", 1, []string{"/logo.png"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/project/"+projectID+"/"+tt.target, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+			}
+
+			var response models.ManifestListResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			var urls []string
+			for _, item := range response.Items {
+				urls = append(urls, strings.TrimPrefix(item.URL, "https://example.com"))
+			}
+			if response.Total != tt.total || strings.Join(urls, " ") != strings.Join(tt.urls, " ") {
+				t.Errorf("got total %d %v, want %d %v", response.Total, urls, tt.total, tt.urls)
+			}
+			for _, item := range response.Items {
+				if item.URL == "https://example.com/" && item.Error != "" {
+					t.Errorf("root page was served from its superseded record")
+				}
+			}
+		})
+	}
+
+	for _, target := range []string{"pages?offset=-1", "pages?limit=0", "pages?status=ok"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/project/"+projectID+"/"+target, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", target, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/user/scrapper/internal/export"
+	"github.com/user/scrapper/internal/models"
+	"github.com/user/scrapper/internal/scraper"
+)
+
+// maxOptionsBody limits JSON export options in request bodies
+const maxOptionsBody = 1 << 20
+
+// validProjectID reports whether id is a project ID as generated by
+// newProject, so it cannot name a path outside DATA_DIR
+func validProjectID(id string) bool {
+	parsed, err := uuid.Parse(id)
+	return err == nil && parsed.String() == id
+}
+
+// HandleListPDFPresets returns the saved PDF presets of a project
+func HandleListPDFPresets(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+
+	if !validProjectID(projectID) || !scraper.ProjectExists(projectID, dataDir) {
+		respondError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+
+	presets, err := export.LoadPDFPresets(projectID, dataDir)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load presets")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.PDFPresetsResponse{Presets: presets})
+}
+
+// HandleSavePDFPreset creates or replaces a named preset from a PDFOptions body
+func HandleSavePDFPreset(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	name := chi.URLParam(r, "name")
+
+	if !validProjectID(projectID) || !scraper.ProjectExists(projectID, dataDir) {
+		respondError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+
+	if err := export.ValidatePresetName(name); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var opts models.PDFOptions
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxOptionsBody)).Decode(&opts); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := export.ValidatePDFOptions(&opts); err != nil {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid PDF options: %v", err))
+		return
+	}
+
+	if err := export.SavePDFPreset(projectID, dataDir, name, opts); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save preset")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, opts)
+}
+
+// HandleDeletePDFPreset removes a named preset
+func HandleDeletePDFPreset(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	name := chi.URLParam(r, "name")
+
+	if !validProjectID(projectID) || !scraper.ProjectExists(projectID, dataDir) {
+		respondError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+
+	err := export.DeletePDFPreset(projectID, dataDir, name)
+	if errors.Is(err, export.ErrPresetNotFound) {
+		respondError(w, http.StatusNotFound, "Preset not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete preset")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
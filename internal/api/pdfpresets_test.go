@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/user/scrapper/internal/export"
+)
+
+func TestPDFPresetsRejectInvalidProjectIDs(t *testing.T) {
+	projectID := uuid.New().String()
+	root := t.TempDir()
+	previous := dataDir
+	dataDir = filepath.Join(root, "data")
+	t.Cleanup(func() { dataDir = previous })
+	if err := os.MkdirAll(filepath.Join(dataDir, projectID), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// A presets file just outside DATA_DIR
+	outside := filepath.Join(root, export.PDFPresetsFileName)
+	if err := os.WriteFile(outside, []byte(`{"keep":{}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	router := chi.NewRouter()
+	router.Get("/project/{id}/pdf-presets", HandleListPDFPresets)
+	router.Put("/project/{id}/pdf-presets/{name}", HandleSavePDFPreset)
+	router.Delete("/project/{id}/pdf-presets/{name}", HandleDeletePDFPreset)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+	}{
+		{"save", http.MethodPut, "/project/" + projectID + "/pdf-presets/print", "{}", http.StatusOK},
+		{"save outside", http.MethodPut, "/project/../pdf-presets/print", "{}", http.StatusNotFound},
+		{"save upper case", http.MethodPut, "/project/" + strings.ToUpper(projectID) + "/pdf-presets/print", "{}", http.StatusNotFound},
+		{"delete outside", http.MethodDelete, "/project/../pdf-presets/keep", "", http.StatusNotFound},
+		{"list outside", http.MethodGet, "/project/../pdf-presets", "", http.StatusNotFound},
+		{"delete", http.MethodDelete, "/project/" + projectID + "/pdf-presets/print", "", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+
+	if data, err := os.ReadFile(outside); err != nil || string(data) != `{"keep":{}}` {
+		t.Errorf("presets file outside DATA_DIR changed: %q, %v", data, err)
+	}
+}
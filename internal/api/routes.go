@@ -27,9 +27,47 @@ func SetupRoutes() *chi.Mux {
 	// API routes
 	r.Route("/api", func(r chi.Router) {
 		r.Post("/scrape", HandleScrape)
+		r.Post("/import/warc", HandleImportWARC)
+		r.Get("/search", HandleSearchAll)
+		r.Get("/integrity/key", HandleSigningKey)
+		r.Post("/integrity/verify", HandleVerifyZip)
 		r.Get("/project/{id}/status", HandleStatus)
+		r.Get("/project/{id}/pages", HandleListPages)
+		r.Get("/project/{id}/page", HandlePageDetail)
+		r.Get("/project/{id}/assets", HandleListAssets)
+		r.Get("/project/{id}/links", HandleLinkGraph)
+		r.Get("/project/{id}/linkcheck", HandleLinkCheckReport)
+		r.Get("/project/{id}/dataset", HandleExportDataset)
+		r.Get("/project/{id}/tables", HandleListTables)
+		r.Get("/project/{id}/search", HandleSearch)
 		r.Get("/project/{id}/export/zip", HandleExportZip)
+		r.Post("/project/{id}/export/zip", HandleExportZip)
+		r.Get("/project/{id}/export/tar.gz", HandleExportTarGz)
+		r.Get("/project/{id}/export/tar.zst", HandleExportTarZst)
 		r.Post("/project/{id}/export/pdf", HandleExportPDF)
+		r.Get("/project/{id}/export/markdown", HandleExportMarkdown)
+		r.Get("/project/{id}/export/epub", HandleExportEPUB)
+		r.Get("/project/{id}/export/html", HandleExportSingleFile)
+		r.Get("/project/{id}/export/tables", HandleExportTables)
+		r.Get("/project/{id}/export/warc", HandleExportWARC)
+		r.Get("/project/{id}/export/pages", HandleExportPages)
+		r.Post("/project/{id}/export/chunks", HandleExportChunks)
+		r.Get("/project/{id}/export/snapshot/zip", HandleExportSnapshotZip)
+		r.Post("/project/{id}/export/snapshot/zip", HandleExportSnapshotZip)
+		r.Post("/project/{id}/export/snapshot/pdf", HandleExportSnapshotPDF)
+		r.Post("/project/{id}/exports/pdf", HandleStartPDFExport)
+		r.Post("/project/{id}/exports/zip", HandleStartZipExport)
+		r.Post("/project/{id}/exports/tar.gz", HandleStartTarGzExport)
+		r.Post("/project/{id}/exports/tar.zst", HandleStartTarZstExport)
+		r.Post("/project/{id}/exports/markdown", HandleStartMarkdownExport)
+		r.Post("/project/{id}/exports/epub", HandleStartEPUBExport)
+		r.Post("/project/{id}/exports/html", HandleStartSingleFileExport)
+		r.Post("/project/{id}/exports/tables", HandleStartTablesExport)
+		r.Get("/project/{id}/exports/{job}", HandleExportJobStatus)
+		r.Get("/project/{id}/exports/{job}/download", HandleExportDownload)
+		r.Get("/project/{id}/pdf-presets", HandleListPDFPresets)
+		r.Put("/project/{id}/pdf-presets/{name}", HandleSavePDFPreset)
+		r.Delete("/project/{id}/pdf-presets/{name}", HandleDeletePDFPreset)
 	})
 
 	return r
@@ -39,8 +77,9 @@ func SetupRoutes() *chi.Mux {
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, "+zipPasswordHeader)
+		w.Header().Set("Access-Control-Expose-Headers", partialExportHeader+", "+pendingAssetsHeader+", "+schemaVersionHeader)
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/user/scrapper/internal/models"
+	"github.com/user/scrapper/internal/scraper"
+	"github.com/user/scrapper/internal/search"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// searchIndexes caches the loaded project indexes
+var searchIndexes = search.NewIndexCache()
+
+// HandleSearch runs a full-text query against one project's index.
+//
+// Query parameters:
+//   - q: search terms (required)
+//   - limit: number of results (default 20, max 100)
+func HandleSearch(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+
+	if !scraper.ProjectExists(projectID, dataDir) {
+		respondError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+
+	query, limit, ok := parseSearchParams(w, r)
+	if !ok {
+		return
+	}
+
+	index, err := searchIndexes.Load(projectID, dataDir)
+	if os.IsNotExist(err) {
+		respondError(w, http.StatusNotFound, "Search index not found (scrape not finished)")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load search index")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, searchResponse(query, index.Search(query), limit))
+}
+
+// HandleSearchAll runs a full-text query against every indexed project in
+// DATA_DIR, ranking all their pages as one collection
+func HandleSearchAll(w http.ResponseWriter, r *http.Request) {
+	query, limit, ok := parseSearchParams(w, r)
+	if !ok {
+		return
+	}
+
+	entries, err := os.ReadDir(dataDir)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list projects")
+		return
+	}
+
+	indexes := make(map[string]*search.Index)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		index, err := searchIndexes.Load(entry.Name(), dataDir)
+		if err != nil {
+			continue // Not indexed yet
+		}
+		indexes[entry.Name()] = index
+	}
+
+	respondJSON(w, http.StatusOK, searchResponse(query, search.SearchAll(indexes, query), limit))
+}
+
+// parseSearchParams reads q and limit, writing an error response when invalid
+func parseSearchParams(w http.ResponseWriter, r *http.Request) (string, int, bool) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		respondError(w, http.StatusBadRequest, "q is required")
+		return "", 0, false
+	}
+
+	limit, err := parseIntParam(r.URL.Query().Get("limit"), defaultSearchLimit)
+	if err != nil || limit < 1 {
+		respondError(w, http.StatusBadRequest, "Invalid limit")
+		return "", 0, false
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	return query, limit, true
+}
+
+// searchResponse truncates ranked results to limit
+func searchResponse(query string, results []models.SearchResult, limit int) models.SearchResponse {
+	response := models.SearchResponse{
+		Query:   query,
+		Total:   len(results),
+		Results: []models.SearchResult{},
+	}
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	if len(results) > 0 {
+		response.Results = results
+	}
+	return response
+}
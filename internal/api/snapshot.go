@@ -0,0 +1,161 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/user/scrapper/internal/export"
+	"github.com/user/scrapper/internal/models"
+	"github.com/user/scrapper/internal/scraper"
+)
+
+// Headers of snapshot exports: the partial mark and the number of assets
+// not downloaded yet, which the export lacks
+const (
+	partialExportHeader = "X-Export-Partial"
+	pendingAssetsHeader = "X-Export-Pending-Assets"
+)
+
+// HandleExportSnapshotZip exports the pages and assets a running crawl has
+// saved so far as a ZIP labelled partial. "signed=true" and the export
+// password work as for HandleExportZip.
+func HandleExportSnapshotZip(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	s, ok := runningScraper(w, projectID)
+	if !ok {
+		return
+	}
+
+	key, ok := zipSigningKey(w, r)
+	if !ok {
+		return
+	}
+	password, ok := zipPassword(w, r)
+	if !ok {
+		return
+	}
+	if password != "" && key != nil {
+		respondError(w, http.StatusBadRequest, "Signed archives cannot be password-protected")
+		return
+	}
+
+	snapshotDir, project, ok := takeSnapshot(w, s)
+	if !ok {
+		return
+	}
+	defer os.RemoveAll(snapshotDir)
+
+	disableWriteTimeout(w)
+
+	setPartialHeaders(w, project)
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s-partial.zip", projectID))
+
+	if err := export.StreamZipToWriter(w, projectID, snapshotDir, export.ZipOptions{SigningKey: key, Password: password}); err != nil {
+		// Can't send error response after streaming started
+		log.Printf("Snapshot ZIP export error for project %s: %v", projectID, err)
+	}
+}
+
+// HandleExportSnapshotPDF renders the pages a running crawl has saved so far
+// as a PDF labelled partial. Options are read as for HandleExportPDF.
+func HandleExportSnapshotPDF(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	if refuseUnencrypted(w) {
+		return
+	}
+	s, ok := runningScraper(w, projectID)
+	if !ok {
+		return
+	}
+
+	opts, status, err := parsePDFOptions(r, projectID)
+	if err != nil {
+		respondError(w, status, err.Error())
+		return
+	}
+
+	disableWriteTimeout(w)
+
+	snapshotDir, project, ok := takeSnapshot(w, s)
+	if !ok {
+		return
+	}
+	defer os.RemoveAll(snapshotDir)
+
+	pdfPath := filepath.Join(snapshotDir, projectID+".pdf")
+	err = export.CreateConsolidatedPDF(pdfPath, projectID, snapshotDir, opts, nil)
+	if errors.Is(err, export.ErrNoChapters) {
+		respondError(w, http.StatusBadRequest, "No saved pages match the export options")
+		return
+	}
+	if err != nil {
+		log.Printf("Snapshot PDF export error for project %s: %v", projectID, err)
+		respondError(w, http.StatusInternalServerError, "Failed to generate export")
+		return
+	}
+
+	file, err := os.Open(pdfPath)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to read export")
+		return
+	}
+	defer file.Close()
+
+	setPartialHeaders(w, project)
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s-partial.pdf", projectID))
+	http.ServeContent(w, r, pdfPath, *project.SnapshotAt, file)
+}
+
+// runningScraper returns the scraper of a crawl in progress. It writes an
+// error response and returns false for unknown and finished projects.
+func runningScraper(w http.ResponseWriter, projectID string) (*scraper.Scraper, bool) {
+	projectsMutex.RLock()
+	s, isActive := activeProjects[projectID]
+	projectsMutex.RUnlock()
+
+	if isActive {
+		return s, true
+	}
+	if scraper.ProjectExists(projectID, dataDir) {
+		respondError(w, http.StatusConflict, "Project is not running; use the regular export")
+		return nil, false
+	}
+	respondError(w, http.StatusNotFound, "Project not found")
+	return nil, false
+}
+
+// takeSnapshot freezes a running crawl into a temporary data directory,
+// which the caller removes. It writes an error response and returns false
+// on failure.
+func takeSnapshot(w http.ResponseWriter, s *scraper.Scraper) (string, *models.Project, bool) {
+	snapshotDir, err := os.MkdirTemp("", "scrapper-snapshot-")
+	if err != nil {
+		log.Printf("Failed to create snapshot directory: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to create snapshot")
+		return "", nil, false
+	}
+
+	project, err := s.Snapshot(snapshotDir)
+	if err != nil {
+		os.RemoveAll(snapshotDir)
+		log.Printf("Failed to snapshot project %s: %v", s.Project.ID, err)
+		respondError(w, http.StatusInternalServerError, "Failed to create snapshot")
+		return "", nil, false
+	}
+	return snapshotDir, project, true
+}
+
+// setPartialHeaders labels a snapshot export as partial
+func setPartialHeaders(w http.ResponseWriter, project *models.Project) {
+	w.Header().Set(partialExportHeader, "true")
+	w.Header().Set(pendingAssetsHeader, strconv.Itoa(project.PendingAssets))
+	w.Header().Set("Last-Modified", project.SnapshotAt.UTC().Format(http.TimeFormat))
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/user/scrapper/internal/export"
+	"github.com/user/scrapper/internal/scraper"
+)
+
+// HandleListTables lists the tables extracted from the project pages
+func HandleListTables(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+
+	if !scraper.ProjectExists(projectID, dataDir) {
+		respondError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+
+	tables, err := scraper.LoadTables(projectID, dataDir)
+	if err != nil {
+		log.Printf("Failed to load tables for project %s: %v", projectID, err)
+		respondError(w, http.StatusInternalServerError, "Failed to load tables")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, tables)
+}
+
+// HandleExportTables serves the extracted tables as an XLSX workbook or a
+// ZIP of CSV files.
+//
+// Query parameters:
+//   - format: "xlsx" (default) or "csv"
+func HandleExportTables(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	if refuseUnencrypted(w) {
+		return
+	}
+	format, extension, generate, ok := tablesExport(w, r, projectID)
+	if !ok {
+		return
+	}
+
+	exportNow(w, r, projectID, format, extension, nil, generate)
+}
+
+// HandleStartTablesExport starts a background table export; format is a
+// query parameter as for HandleExportTables
+func HandleStartTablesExport(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	if refuseUnencrypted(w) {
+		return
+	}
+	format, extension, generate, ok := tablesExport(w, r, projectID)
+	if !ok {
+		return
+	}
+
+	startExport(w, projectID, format, extension, nil, generate)
+}
+
+// tablesExport checks a table export request and picks its generator,
+// writing an error response if the request cannot be served
+func tablesExport(w http.ResponseWriter, r *http.Request, projectID string) (format, extension string, generate export.GenerateFunc, ok bool) {
+	if !checkExportable(w, projectID) {
+		return "", "", nil, false
+	}
+
+	tables, err := scraper.LoadTables(projectID, dataDir)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load tables")
+		return "", "", nil, false
+	}
+	if len(tables) == 0 {
+		respondError(w, http.StatusNotFound, "No tables were found in the project pages")
+		return "", "", nil, false
+	}
+
+	switch r.URL.Query().Get("format") {
+	case "", "xlsx":
+		return "tables-xlsx", "tables.xlsx", func(path string, progress export.ProgressFunc) error {
+			return export.CreateTablesWorkbook(path, projectID, dataDir, progress)
+		}, true
+	case "csv":
+		return "tables-csv", "tables.zip", func(path string, progress export.ProgressFunc) error {
+			return export.CreateTablesArchive(path, projectID, dataDir, progress)
+		}, true
+	}
+
+	respondError(w, http.StatusBadRequest, "Format must be xlsx or csv")
+	return "", "", nil, false
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/user/scrapper/internal/models"
+	"github.com/user/scrapper/internal/scraper"
+	"github.com/user/scrapper/internal/warc"
+)
+
+// Import limits
+const (
+	maxImportMemory = 32 << 20 // Larger uploads are spooled to disk
+	maxImportUpload = 1 << 30  // Request body
+	maxImportSize   = 4 << 30  // Uncompressed WARC
+	importMaxDepth  = 5        // Default depth of imports
+)
+
+// HandleExportWARC serves the WARC file recorded during the crawl
+func HandleExportWARC(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	if refuseUnencrypted(w) {
+		return
+	}
+	if !checkExportable(w, projectID) {
+		return
+	}
+
+	file, err := os.Open(filepath.Join(dataDir, projectID, scraper.WARCFileName))
+	if os.IsNotExist(err) {
+		respondError(w, http.StatusNotFound, "No WARC file was recorded for this project")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to open WARC file")
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to open WARC file")
+		return
+	}
+
+	disableWriteTimeout(w)
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.warc.gz", projectID))
+	http.ServeContent(w, r, scraper.WARCFileName, info.ModTime(), file)
+}
+
+// HandleImportWARC builds a project from an uploaded WARC file without
+// going to the network. The multipart form has the WARC (optionally
+// gzipped) in "file" and crawl settings as ScrapeRequest JSON in "options";
+// url defaults to the first archived HTML page and depth to 5.
+func HandleImportWARC(w http.ResponseWriter, r *http.Request) {
+	disableReadTimeout(w)
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportUpload)
+	if err := r.ParseMultipartForm(maxImportMemory); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("WARC upload exceeds %d bytes", maxImportUpload))
+			return
+		}
+		respondError(w, http.StatusBadRequest, "Expected a multipart form with a WARC file")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	upload, fileHeader, err := r.FormFile("file")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "WARC file is required")
+		return
+	}
+	defer upload.Close()
+
+	var req models.ScrapeRequest
+	if options := r.FormValue("options"); options != "" {
+		if err := json.Unmarshal([]byte(options), &req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid options")
+			return
+		}
+	}
+	if req.LinkCheck != nil {
+		respondError(w, http.StatusBadRequest, "link_check is not available for imports")
+		return
+	}
+
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to store WARC file")
+		return
+	}
+	archive, err := warc.NewArchive(upload, dataDir, maxImportSize)
+	if errors.Is(err, warc.ErrArchiveTooLarge) {
+		respondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Uncompressed WARC file exceeds %d bytes", int64(maxImportSize)))
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid WARC file: %v", err))
+		return
+	}
+
+	if req.URL == "" {
+		req.URL = archive.FirstPage()
+	}
+	if req.Depth == 0 {
+		req.Depth = importMaxDepth
+	}
+
+	urlPrefix, err := validateScrapeRequest(&req)
+	if err != nil {
+		archive.Close()
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	project := newProject(&req, urlPrefix)
+	project.ImportedFrom = filepath.Base(fileHeader.Filename)
+
+	s, err := scraper.NewScraper(project, dataDir)
+	if err != nil {
+		archive.Close()
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create scraper: %v", err))
+		return
+	}
+	s.UseArchive(archive)
+
+	projectsMutex.Lock()
+	activeProjects[project.ID] = s
+	projectsMutex.Unlock()
+
+	go func() {
+		defer archive.Close()
+		runScraper(s, project.ID)
+	}()
+
+	respondJSON(w, http.StatusAccepted, models.ScrapeResponse{
+		ProjectID: project.ID,
+		Status:    models.StatusStarted,
+	})
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// zipPasswordHeader carries the password of an encrypted ZIP export. The
+// password is never accepted in the URL, which ends up in request logs.
+const zipPasswordHeader = "X-Export-Password"
+
+// Password limits
+const (
+	maxZipPasswordLength = 1024
+	maxZipOptionsBody    = 64 << 10
+)
+
+// requireZipPassword refuses unencrypted ZIP exports when set. Exports that
+// cannot be password-protected, which is every format but export/zip, are
+// refused as well.
+var requireZipPassword = getEnvOrDefault("REQUIRE_ZIP_PASSWORD", "") == "true"
+
+// zipExportRequest is the optional JSON body of POST /export/zip
+type zipExportRequest struct {
+	Password string `json:"password"`
+}
+
+// zipPassword reads the password of a ZIP export from the header or, for
+// POST, the JSON body. It writes an error response and returns false for
+// invalid or missing passwords.
+func zipPassword(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if r.URL.Query().Has("password") {
+		respondError(w, http.StatusBadRequest, "Password must be sent in the "+zipPasswordHeader+" header or the request body, not the URL")
+		return "", false
+	}
+
+	password := r.Header.Get(zipPasswordHeader)
+	if r.Method == http.MethodPost {
+		var req zipExportRequest
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxZipOptionsBody)).Decode(&req)
+		if err != nil && !errors.Is(err, io.EOF) {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return "", false
+		}
+		if req.Password != "" {
+			password = req.Password
+		}
+	}
+
+	if len(password) > maxZipPasswordLength {
+		respondError(w, http.StatusBadRequest, "Password is too long")
+		return "", false
+	}
+	if password == "" && requireZipPassword {
+		respondError(w, http.StatusBadRequest, "This server only exports password-protected ZIP archives")
+		return "", false
+	}
+	return password, true
+}
+
+// refuseUnencrypted writes an error response and returns true when the
+// server only exports password-protected ZIP archives. Every handler that
+// serves project content in another format calls it first.
+func refuseUnencrypted(w http.ResponseWriter) bool {
+	if !requireZipPassword {
+		return false
+	}
+	respondError(w, http.StatusBadRequest, "This server only exports password-protected ZIP archives; use export/zip with a password")
+	return true
+}
@@ -0,0 +1,203 @@
+package export
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"hash"
+	"io"
+	"unicode/utf8"
+)
+
+// WinZip AES encryption (AE-2) with 256-bit keys, as read by 7-Zip,
+// WinZip and libarchive based tools
+const (
+	methodWinZipAES   = 99
+	aesExtraID        = 0x9901
+	aesVendorVersion  = 2 // AE-2: CRC is left out, the HMAC covers the data
+	aesStrength256    = 3
+	aesKeySize        = 32
+	aesSaltSize       = 16
+	aesIterations     = 1000
+	aesVerifierSize   = 2
+	aesMACSize        = 10
+	zipVersionAES     = 51
+	zipFlagEncrypted  = 0x1
+	zipFlagDescriptor = 0x8
+	zipFlagUTF8       = 0x800
+)
+
+// aesEntryWriter deflates and encrypts one archive entry. Sizes are only
+// known at the end, so they go to the data descriptor and the central
+// directory.
+type aesEntryWriter struct {
+	header     *zip.FileHeader
+	compressor *flate.Writer
+	cipher     *aesCTRWriter
+	raw        *countingWriter
+	size       int64
+}
+
+// createAESEntry adds an encrypted file entry to the archive. The entry
+// must be closed before the next one is created.
+func createAESEntry(zipWriter *zip.Writer, header *zip.FileHeader, password string) (*aesEntryWriter, error) {
+	salt := make([]byte, aesSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return newAESEntry(zipWriter, header, password, salt)
+}
+
+// newAESEntry adds an encrypted file entry with the given salt
+func newAESEntry(zipWriter *zip.Writer, header *zip.FileHeader, password string, salt []byte) (*aesEntryWriter, error) {
+	encryptionKey, macKey, verifier, err := aesKeys(password, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	extra := make([]byte, 11)
+	binary.LittleEndian.PutUint16(extra[0:], aesExtraID)
+	binary.LittleEndian.PutUint16(extra[2:], 7)
+	binary.LittleEndian.PutUint16(extra[4:], aesVendorVersion)
+	copy(extra[6:], "AE")
+	extra[8] = aesStrength256
+	binary.LittleEndian.PutUint16(extra[9:], zip.Deflate)
+
+	header.Method = methodWinZipAES
+	header.Flags |= zipFlagEncrypted | zipFlagDescriptor
+	if !isASCII(header.Name) && utf8.ValidString(header.Name) {
+		header.Flags |= zipFlagUTF8
+	}
+	header.CRC32 = 0
+	header.CompressedSize64, header.UncompressedSize64 = 0, 0
+	header.ReaderVersion = zipVersionAES
+	header.CreatorVersion = header.CreatorVersion&0xff00 | zipVersionAES
+	header.Extra = append(header.Extra, extra...)
+
+	writer, err := zipWriter.CreateRaw(header)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := &countingWriter{w: writer}
+	if _, err := raw.Write(salt); err != nil {
+		return nil, err
+	}
+	if _, err := raw.Write(verifier); err != nil {
+		return nil, err
+	}
+
+	stream := &aesCTRWriter{block: block, mac: hmac.New(sha1.New, macKey), w: raw}
+	compressor, err := flate.NewWriter(stream, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	return &aesEntryWriter{header: header, compressor: compressor, cipher: stream, raw: raw}, nil
+}
+
+func (e *aesEntryWriter) Write(p []byte) (int, error) {
+	n, err := e.compressor.Write(p)
+	e.size += int64(n)
+	return n, err
+}
+
+// Close writes the authentication code and records the entry sizes
+func (e *aesEntryWriter) Close() error {
+	if err := e.compressor.Close(); err != nil {
+		return err
+	}
+	if _, err := e.raw.Write(e.cipher.mac.Sum(nil)[:aesMACSize]); err != nil {
+		return err
+	}
+
+	// The writer reads these when it writes the data descriptor and the
+	// central directory
+	e.header.CompressedSize64 = uint64(e.raw.count)
+	e.header.UncompressedSize64 = uint64(e.size)
+	e.header.CompressedSize = uint32(min(e.header.CompressedSize64, uint32max))
+	e.header.UncompressedSize = uint32(min(e.header.UncompressedSize64, uint32max))
+	return nil
+}
+
+// aesKeys derives the encryption key, the HMAC key and the password
+// verifier of an entry from the password and its salt
+func aesKeys(password string, salt []byte) (encryptionKey, macKey, verifier []byte, err error) {
+	keys, err := pbkdf2.Key(sha1.New, password, salt, aesIterations, 2*aesKeySize+aesVerifierSize)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return keys[:aesKeySize], keys[aesKeySize : 2*aesKeySize], keys[2*aesKeySize:], nil
+}
+
+// uint32max marks ZIP64 sizes in 32-bit header fields
+const uint32max = 1<<32 - 1
+
+// aesCTRWriter encrypts with AES in the counter mode of WinZip AES: a
+// little-endian block counter starting at 1. The ciphertext is added to
+// the HMAC.
+type aesCTRWriter struct {
+	block     cipher.Block
+	mac       hash.Hash
+	w         io.Writer
+	counter   uint64
+	keystream [aes.BlockSize]byte
+	used      int // Bytes of keystream consumed
+	buf       []byte
+}
+
+func (c *aesCTRWriter) Write(p []byte) (int, error) {
+	if cap(c.buf) < len(p) {
+		c.buf = make([]byte, len(p))
+	}
+	out := c.buf[:len(p)]
+	for i, b := range p {
+		if c.counter == 0 || c.used == aes.BlockSize {
+			c.counter++
+			var counterBlock [aes.BlockSize]byte
+			binary.LittleEndian.PutUint64(counterBlock[:], c.counter)
+			c.block.Encrypt(c.keystream[:], counterBlock[:])
+			c.used = 0
+		}
+		out[i] = b ^ c.keystream[c.used]
+		c.used++
+	}
+
+	c.mac.Write(out)
+	if _, err := c.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w     io.Writer
+	count int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.count += int64(n)
+	return n, err
+}
+
+// isASCII reports whether s has only ASCII characters
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
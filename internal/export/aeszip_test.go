@@ -0,0 +1,229 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// Known answers computed with Python hashlib (PBKDF2-HMAC-SHA1, HMAC-SHA1)
+// and OpenSSL (AES-256 on little-endian counter blocks starting at 1)
+const (
+	katPassword      = "correct horse"
+	katEncryptionKey = "9dd856c376b8b2b713b6be074054cb88a46556e01185ea8d0d267f339c652169"
+	katMACKey        = "d5f1d5b9a38171596e125fa57aa703cc150ac5962c309b2a8735205e0294993c"
+	katVerifier      = "2d15"
+	katPlaintext     = "WinZip AES counter mode spans several blocks of sixteen bytes."
+	katCiphertext    = "c2892cdafd2c988e31c060fb489ddbfb5b8d55182431fd40cc12b7166e9726a0" +
+		"396261bf07a15a88665efb2f547c8c480504bc0733a0518c4152ef71e333"
+	katMAC = "dca1ce3f8196acc6f4dc"
+)
+
+// katSalt is the salt of the known answers: bytes 0 to 15
+func katSalt() []byte {
+	salt := make([]byte, aesSaltSize)
+	for i := range salt {
+		salt[i] = byte(i)
+	}
+	return salt
+}
+
+func TestAESKeysKnownAnswer(t *testing.T) {
+	encryptionKey, macKey, verifier, err := aesKeys(katPassword, katSalt())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		got, want string
+	}{
+		{"encryption key", hex.EncodeToString(encryptionKey), katEncryptionKey},
+		{"HMAC key", hex.EncodeToString(macKey), katMACKey},
+		{"verifier", hex.EncodeToString(verifier), katVerifier},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestAESCTRKnownAnswer(t *testing.T) {
+	encryptionKey, macKey, _, err := aesKeys(katPassword, katSalt())
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := aes.NewCipher(encryptionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Uneven writes cross block boundaries mid-write
+	var out bytes.Buffer
+	stream := &aesCTRWriter{block: block, mac: hmac.New(sha1.New, macKey), w: &out}
+	for _, part := range []string{katPlaintext[:7], katPlaintext[7:16], katPlaintext[16:40], katPlaintext[40:]} {
+		if _, err := stream.Write([]byte(part)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := hex.EncodeToString(out.Bytes()); got != katCiphertext {
+		t.Errorf("ciphertext = %s, want %s", got, katCiphertext)
+	}
+	if got := hex.EncodeToString(stream.mac.Sum(nil)[:aesMACSize]); got != katMAC {
+		t.Errorf("mac = %s, want %s", got, katMAC)
+	}
+}
+
+func TestAESEntryRoundTrip(t *testing.T) {
+	content := []byte(strings.Repeat("Zażółć gęślą jaźń. ", 2000))
+
+	var archive bytes.Buffer
+	zipWriter := zip.NewWriter(&archive)
+	entry, err := newAESEntry(zipWriter, &zip.FileHeader{Name: "pages/strona.html"}, katPassword, katSalt())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := entry.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reader.File) != 1 {
+		t.Fatalf("got %d entries, want 1", len(reader.File))
+	}
+	file := reader.File[0]
+	if file.Method != methodWinZipAES || file.Flags&zipFlagEncrypted == 0 {
+		t.Fatalf("method %d, flags %#x: entry is not AES encrypted", file.Method, file.Flags)
+	}
+	if file.UncompressedSize64 != uint64(len(content)) {
+		t.Errorf("uncompressed size = %d, want %d", file.UncompressedSize64, len(content))
+	}
+	checkAESExtra(t, file.Extra)
+
+	raw, err := file.OpenRaw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uint64(len(data)) != file.CompressedSize64 {
+		t.Fatalf("read %d bytes, compressed size is %d", len(data), file.CompressedSize64)
+	}
+
+	plain, err := decryptAESEntry(data, katPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plain, content) {
+		t.Fatal("decrypted content differs")
+	}
+
+	// The verifier and the HMAC both reject another password
+	if _, err := decryptAESEntry(data, "wrong horse"); err != errWrongVerifier {
+		t.Errorf("wrong password: err = %v, want %v", err, errWrongVerifier)
+	}
+	if ok := checkAESMAC(data, "wrong horse"); ok {
+		t.Error("wrong password: HMAC accepted")
+	}
+
+	// A flipped ciphertext bit fails authentication
+	tampered := bytes.Clone(data)
+	tampered[aesSaltSize+aesVerifierSize] ^= 1
+	if _, err := decryptAESEntry(tampered, katPassword); err != errBadMAC {
+		t.Errorf("tampered data: err = %v, want %v", err, errBadMAC)
+	}
+}
+
+// checkAESExtra checks the WinZip AES extra field of an entry
+func checkAESExtra(t *testing.T, extra []byte) {
+	t.Helper()
+	for len(extra) >= 4 {
+		id, size := binary.LittleEndian.Uint16(extra), int(binary.LittleEndian.Uint16(extra[2:]))
+		if len(extra) < 4+size {
+			break
+		}
+		field := extra[4 : 4+size]
+		extra = extra[4+size:]
+		if id != aesExtraID {
+			continue
+		}
+		if size != 7 || binary.LittleEndian.Uint16(field) != aesVendorVersion || string(field[2:4]) != "AE" ||
+			field[4] != aesStrength256 || binary.LittleEndian.Uint16(field[5:]) != zip.Deflate {
+			t.Errorf("AES extra field = %x", field)
+		}
+		return
+	}
+	t.Error("AES extra field is missing")
+}
+
+// Errors of decryptAESEntry
+var (
+	errWrongVerifier = errors.New("password verifier does not match")
+	errBadMAC        = errors.New("authentication code does not match")
+)
+
+// decryptAESEntry reads the raw data of an AE-2 entry the way an extracting
+// tool does: check the verifier and the HMAC, decrypt, inflate
+func decryptAESEntry(data []byte, password string) ([]byte, error) {
+	salt := data[:aesSaltSize]
+	verifier := data[aesSaltSize : aesSaltSize+aesVerifierSize]
+	ciphertext := data[aesSaltSize+aesVerifierSize : len(data)-aesMACSize]
+
+	encryptionKey, _, expected, err := aesKeys(password, salt)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(verifier, expected) {
+		return nil, errWrongVerifier
+	}
+	if !checkAESMAC(data, password) {
+		return nil, errBadMAC
+	}
+
+	// Counter mode decrypts by encrypting again
+	block, err := aes.NewCipher(encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	var compressed bytes.Buffer
+	stream := &aesCTRWriter{block: block, mac: sha1.New(), w: &compressed}
+	if _, err := stream.Write(ciphertext); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(flate.NewReader(&compressed))
+}
+
+// checkAESMAC checks the authentication code of an AE-2 entry
+func checkAESMAC(data []byte, password string) bool {
+	salt := data[:aesSaltSize]
+	ciphertext := data[aesSaltSize+aesVerifierSize : len(data)-aesMACSize]
+
+	_, macKey, _, err := aesKeys(password, salt)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha1.New, macKey)
+	mac.Write(ciphertext)
+	return hmac.Equal(mac.Sum(nil)[:aesMACSize], data[len(data)-aesMACSize:])
+}
@@ -0,0 +1,263 @@
+package export
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/user/scrapper/internal/models"
+	"github.com/user/scrapper/internal/scraper"
+)
+
+// Project archive formats
+const (
+	ArchiveZip    = "zip"
+	ArchiveTarGz  = "tar.gz"
+	ArchiveTarZst = "tar.zst"
+)
+
+// ErrUnknownArchiveFormat is returned for unsupported archive formats
+var ErrUnknownArchiveFormat = errors.New("unknown archive format")
+
+// Entries of deterministic archives get this modification time and these
+// permissions instead of the ones on disk
+var deterministicTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+const (
+	deterministicFileMode = 0644
+	deterministicDirMode  = 0755
+)
+
+// ArchiveOptions controls which project files go into an archive and how
+// they are stored
+type ArchiveOptions struct {
+	Deterministic bool                     // Sorted entries with fixed timestamps and permissions
+	Selection     *models.ArchiveSelection // Pages, assets and metadata to include; nil for everything
+}
+
+// archiveFile is a file or directory of a project archive
+type archiveFile struct {
+	path string // On disk
+	name string // In the archive, with forward slashes
+	info os.FileInfo
+}
+
+// projectFiles lists the files and directories of a project, leaving out
+// cached exports, the crawl WARC and files outside the selection. Parents come before their
+// contents; deterministic lists are sorted by archive name so they do not
+// depend on the filesystem.
+func projectFiles(projectDir string, opts ArchiveOptions) ([]archiveFile, error) {
+	var files []archiveFile
+	err := filepath.Walk(projectDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip the project directory itself
+		if path == projectDir {
+			return nil
+		}
+
+		// Cached exports are derived from the project
+		if info.IsDir() && isExportsDir(projectDir, path) {
+			return filepath.SkipDir
+		}
+
+		// The WARC repeats every page and asset and has its own export
+		if path == filepath.Join(projectDir, scraper.WARCFileName) {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(projectDir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(relPath)
+		if info.IsDir() {
+			name += "/"
+		}
+		files = append(files, archiveFile{path: path, name: name, info: info})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Selection != nil {
+		if files, err = selectFiles(projectDir, files, opts.Selection); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Deterministic {
+		sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+	}
+	return files, nil
+}
+
+// selectFiles keeps the selected files and the directories holding them
+func selectFiles(projectDir string, files []archiveFile, sel *models.ArchiveSelection) ([]archiveFile, error) {
+	selection, err := newArchiveSelection(projectDir, sel)
+	if err != nil {
+		return nil, err
+	}
+
+	parents := make(map[string]bool)
+	var size int64
+	for _, file := range files {
+		if file.info.IsDir() || !selection.includes(file.name) {
+			continue
+		}
+		size += file.info.Size()
+		for dir := path.Dir(file.name); dir != "."; dir = path.Dir(dir) {
+			parents[dir+"/"] = true
+		}
+	}
+	if sel.MaxSize > 0 && size > sel.MaxSize {
+		return nil, fmt.Errorf("%w: %d bytes selected, limit is %d", ErrArchiveTooLarge, size, sel.MaxSize)
+	}
+
+	kept := files[:0]
+	for _, file := range files {
+		keep := parents[file.name]
+		if !file.info.IsDir() {
+			keep = selection.includes(file.name)
+		}
+		if keep {
+			kept = append(kept, file)
+		}
+	}
+	return kept, nil
+}
+
+// countFiles returns the number of regular files in a list
+func countFiles(files []archiveFile) int {
+	total := 0
+	for _, file := range files {
+		if !file.info.IsDir() {
+			total++
+		}
+	}
+	return total
+}
+
+// CreateTarArchive writes a compressed tar of the project directory to
+// path. progress, if set, is called after each file.
+func CreateTarArchive(path, projectID, dataDir, format string, opts ArchiveOptions, progress ProgressFunc) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer file.Close()
+
+	if err := writeProjectTar(file, filepath.Join(dataDir, projectID), format, opts, progress); err != nil {
+		return fmt.Errorf("failed to archive project: %w", err)
+	}
+	return file.Close()
+}
+
+// StreamTarToWriter streams a compressed tar of the project directory
+func StreamTarToWriter(w io.Writer, projectID, dataDir, format string, opts ArchiveOptions) error {
+	return writeProjectTar(w, filepath.Join(dataDir, projectID), format, opts, nil)
+}
+
+// writeProjectTar archives a project directory as tar.gz or tar.zst
+func writeProjectTar(w io.Writer, projectDir, format string, opts ArchiveOptions, progress ProgressFunc) error {
+	files, err := projectFiles(projectDir, opts)
+	if err != nil {
+		return err
+	}
+	total := countFiles(files)
+
+	var compressor io.WriteCloser
+	switch format {
+	case ArchiveTarGz:
+		compressor = gzip.NewWriter(w)
+	case ArchiveTarZst:
+		// One goroutine keeps the output independent of scheduling
+		encoder, err := zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return err
+		}
+		compressor = encoder
+	default:
+		return ErrUnknownArchiveFormat
+	}
+
+	tarWriter := tar.NewWriter(compressor)
+	done := 0
+	for _, file := range files {
+		if err := writeTarEntry(tarWriter, file, opts.Deterministic); err != nil {
+			return err
+		}
+		if file.info.IsDir() {
+			continue
+		}
+
+		done++
+		if progress != nil {
+			progress(done, total)
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	return compressor.Close()
+}
+
+// writeTarEntry adds a file or directory to a tar archive
+func writeTarEntry(tarWriter *tar.Writer, file archiveFile, deterministic bool) error {
+	header, err := tar.FileInfoHeader(file.info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = file.name
+
+	if deterministic {
+		mode := int64(deterministicFileMode)
+		if file.info.IsDir() {
+			mode = deterministicDirMode
+		}
+		*header = tar.Header{
+			Typeflag: header.Typeflag,
+			Name:     header.Name,
+			Size:     header.Size,
+			Mode:     mode,
+			ModTime:  deterministicTime,
+		}
+	}
+
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+	if file.info.IsDir() {
+		return nil
+	}
+
+	source, err := os.Open(file.path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	_, err = io.Copy(tarWriter, io.LimitReader(source, header.Size))
+	return err
+}
+
+// zipEntryMode returns the permissions of a deterministic ZIP entry
+func zipEntryMode(info os.FileInfo) fs.FileMode {
+	if info.IsDir() {
+		return fs.ModeDir | deterministicDirMode
+	}
+	return deterministicFileMode
+}
@@ -0,0 +1,136 @@
+package export
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestProject creates a small project directory under dataDir
+func writeTestProject(t *testing.T, dataDir, projectID string) string {
+	t.Helper()
+	projectDir := filepath.Join(dataDir, projectID)
+	files := map[string]string{
+		"project.json":                `{"project_id":"` + projectID + `"}`,
+		"index.html":                  "<html><body><a href=\"pages/b.html\">b</a></body></html>",
+		"pages/b.html":                "<html><body>b</body></html>",
+		"assets/css/site.css":         "body { color: black }",
+		"assets/img/empty/.keep":      "",
+		"exports/old.zip":             "cached export, never archived",
+		"pages/zażółć.content.html":   "<p>treść</p>",
+		"assets/other/deep/nested.js": "console.log(1)",
+	}
+	for name, content := range files {
+		path := filepath.Join(projectDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return projectDir
+}
+
+// touchProject sets the modification time and permissions of everything in
+// a project directory
+func touchProject(t *testing.T, projectDir string, modTime time.Time, fileMode os.FileMode) {
+	t.Helper()
+	err := filepath.Walk(projectDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			if err := os.Chmod(path, fileMode); err != nil {
+				return err
+			}
+		}
+		return os.Chtimes(path, modTime, modTime)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeterministicArchives(t *testing.T) {
+	const projectID = "project"
+	dataDir := t.TempDir()
+	projectDir := writeTestProject(t, dataDir, projectID)
+
+	formats := []struct {
+		name  string
+		write func(opts ArchiveOptions) ([]byte, error)
+	}{
+		{ArchiveZip, func(opts ArchiveOptions) ([]byte, error) {
+			var buf bytes.Buffer
+			err := StreamZipToWriter(&buf, projectID, dataDir, ZipOptions{ArchiveOptions: opts})
+			return buf.Bytes(), err
+		}},
+		{ArchiveTarGz, func(opts ArchiveOptions) ([]byte, error) {
+			var buf bytes.Buffer
+			err := StreamTarToWriter(&buf, projectID, dataDir, ArchiveTarGz, opts)
+			return buf.Bytes(), err
+		}},
+		{ArchiveTarZst, func(opts ArchiveOptions) ([]byte, error) {
+			var buf bytes.Buffer
+			err := StreamTarToWriter(&buf, projectID, dataDir, ArchiveTarZst, opts)
+			return buf.Bytes(), err
+		}},
+	}
+
+	for _, format := range formats {
+		t.Run(format.name, func(t *testing.T) {
+			archive := func(modTime time.Time, fileMode os.FileMode, deterministic bool) []byte {
+				t.Helper()
+				touchProject(t, projectDir, modTime, fileMode)
+				data, err := format.write(ArchiveOptions{Deterministic: deterministic})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return data
+			}
+
+			first := archive(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), 0644, true)
+			second := archive(time.Date(2025, 7, 9, 8, 30, 15, 0, time.UTC), 0600, true)
+			if !bytes.Equal(first, second) {
+				t.Fatal("deterministic archives differ after mtime and permission changes")
+			}
+
+			// Without the option the same changes show up in the archive
+			plain := archive(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), 0644, false)
+			changed := archive(time.Date(2025, 7, 9, 8, 30, 15, 0, time.UTC), 0600, false)
+			if bytes.Equal(plain, changed) {
+				t.Fatal("plain archives do not record mtimes, the test cannot tell determinism apart")
+			}
+		})
+	}
+}
+
+func TestProjectFilesLeaveOutDerivedFiles(t *testing.T) {
+	projectDir := writeTestProject(t, t.TempDir(), "project")
+	if err := os.WriteFile(filepath.Join(projectDir, "crawl.warc.gz"), []byte("recorded exchanges"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := projectFiles(projectDir, ArchiveOptions{Deterministic: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]bool)
+	for _, file := range files {
+		names[file.name] = true
+	}
+
+	for _, name := range []string{"crawl.warc.gz", "exports/", "exports/old.zip"} {
+		if names[name] {
+			t.Errorf("archive includes %s", name)
+		}
+	}
+	for _, name := range []string{"project.json", "index.html", "pages/b.html", "assets/css/site.css"} {
+		if !names[name] {
+			t.Errorf("archive lacks %s", name)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package export
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// archiveLinks maps links found in saved pages to files of an export
+// archive. Pages map to their converted file and downloaded assets are
+// included under their project path.
+type archiveLinks struct {
+	projectDir  string
+	byFile      map[string]string // Raw page file -> archive path
+	byURL       map[string]string // Page URL -> archive path
+	attachments map[string]bool   // Linked project files (slash paths) to include
+	accept      func(rel string) bool
+}
+
+// newArchiveLinks creates links for a project; accept, if set, further
+// limits which assets may be included
+func newArchiveLinks(projectDir string, accept func(rel string) bool) *archiveLinks {
+	return &archiveLinks{
+		projectDir:  projectDir,
+		byFile:      make(map[string]string),
+		byURL:       make(map[string]string),
+		attachments: make(map[string]bool),
+		accept:      accept,
+	}
+}
+
+// addPage records the archive path of a chapter
+func (l *archiveLinks) addPage(chapter *pdfChapter, archivePath string) {
+	l.byFile[chapter.Path] = archivePath
+	if chapter.URL != "" {
+		l.byURL[chapter.URL] = archivePath
+	}
+}
+
+// rewrite points a link or image found in a page to its place in the archive,
+// relative to the archive file from. Anything not in the archive is resolved
+// against pageURL back to the site; local is false then.
+func (l *archiveLinks) rewrite(from, htmlDir, pageURL, href string) (target string, local bool) {
+	href = strings.TrimSpace(href)
+	fragment := ""
+	if i := strings.IndexByte(href, '#'); i >= 0 {
+		href, fragment = href[:i], href[i:]
+	}
+	if href == "" {
+		return fragment, true
+	}
+
+	if page, ok := l.byURL[href]; ok {
+		return relativeArchivePath(from, page) + fragment, true
+	}
+
+	if file := resolveProjectFile(l.projectDir, htmlDir, href); file != "" {
+		if page, ok := l.byFile[file]; ok {
+			return relativeArchivePath(from, page) + fragment, true
+		}
+		if rel, ok := l.attach(file); ok {
+			return relativeArchivePath(from, rel) + fragment, true
+		}
+	}
+
+	if base, err := url.Parse(pageURL); err == nil && pageURL != "" {
+		if ref, err := url.Parse(href); err == nil {
+			return base.ResolveReference(ref).String() + fragment, false
+		}
+	}
+	return href + fragment, false
+}
+
+// attach includes a project file under assets/ and returns its archive path
+func (l *archiveLinks) attach(file string) (string, bool) {
+	rel, err := filepath.Rel(l.projectDir, file)
+	rel = filepath.ToSlash(rel)
+	if err != nil || !strings.HasPrefix(rel, "assets/") || (l.accept != nil && !l.accept(rel)) {
+		return "", false
+	}
+	l.attachments[rel] = true
+	return rel, true
+}
+
+// writeAttachments adds the included assets to the archive in path order,
+// each under prefix + its project path
+func (l *archiveLinks) writeAttachments(zipWriter *zip.Writer, prefix string) error {
+	for _, name := range l.sortedAttachments() {
+		if err := addFileToZip(zipWriter, filepath.Join(l.projectDir, filepath.FromSlash(name)), prefix+name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedAttachments lists the included assets in path order
+func (l *archiveLinks) sortedAttachments() []string {
+	names := make([]string, 0, len(l.attachments))
+	for name := range l.attachments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// uniqueArchivePath appends a counter to paths already taken
+func uniqueArchivePath(p string, used map[string]bool) string {
+	candidate := p
+	ext := path.Ext(p)
+	for i := 2; used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(p, ext), i, ext)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// relativeArchivePath is the link from one archive file to another
+func relativeArchivePath(from, to string) string {
+	rel, err := filepath.Rel(filepath.FromSlash(path.Dir(from)), filepath.FromSlash(to))
+	if err != nil {
+		return to
+	}
+	return (&url.URL{Path: filepath.ToSlash(rel)}).EscapedPath()
+}
+
+// addFileToZip copies a file into the archive
+func addFileToZip(zipWriter *zip.Writer, filePath, name string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+
+	writer, err := zipWriter.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(writer, file)
+	return err
+}
+
+// writeZipEntry writes a compressed file to the archive
+func writeZipEntry(zipWriter *zip.Writer, name string, data []byte, modified time.Time) error {
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+	writer, err := zipWriter.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(data)
+	return err
+}
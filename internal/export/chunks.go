@@ -0,0 +1,318 @@
+package export
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/user/scrapper/internal/models"
+	"github.com/user/scrapper/internal/scraper"
+)
+
+// Chunk size defaults and limits
+const (
+	defaultChunkChars  = 1000
+	defaultChunkTokens = 200
+	maxChunkSize       = 100000
+)
+
+// paragraphSeparator joins paragraphs within a chunk
+const paragraphSeparator = "\n\n"
+
+// ValidateChunkOptions checks chunked export options
+func ValidateChunkOptions(opts *models.ChunkOptions) error {
+	switch opts.Unit {
+	case "", models.ChunkUnitChars, models.ChunkUnitTokens:
+	default:
+		return fmt.Errorf("unit must be %s or %s", models.ChunkUnitChars, models.ChunkUnitTokens)
+	}
+	if opts.Size < 0 || opts.Size > maxChunkSize {
+		return fmt.Errorf("size must be between 1 and %d, or 0 for the default", maxChunkSize)
+	}
+	if opts.Overlap < 0 || opts.Overlap >= chunkSize(*opts) {
+		return fmt.Errorf("overlap must be at least 0 and smaller than size")
+	}
+	return nil
+}
+
+// chunkSize returns the maximum chunk size, applying the unit default
+func chunkSize(opts models.ChunkOptions) int {
+	if opts.Size > 0 {
+		return opts.Size
+	}
+	if opts.Unit == models.ChunkUnitTokens {
+		return defaultChunkTokens
+	}
+	return defaultChunkChars
+}
+
+// WriteChunksJSONL splits the main content of every saved page into chunks
+// along its headings and writes them as JSON Lines, in the order pages were
+// first recorded. Chunks whose hash is in opts.KnownHashes are left out.
+func WriteChunksJSONL(w io.Writer, projectID, dataDir string, opts models.ChunkOptions) error {
+	projectDir := filepath.Join(dataDir, projectID)
+
+	entries, err := scraper.LoadManifest(projectID, dataDir)
+	if err != nil {
+		return err
+	}
+
+	known := make(map[string]bool, len(opts.KnownHashes))
+	for _, hash := range opts.KnownHashes {
+		known[hash] = true
+	}
+
+	chunker := &textChunker{
+		tokens:  opts.Unit == models.ChunkUnitTokens,
+		size:    chunkSize(opts),
+		overlap: opts.Overlap,
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	index := 0
+	for _, entry := range entries {
+		if entry.Kind != models.ManifestKindPage || entry.LocalPath == "" || entry.Error != "" {
+			continue
+		}
+		if !fileExists(filepath.Join(projectDir, filepath.FromSlash(entry.LocalPath))) {
+			continue
+		}
+
+		content := pageContent(entry, projectDir)
+		if content == nil {
+			continue
+		}
+		for _, chunk := range chunker.page(entry, chapterTitle(entry, index), textBlocks(content)) {
+			if known[chunk.Hash] {
+				continue
+			}
+			if err := encoder.Encode(chunk); err != nil {
+				return err
+			}
+		}
+		index++
+	}
+	return nil
+}
+
+// textSection is the text below one heading of a page
+type textSection struct {
+	path       []string // Heading texts from the top level down
+	anchor     string   // id of the nearest heading that has one
+	paragraphs []string
+}
+
+// pageSections groups the blocks of a page under their headings
+func pageSections(blocks []textBlock) []textSection {
+	var sections []textSection
+	var stack []textBlock
+	current := textSection{}
+
+	for _, block := range blocks {
+		if block.heading == 0 {
+			current.paragraphs = append(current.paragraphs, block.text)
+			continue
+		}
+
+		if len(current.paragraphs) > 0 {
+			sections = append(sections, current)
+		}
+		for len(stack) > 0 && stack[len(stack)-1].heading >= block.heading {
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, block)
+
+		current = textSection{path: make([]string, len(stack))}
+		for i, heading := range stack {
+			current.path[i] = heading.text
+			if heading.id != "" {
+				current.anchor = heading.id
+			}
+		}
+	}
+	if len(current.paragraphs) > 0 {
+		sections = append(sections, current)
+	}
+
+	return sections
+}
+
+// textChunker packs paragraphs into chunks of a maximum size
+type textChunker struct {
+	tokens  bool // Measure in whitespace-separated words instead of characters
+	size    int
+	overlap int
+}
+
+// page chunks the text blocks of a page
+func (c *textChunker) page(entry models.ManifestEntry, title string, blocks []textBlock) []models.TextChunk {
+	pageURL, _, _ := strings.Cut(entry.URL, "#")
+
+	var chunks []models.TextChunk
+	for _, section := range pageSections(blocks) {
+		chunkURL := pageURL
+		if section.anchor != "" {
+			chunkURL += "#" + section.anchor
+		}
+		headings := section.path
+		if headings == nil {
+			headings = []string{}
+		}
+
+		for _, text := range c.split(section.paragraphs) {
+			chunks = append(chunks, models.TextChunk{
+				ID:       fmt.Sprintf("%s#chunk-%d", pageURL, len(chunks)),
+				URL:      chunkURL,
+				PageURL:  entry.URL,
+				Title:    title,
+				Headings: headings,
+				Index:    len(chunks),
+				Text:     text,
+				Size:     c.measure(text),
+				Hash:     chunkHash(chunkURL, headings, text),
+			})
+		}
+	}
+	return chunks
+}
+
+// split packs the paragraphs of a section into chunks; every chunk but the
+// first starts with the overlap taken from the end of the previous one.
+// Parts of a paragraph longer than a chunk are joined with a space.
+func (c *textChunker) split(paragraphs []string) []string {
+	var chunks []string
+	current, size := "", 0
+
+	for _, paragraph := range paragraphs {
+		for i, piece := range c.pieces(paragraph) {
+			separator := paragraphSeparator
+			if i > 0 {
+				separator = " "
+			}
+			pieceSize := c.measure(piece)
+
+			if current != "" && size+c.separatorSize(separator)+pieceSize > c.size {
+				chunks = append(chunks, current)
+				previous := current
+				current, size = "", 0
+
+				if room := c.size - pieceSize - c.separatorSize(separator); room > 0 {
+					current = c.tail(previous, min(c.overlap, room))
+					size = c.measure(current)
+				}
+			}
+			if current != "" {
+				current += separator
+				size += c.separatorSize(separator)
+			}
+			current += piece
+			size += pieceSize
+		}
+	}
+	if current != "" {
+		chunks = append(chunks, current)
+	}
+
+	return chunks
+}
+
+// pieces splits a paragraph longer than the chunk size at word boundaries;
+// words longer than a chunk are cut
+func (c *textChunker) pieces(paragraph string) []string {
+	if c.measure(paragraph) <= c.size {
+		return []string{paragraph}
+	}
+
+	var pieces []string
+	var current []string
+	size := 0
+	for _, word := range strings.Fields(paragraph) {
+		for _, part := range c.cutWord(word) {
+			partSize := c.measure(part)
+			gap := 0
+			if len(current) > 0 && !c.tokens {
+				gap = 1
+			}
+			if len(current) > 0 && size+gap+partSize > c.size {
+				pieces = append(pieces, strings.Join(current, " "))
+				current, size, gap = nil, 0, 0
+			}
+			current = append(current, part)
+			size += gap + partSize
+		}
+	}
+	if len(current) > 0 {
+		pieces = append(pieces, strings.Join(current, " "))
+	}
+	return pieces
+}
+
+// cutWord splits a word longer than a character chunk
+func (c *textChunker) cutWord(word string) []string {
+	if c.tokens || utf8.RuneCountInString(word) <= c.size {
+		return []string{word}
+	}
+	runes := []rune(word)
+	var parts []string
+	for len(runes) > c.size {
+		parts = append(parts, string(runes[:c.size]))
+		runes = runes[c.size:]
+	}
+	return append(parts, string(runes))
+}
+
+// tail returns the last whole words of text that fit in size
+func (c *textChunker) tail(text string, size int) string {
+	if size <= 0 {
+		return ""
+	}
+	words := strings.Fields(text)
+	start, used := len(words), 0
+	for start > 0 {
+		next := used + 1
+		if !c.tokens {
+			next = used + utf8.RuneCountInString(words[start-1])
+			if start < len(words) {
+				next++
+			}
+		}
+		if next > size {
+			break
+		}
+		start, used = start-1, next
+	}
+	return strings.Join(words[start:], " ")
+}
+
+// measure returns the size of text in the chunker's unit
+func (c *textChunker) measure(text string) int {
+	if c.tokens {
+		return len(strings.Fields(text))
+	}
+	return utf8.RuneCountInString(text)
+}
+
+// separatorSize is the size added by joining two parts of a chunk
+func (c *textChunker) separatorSize(separator string) int {
+	if c.tokens {
+		return 0
+	}
+	return utf8.RuneCountInString(separator)
+}
+
+// chunkHash identifies the content of a chunk, including where it is
+func chunkHash(chunkURL string, headings []string, text string) string {
+	h := sha256.New()
+	io.WriteString(h, chunkURL)
+	h.Write([]byte{0})
+	io.WriteString(h, strings.Join(headings, "\x1f"))
+	h.Write([]byte{0})
+	io.WriteString(h, text)
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
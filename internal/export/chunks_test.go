@@ -0,0 +1,160 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/user/scrapper/internal/models"
+)
+
+func TestTextChunkerSplit(t *testing.T) {
+	tests := []struct {
+		name       string
+		chunker    textChunker
+		paragraphs []string
+		want       []string
+	}{
+		{
+			name:       "paragraphs fit in one chunk",
+			chunker:    textChunker{size: 100, overlap: 10},
+			paragraphs: []string{"alpha beta", "gamma"},
+			want:       []string{"alpha beta\n\ngamma"},
+		},
+		{
+			name:       "overlap across paragraphs",
+			chunker:    textChunker{size: 20, overlap: 10},
+			paragraphs: []string{"one two three four", "five six seven"},
+			// Only 4 characters are left next to the second paragraph
+			want: []string{"one two three four", "four\n\nfive six seven"},
+		},
+		{
+			name:       "overlap across pieces of a paragraph",
+			chunker:    textChunker{size: 12, overlap: 5},
+			paragraphs: []string{"aaa bbb ccc ddd eee"},
+			want:       []string{"aaa bbb ccc", "ccc ddd eee"},
+		},
+		{
+			name:       "no overlap",
+			chunker:    textChunker{size: 12},
+			paragraphs: []string{"aaa bbb ccc ddd eee"},
+			want:       []string{"aaa bbb ccc", "ddd eee"},
+		},
+		{
+			name:       "words longer than a chunk are cut",
+			chunker:    textChunker{size: 5, overlap: 2},
+			paragraphs: []string{"abcdefghijkl xy"},
+			// A full piece leaves no room for the overlap
+			want: []string{"abcde", "fghij", "kl xy"},
+		},
+		{
+			name:       "words are cut at characters, not bytes",
+			chunker:    textChunker{size: 4},
+			paragraphs: []string{"żółwiżółw"},
+			want:       []string{"żółw", "iżół", "w"},
+		},
+		{
+			name:       "tokens",
+			chunker:    textChunker{tokens: true, size: 3, overlap: 1},
+			paragraphs: []string{"a b c d e", "f g"},
+			// Separators take no tokens
+			want: []string{"a b c", "c d e", "e\n\nf g"},
+		},
+		{
+			name:       "long words are kept whole in tokens",
+			chunker:    textChunker{tokens: true, size: 2},
+			paragraphs: []string{"abcdefghijkl xy z"},
+			want:       []string{"abcdefghijkl xy", "z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.chunker.split(tt.paragraphs)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Fatalf("chunks = %q, want %q", got, tt.want)
+			}
+			for _, chunk := range got {
+				if size := tt.chunker.measure(chunk); size > tt.chunker.size {
+					t.Errorf("chunk %q has size %d, over %d", chunk, size, tt.chunker.size)
+				}
+			}
+		})
+	}
+}
+
+func TestTextChunkerTail(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens bool
+		text   string
+		size   int
+		want   string
+	}{
+		{name: "whole words", text: "one two three", size: 9, want: "two three"},
+		{name: "word cut off", text: "one two three", size: 8, want: "three"},
+		{name: "all", text: "one two", size: 20, want: "one two"},
+		{name: "too small", text: "one two three", size: 4, want: ""},
+		{name: "zero", text: "one two three", size: 0, want: ""},
+		{name: "multibyte", text: "zażółć gęślą", size: 5, want: "gęślą"},
+		{name: "paragraph break", text: "one\n\ntwo", size: 7, want: "one two"},
+		{name: "tokens", tokens: true, text: "one two three", size: 2, want: "two three"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &textChunker{tokens: tt.tokens}
+			if got := c.tail(tt.text, tt.size); got != tt.want {
+				t.Errorf("tail(%q, %d) = %q, want %q", tt.text, tt.size, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextChunkerHashes(t *testing.T) {
+	entry := models.ManifestEntry{URL: "https://example.com/guide"}
+	blocks := []textBlock{
+		{heading: 1, text: "Guide", id: "guide"},
+		{text: "Install the crawler first."},
+		{heading: 2, text: "Usage", id: "usage"},
+		{text: "Start a project."},
+	}
+	export := func(blocks []textBlock) []models.TextChunk {
+		c := &textChunker{size: 100, overlap: 10}
+		return c.page(entry, "Guide", blocks)
+	}
+
+	first := export(blocks)
+	if len(first) != 2 {
+		t.Fatalf("got %d chunks, want 2: %+v", len(first), first)
+	}
+	if first[0].Hash == first[1].Hash {
+		t.Error("chunks of different sections share a hash")
+	}
+	if want := chunkHash("https://example.com/guide#guide", []string{"Guide"}, "Install the crawler first."); first[0].Hash != want {
+		t.Errorf("hash = %s, want %s", first[0].Hash, want)
+	}
+
+	// Exporting again gives the same hashes, so known_hashes skips them
+	again := export(blocks)
+	for i := range first {
+		if again[i].Hash != first[i].Hash {
+			t.Errorf("chunk %d: hash changed between exports: %s, %s", i, first[i].Hash, again[i].Hash)
+		}
+	}
+
+	// Changing one section leaves the hashes of the others alone
+	changed := append([]textBlock(nil), blocks...)
+	changed[3].text = "Start a new project."
+	edited := export(changed)
+	if edited[0].Hash != first[0].Hash {
+		t.Error("hash of an unchanged section changed")
+	}
+	if edited[1].Hash == first[1].Hash {
+		t.Error("hash of a changed section stayed the same")
+	}
+
+	// The same text under another heading is a different chunk
+	if chunkHash("https://example.com/a", []string{"A"}, "text") == chunkHash("https://example.com/a", []string{"B"}, "text") {
+		t.Error("headings do not change the hash")
+	}
+}
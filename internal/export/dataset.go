@@ -0,0 +1,182 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/user/scrapper/internal/models"
+	"github.com/xuri/excelize/v2"
+)
+
+// datasetColumns are the fixed leading columns of tabular dataset exports
+var datasetColumns = []string{"schema", "url", "index", "extracted_at"}
+
+// WriteDatasetJSONL writes extracted records as JSON Lines
+func WriteDatasetJSONL(w io.Writer, records []models.DatasetRecord) error {
+	encoder := json.NewEncoder(w)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteDatasetCSV writes extracted records as a single CSV table.
+// Field columns follow schema definition order; lists are joined with "; ".
+func WriteDatasetCSV(w io.Writer, schemas []models.ExtractionSchema, records []models.DatasetRecord) error {
+	fieldNames := datasetFieldNames(schemas, "")
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(append(append([]string{}, datasetColumns...), fieldNames...)); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if err := writer.Write(datasetRow(record, fieldNames)); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteDatasetXLSX writes extracted records as a workbook with one sheet per schema
+func WriteDatasetXLSX(w io.Writer, schemas []models.ExtractionSchema, records []models.DatasetRecord) error {
+	workbook := excelize.NewFile()
+	defer workbook.Close()
+
+	bySchema := make(map[string][]models.DatasetRecord)
+	for _, record := range records {
+		bySchema[record.Schema] = append(bySchema[record.Schema], record)
+	}
+
+	first := true
+	used := make(map[string]bool) // Lower-case sheet names; Excel ignores case
+	for _, schema := range schemas {
+		sheet := uniqueSheetName(schema.Name, used)
+		if first {
+			if err := workbook.SetSheetName("Sheet1", sheet); err != nil {
+				return err
+			}
+			first = false
+		} else if _, err := workbook.NewSheet(sheet); err != nil {
+			return err
+		}
+
+		fieldNames := datasetFieldNames(schemas, schema.Name)
+		rows := [][]string{append(append([]string{}, datasetColumns...), fieldNames...)}
+		for _, record := range bySchema[schema.Name] {
+			rows = append(rows, datasetRow(record, fieldNames))
+		}
+
+		if err := writeSheetRows(workbook, sheet, rows); err != nil {
+			return err
+		}
+	}
+
+	return workbook.Write(w)
+}
+
+// writeSheetRows writes string rows to a sheet starting at A1
+func writeSheetRows(workbook *excelize.File, sheet string, rows [][]string) error {
+	values := make([][]any, len(rows))
+	for i, row := range rows {
+		values[i] = toAnyRow(row)
+	}
+	return writeSheetValues(workbook, sheet, values)
+}
+
+// maxSheetName is the length limit of worksheet names, in characters
+const maxSheetName = 31
+
+// sheetName makes a valid, 31-character worksheet name
+func sheetName(name string) string {
+	name = strings.NewReplacer(":", "_", "\\", "_", "/", "_", "?", "_", "*", "_", "[", "_", "]", "_").Replace(name)
+	if runes := []rune(name); len(runes) > maxSheetName {
+		name = string(runes[:maxSheetName])
+	}
+	if name == "" {
+		name = "Sheet"
+	}
+	return name
+}
+
+// uniqueSheetName returns sheetName(name), with a " (2)", " (3)", ... suffix
+// if that is already in used, and adds the result to used. Different
+// schema names can map to one sheet name after sanitizing and truncation.
+func uniqueSheetName(name string, used map[string]bool) string {
+	base := sheetName(name)
+	sheet := base
+	for n := 2; used[strings.ToLower(sheet)]; n++ {
+		suffix := fmt.Sprintf(" (%d)", n)
+		runes := []rune(base)
+		if limit := maxSheetName - len(suffix); len(runes) > limit {
+			runes = runes[:limit]
+		}
+		sheet = string(runes) + suffix
+	}
+	used[strings.ToLower(sheet)] = true
+	return sheet
+}
+
+// datasetFieldNames returns field names of one schema, or the union over all schemas
+func datasetFieldNames(schemas []models.ExtractionSchema, schemaName string) []string {
+	var names []string
+	seen := make(map[string]bool)
+
+	for _, schema := range schemas {
+		if schemaName != "" && schema.Name != schemaName {
+			continue
+		}
+		for _, field := range schema.Fields {
+			if !seen[field.Name] {
+				seen[field.Name] = true
+				names = append(names, field.Name)
+			}
+		}
+	}
+
+	return names
+}
+
+// datasetRow flattens a record into a table row
+func datasetRow(record models.DatasetRecord, fieldNames []string) []string {
+	row := []string{
+		record.Schema,
+		record.URL,
+		strconv.Itoa(record.Index),
+		record.ExtractedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+
+	for _, name := range fieldNames {
+		row = append(row, datasetValue(record.Fields[name]))
+	}
+
+	return row
+}
+
+// datasetValue formats a field value (string or list) as a cell
+func datasetValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, "; ")
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, fmt.Sprint(item))
+		}
+		return strings.Join(parts, "; ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
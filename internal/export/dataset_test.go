@@ -0,0 +1,53 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/user/scrapper/internal/models"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestWriteDatasetXLSXSheetNames(t *testing.T) {
+	long := strings.Repeat("produkty-", 4)
+	names := []string{"a/b", "a_b", "A_B", long + "ceny", long + "opinie", ""}
+
+	var schemas []models.ExtractionSchema
+	var records []models.DatasetRecord
+	for _, name := range names {
+		schemas = append(schemas, models.ExtractionSchema{Name: name, Fields: []models.ExtractionField{{Name: "value"}}})
+		records = append(records, models.DatasetRecord{Schema: name, URL: "https://example.com/", Fields: map[string]any{"value": "from " + name}})
+	}
+
+	var buf bytes.Buffer
+	if err := WriteDatasetXLSX(&buf, schemas, records); err != nil {
+		t.Fatal(err)
+	}
+	workbook, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer workbook.Close()
+
+	want := []string{"a_b", "a_b (2)", "A_B (3)", "produkty-produkty-produkty-prod", "produkty-produkty-produkty- (2)", "Sheet"}
+	sheets := workbook.GetSheetList()
+	if strings.Join(sheets, "|") != strings.Join(want, "|") {
+		t.Fatalf("sheets = %q, want %q", sheets, want)
+	}
+
+	// Every schema keeps its own rows
+	for i, sheet := range sheets {
+		if utf8.RuneCountInString(sheet) > maxSheetName {
+			t.Errorf("sheet name %q is longer than %d characters", sheet, maxSheetName)
+		}
+		rows, err := workbook.GetRows(sheet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != 2 || rows[1][len(rows[1])-1] != "from "+names[i] {
+			t.Errorf("sheet %q rows = %q, want the record of schema %q", sheet, rows, names[i])
+		}
+	}
+}
@@ -0,0 +1,507 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/user/scrapper/internal/models"
+	"github.com/user/scrapper/internal/scraper"
+	"golang.org/x/net/html"
+)
+
+// EPUB container layout
+const (
+	epubMimeType   = "application/epub+zip"
+	epubContentDir = "OEBPS/"
+	epubNavPath    = "nav.xhtml"
+	epubDateFormat = "2006-01-02T15:04:05Z"
+)
+
+const epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="` + epubContentDir + `content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+// epubMediaTypes are the asset types included in books; images with other
+// types are replaced by their alt text
+var epubMediaTypes = map[string]string{
+	".css":   "text/css",
+	".png":   "image/png",
+	".jpg":   "image/jpeg",
+	".jpeg":  "image/jpeg",
+	".gif":   "image/gif",
+	".svg":   "image/svg+xml",
+	".webp":  "image/webp",
+	".woff":  "font/woff",
+	".woff2": "font/woff2",
+	".ttf":   "font/ttf",
+	".otf":   "font/otf",
+}
+
+// epubElements are kept in chapters; other elements are replaced by their
+// content
+var epubElements = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true, "header": true,
+	"footer": true, "nav": true, "aside": true, "figure": true, "figcaption": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "li": true, "dl": true, "dt": true, "dd": true,
+	"pre": true, "blockquote": true, "hr": true, "address": true, "details": true, "summary": true,
+	"table": true, "caption": true, "colgroup": true, "col": true, "thead": true, "tbody": true,
+	"tfoot": true, "tr": true, "th": true, "td": true,
+	"a": true, "abbr": true, "b": true, "bdi": true, "bdo": true, "br": true, "cite": true,
+	"code": true, "data": true, "del": true, "dfn": true, "em": true, "i": true, "img": true,
+	"ins": true, "kbd": true, "mark": true, "q": true, "s": true, "samp": true, "small": true,
+	"span": true, "strong": true, "sub": true, "sup": true, "time": true, "u": true,
+	"var": true, "wbr": true,
+}
+
+// epubDroppedElements are removed with their content, next to skippedElements
+var epubDroppedElements = map[string]bool{
+	"video": true, "audio": true, "source": true, "track": true, "map": true,
+	"link": true, "meta": true, "base": true, "title": true,
+}
+
+// epubAttributes are kept on every element; epubElementAttributes only on one
+var epubAttributes = map[string]bool{"id": true, "class": true, "title": true, "lang": true, "dir": true}
+
+var epubElementAttributes = map[string]map[string]bool{
+	"a":        {"href": true},
+	"img":      {"src": true, "alt": true},
+	"td":       {"colspan": true, "rowspan": true},
+	"th":       {"colspan": true, "rowspan": true, "scope": true},
+	"ol":       {"start": true, "reversed": true},
+	"li":       {"value": true},
+	"col":      {"span": true},
+	"colgroup": {"span": true},
+	"time":     {"datetime": true},
+}
+
+var (
+	// xmlIDPattern matches IDs valid in XHTML
+	xmlIDPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+	// languagePattern matches BCP 47 language tags
+	languagePattern = regexp.MustCompile(`^[A-Za-z]{1,8}(-[A-Za-z0-9]{1,8})*$`)
+	// cssURLPattern matches url() references in stylesheets
+	cssURLPattern = regexp.MustCompile(`url\(\s*['"]?([^'")]*)['"]?\s*\)`)
+)
+
+// CreateEPUB writes an EPUB 3 book of the project to epubPath: one cleaned
+// XHTML chapter per saved page in crawl order, the images and stylesheets
+// they use, and a navigation document following the site hierarchy.
+// progress, if set, is called after each chapter.
+func CreateEPUB(epubPath, projectID, dataDir string, progress ProgressFunc) error {
+	projectDir := filepath.Join(dataDir, projectID)
+
+	project, err := scraper.LoadProject(projectID, dataDir)
+	if err != nil {
+		return fmt.Errorf("failed to load project: %w", err)
+	}
+
+	chapters, err := loadChapters(projectID, dataDir, models.PDFOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list pages: %w", err)
+	}
+	if len(chapters) == 0 {
+		return ErrNoChapters
+	}
+
+	links := newArchiveLinks(projectDir, func(rel string) bool {
+		return epubMediaType(rel) != ""
+	})
+
+	paths := make([]string, len(chapters))
+	for i, chapter := range chapters {
+		paths[i] = fmt.Sprintf("text/chapter-%04d.xhtml", i+1)
+		links.addPage(chapter, paths[i])
+	}
+
+	file, err := os.Create(epubPath)
+	if err != nil {
+		return fmt.Errorf("failed to create epub file: %w", err)
+	}
+	defer file.Close()
+
+	zipWriter := zip.NewWriter(file)
+
+	// The mimetype must come first, uncompressed and without extra fields
+	mimetype := []byte(epubMimeType)
+	writer, err := zipWriter.CreateRaw(&zip.FileHeader{
+		Name:               "mimetype",
+		Method:             zip.Store,
+		CRC32:              crc32.ChecksumIEEE(mimetype),
+		CompressedSize64:   uint64(len(mimetype)),
+		UncompressedSize64: uint64(len(mimetype)),
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(mimetype); err != nil {
+		return err
+	}
+
+	modified := project.UpdatedAt
+	if err := writeZipEntry(zipWriter, "META-INF/container.xml", []byte(epubContainer), modified); err != nil {
+		return err
+	}
+
+	for i, chapter := range chapters {
+		data, err := epubChapter(chapter, paths[i], links)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s: %w", chapter.Source, err)
+		}
+		if err := writeZipEntry(zipWriter, epubContentDir+paths[i], data, chapter.Date); err != nil {
+			return err
+		}
+
+		if progress != nil {
+			progress(i+1, len(chapters))
+		}
+	}
+
+	// Stylesheets may reference further assets, including other stylesheets
+	styles := make(map[string][]byte)
+	for pending := true; pending; {
+		pending = false
+		for _, name := range links.sortedAttachments() {
+			if _, done := styles[name]; done || path.Ext(name) != ".css" {
+				continue
+			}
+			if styles[name], err = epubStylesheet(links, name); err != nil {
+				return err
+			}
+			pending = true
+		}
+	}
+
+	if err := writeZipEntry(zipWriter, epubContentDir+epubNavPath, epubNav(chapters, paths), modified); err != nil {
+		return err
+	}
+	if err := writeZipEntry(zipWriter, epubContentDir+"content.opf", epubPackage(project, chapters, paths, links), modified); err != nil {
+		return err
+	}
+
+	for _, name := range links.sortedAttachments() {
+		if data, ok := styles[name]; ok {
+			err = writeZipEntry(zipWriter, epubContentDir+name, data, modified)
+		} else {
+			err = addFileToZip(zipWriter, filepath.Join(projectDir, filepath.FromSlash(name)), epubContentDir+name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// epubChapter converts a saved page to an XHTML content document
+func epubChapter(chapter *pdfChapter, chapterPath string, links *archiveLinks) ([]byte, error) {
+	doc, err := parseHTMLFile(chapter.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stylesheets come from the raw page; extracted content has none
+	raw := doc
+	if chapter.Source != chapter.Path {
+		if raw, err = parseHTMLFile(chapter.Path); err != nil {
+			return nil, err
+		}
+	}
+	var styles []string
+	if head := findElement(raw, "head"); head != nil {
+		for link := head.FirstChild; link != nil; link = link.NextSibling {
+			if link.Type != html.ElementNode || link.Data != "link" || !strings.Contains(strings.ToLower(getAttr(link, "rel")), "stylesheet") {
+				continue
+			}
+			target, local := links.rewrite(chapterPath, filepath.Dir(chapter.Path), chapter.URL, getAttr(link, "href"))
+			if local && path.Ext(strings.SplitN(target, "#", 2)[0]) == ".css" {
+				styles = append(styles, target)
+			}
+		}
+	}
+
+	body := findElement(doc, "body")
+	if body == nil {
+		body = doc
+	}
+	cleaner := &epubCleaner{
+		links:   links,
+		from:    chapterPath,
+		htmlDir: filepath.Dir(chapter.Source),
+		pageURL: chapter.URL,
+	}
+	cleaner.clean(body)
+
+	var b bytes.Buffer
+	writeXHTMLStart(&b, chapter.Title, chapter.Language, styles)
+	for child := body.FirstChild; child != nil; child = child.NextSibling {
+		if err := html.Render(&b, child); err != nil {
+			return nil, err
+		}
+	}
+	b.WriteString("\n</body>\n</html>\n")
+
+	return b.Bytes(), nil
+}
+
+// epubCleaner reduces a page to XHTML elements and attributes e-readers
+// support, pointing links and images into the book
+type epubCleaner struct {
+	links   *archiveLinks
+	from    string // Archive path of the chapter
+	htmlDir string
+	pageURL string
+}
+
+// clean rewrites the children of node in place
+func (c *epubCleaner) clean(node *html.Node) {
+	for child := node.FirstChild; child != nil; {
+		next := child.NextSibling
+
+		switch {
+		case child.Type == html.TextNode:
+			child.Data = xmlText(child.Data)
+		case child.Type != html.ElementNode:
+			node.RemoveChild(child) // Comments and doctypes
+		case skippedElements[child.Data] || epubDroppedElements[child.Data] || hasAttr(child, "hidden"):
+			node.RemoveChild(child)
+		case child.Data == "img":
+			c.cleanImage(node, child)
+		case !epubElements[child.Data]:
+			// Unknown elements are replaced by their content, cleaned next
+			first := child.FirstChild
+			for grandchild := first; grandchild != nil; grandchild = child.FirstChild {
+				child.RemoveChild(grandchild)
+				node.InsertBefore(grandchild, child)
+			}
+			node.RemoveChild(child)
+			if first != nil {
+				next = first
+			}
+		default:
+			c.cleanAttributes(child)
+			c.clean(child)
+		}
+
+		child = next
+	}
+}
+
+// cleanImage keeps images included in the book; others become their alt text
+func (c *epubCleaner) cleanImage(parent, img *html.Node) {
+	src := getAttr(img, "src")
+	alt := xmlText(getAttr(img, "alt"))
+
+	target, local := "", false
+	if src != "" {
+		target, local = c.links.rewrite(c.from, c.htmlDir, c.pageURL, src)
+	}
+	if !local || target == "" || strings.HasPrefix(target, "#") {
+		if alt != "" {
+			parent.InsertBefore(&html.Node{Type: html.TextNode, Data: alt}, img)
+		}
+		parent.RemoveChild(img)
+		return
+	}
+
+	img.Attr = []html.Attribute{{Key: "src", Val: target}, {Key: "alt", Val: alt}}
+}
+
+// cleanAttributes keeps allowed attributes and rewrites links
+func (c *epubCleaner) cleanAttributes(node *html.Node) {
+	kept := node.Attr[:0]
+	for _, attr := range node.Attr {
+		if attr.Namespace != "" || (!epubAttributes[attr.Key] && !epubElementAttributes[node.Data][attr.Key]) {
+			continue
+		}
+		switch attr.Key {
+		case "id":
+			if !xmlIDPattern.MatchString(attr.Val) {
+				continue
+			}
+		case "lang":
+			if !languagePattern.MatchString(attr.Val) {
+				continue
+			}
+		case "href":
+			if strings.HasPrefix(strings.ToLower(strings.TrimSpace(attr.Val)), "javascript:") {
+				continue
+			}
+			attr.Val, _ = c.links.rewrite(c.from, c.htmlDir, c.pageURL, attr.Val)
+		}
+		attr.Val = xmlText(attr.Val)
+		kept = append(kept, attr)
+	}
+	node.Attr = kept
+}
+
+// epubStylesheet reads an included stylesheet, pointing url() references to
+// assets in the book; references to anything else are dropped
+func epubStylesheet(links *archiveLinks, name string) ([]byte, error) {
+	filePath := filepath.Join(links.projectDir, filepath.FromSlash(name))
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	cssDir := filepath.Dir(filePath)
+	css := cssURLPattern.ReplaceAllStringFunc(string(data), func(match string) string {
+		ref := strings.TrimSpace(cssURLPattern.FindStringSubmatch(match)[1])
+		if ref == "" || strings.HasPrefix(ref, "data:") || strings.HasPrefix(ref, "#") {
+			return match
+		}
+		target, local := links.rewrite(name, cssDir, "", ref)
+		if !local {
+			return "none"
+		}
+		return `url("` + target + `")`
+	})
+
+	return []byte(css), nil
+}
+
+// epubNav builds the navigation document; nested lists follow chapter levels
+func epubNav(chapters []*pdfChapter, paths []string) []byte {
+	var b bytes.Buffer
+	writeXHTMLStart(&b, "Table of Contents", bookLanguage(chapters), nil)
+	b.WriteString("<nav epub:type=\"toc\" id=\"toc\">\n<h1>Table of Contents</h1>\n")
+
+	depth := 0
+	for i, chapter := range chapters {
+		// Levels may only grow one step at a time
+		level := chapter.Level + 1
+		if level > depth+1 {
+			level = depth + 1
+		}
+		if level < 1 {
+			level = 1
+		}
+
+		switch {
+		case level > depth:
+			b.WriteString("<ol>\n")
+		case level == depth:
+			b.WriteString("</li>\n")
+		default:
+			b.WriteString(strings.Repeat("</li>\n</ol>\n", depth-level))
+			b.WriteString("</li>\n")
+		}
+		depth = level
+
+		fmt.Fprintf(&b, "<li><a href=\"%s\">%s</a>", html.EscapeString(paths[i]), html.EscapeString(xmlText(chapter.Title)))
+	}
+	b.WriteString(strings.Repeat("</li>\n</ol>\n", depth))
+
+	b.WriteString("</nav>\n</body>\n</html>\n")
+	return b.Bytes()
+}
+
+// epubPackage builds the package document with book metadata, every file
+// of the book and the reading order
+func epubPackage(project *models.Project, chapters []*pdfChapter, paths []string, links *archiveLinks) []byte {
+	// Date of the newest captured page
+	captured := project.CreatedAt
+	for _, chapter := range chapters {
+		if chapter.Date.After(captured) {
+			captured = chapter.Date
+		}
+	}
+
+	language := bookLanguage(chapters)
+
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(&b, "<package xmlns=\"http://www.idpf.org/2007/opf\" version=\"3.0\" unique-identifier=\"book-id\" xml:lang=\"%s\">\n", language)
+
+	b.WriteString("<metadata xmlns:dc=\"http://purl.org/dc/elements/1.1/\">\n")
+	fmt.Fprintf(&b, "<dc:identifier id=\"book-id\">urn:uuid:%s</dc:identifier>\n", html.EscapeString(project.ID))
+	fmt.Fprintf(&b, "<dc:title>%s</dc:title>\n", html.EscapeString(xmlText(chapters[0].Title)))
+	fmt.Fprintf(&b, "<dc:language>%s</dc:language>\n", language)
+	fmt.Fprintf(&b, "<dc:source>%s</dc:source>\n", html.EscapeString(xmlText(project.URL)))
+	fmt.Fprintf(&b, "<dc:date>%s</dc:date>\n", captured.UTC().Format(epubDateFormat))
+	fmt.Fprintf(&b, "<meta property=\"dcterms:modified\">%s</meta>\n", project.UpdatedAt.UTC().Format(epubDateFormat))
+	b.WriteString("</metadata>\n")
+
+	b.WriteString("<manifest>\n")
+	fmt.Fprintf(&b, "<item id=\"nav\" href=\"%s\" media-type=\"application/xhtml+xml\" properties=\"nav\"/>\n", epubNavPath)
+	for i, chapterPath := range paths {
+		fmt.Fprintf(&b, "<item id=\"chapter-%d\" href=\"%s\" media-type=\"application/xhtml+xml\"/>\n", i+1, chapterPath)
+	}
+	for i, name := range links.sortedAttachments() {
+		href := relativeArchivePath(epubNavPath, name)
+		fmt.Fprintf(&b, "<item id=\"asset-%d\" href=\"%s\" media-type=\"%s\"/>\n", i+1, html.EscapeString(href), epubMediaType(name))
+	}
+	b.WriteString("</manifest>\n")
+
+	b.WriteString("<spine>\n")
+	for i := range paths {
+		fmt.Fprintf(&b, "<itemref idref=\"chapter-%d\"/>\n", i+1)
+	}
+	b.WriteString("</spine>\n</package>\n")
+
+	return b.Bytes()
+}
+
+// writeXHTMLStart writes the XHTML prologue up to the opening body tag
+func writeXHTMLStart(b *bytes.Buffer, title, language string, styles []string) {
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n<!DOCTYPE html>\n")
+	b.WriteString(`<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops"`)
+	if languagePattern.MatchString(language) {
+		fmt.Fprintf(b, ` xml:lang="%s" lang="%s"`, language, language)
+	}
+	b.WriteString(">\n<head>\n<meta charset=\"UTF-8\"/>\n")
+	fmt.Fprintf(b, "<title>%s</title>\n", html.EscapeString(xmlText(title)))
+	for _, style := range styles {
+		fmt.Fprintf(b, "<link rel=\"stylesheet\" type=\"text/css\" href=\"%s\"/>\n", html.EscapeString(style))
+	}
+	b.WriteString("</head>\n<body>\n")
+}
+
+// bookLanguage is the language of the first page that declares one
+func bookLanguage(chapters []*pdfChapter) string {
+	for _, chapter := range chapters {
+		if languagePattern.MatchString(chapter.Language) {
+			return chapter.Language
+		}
+	}
+	return "und"
+}
+
+// epubMediaType returns the media type of an includable asset, or ""
+func epubMediaType(name string) string {
+	return epubMediaTypes[strings.ToLower(path.Ext(name))]
+}
+
+// xmlText removes characters XML 1.0 does not allow
+func xmlText(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' || (r >= 0x20 && r <= 0xD7FF) || (r >= 0xE000 && r <= 0xFFFD) || r >= 0x10000 {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// parseHTMLFile parses a saved page
+func parseHTMLFile(filePath string) (*html.Node, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return html.Parse(file)
+}
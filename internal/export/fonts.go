@@ -0,0 +1,429 @@
+package export
+
+import (
+	"embed"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+//go:embed fonts/*.ttf
+var bundledFonts embed.FS
+
+// Bundled UTF-8 families used for PDF text
+const (
+	pdfFontFamily = "DejaVu"
+	pdfMonoFamily = "DejaVuMono"
+)
+
+// bundledFontFiles maps gofpdf styles to the bundled DejaVu files
+var bundledFontFiles = map[string]map[string]string{
+	pdfFontFamily: {
+		"":   "fonts/DejaVuSansCondensed.ttf",
+		"B":  "fonts/DejaVuSansCondensed-Bold.ttf",
+		"I":  "fonts/DejaVuSansCondensed-Oblique.ttf",
+		"BI": "fonts/DejaVuSansCondensed-BoldOblique.ttf",
+	},
+	pdfMonoFamily: {
+		"":  "fonts/DejaVuSansMono.ttf",
+		"B": "fonts/DejaVuSansMono-Bold.ttf",
+	},
+}
+
+// fontDirEnv names a directory of extra *.ttf fallback fonts (e.g. CJK)
+const fontDirEnv = "PDF_FONT_DIR"
+
+// replacementChar stands in for glyphs no loaded font has
+const replacementChar = '\uFFFD'
+
+// pdfFont is a registered font family and the runes it has glyphs for
+type pdfFont struct {
+	family   string
+	styles   map[string]bool // Registered styles ("", "B", "I", "BI")
+	coverage runeRanges
+}
+
+// style returns the closest registered variant of a gofpdf style
+func (f pdfFont) style(style string) string {
+	for _, candidate := range []string{style, strings.Trim(style, "I"), strings.Trim(style, "B")} {
+		if f.styles[candidate] {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// textStyle describes how a piece of inline text is rendered
+type textStyle struct {
+	Bold      bool
+	Italic    bool
+	Underline bool
+	Mono      bool
+	Size      float64
+	Link      string // External URL
+	LinkID    int    // Internal link from Fpdf.AddLink
+}
+
+// fontStyle returns the gofpdf style string without underline
+func (st textStyle) fontStyle() string {
+	style := ""
+	if st.Bold {
+		style += "B"
+	}
+	if st.Italic {
+		style += "I"
+	}
+	return style
+}
+
+// fontSet picks, for every rune, the first font that covers it
+type fontSet struct {
+	pdf       *gofpdf.Fpdf
+	primary   pdfFont
+	mono      pdfFont
+	fallbacks []pdfFont
+}
+
+// newFontSet registers the bundled families and any fallback fonts from PDF_FONT_DIR
+func newFontSet(pdf *gofpdf.Fpdf) (*fontSet, error) {
+	set := &fontSet{pdf: pdf}
+
+	for family, files := range bundledFontFiles {
+		font := pdfFont{family: family, styles: make(map[string]bool)}
+		for style, name := range files {
+			data, err := bundledFonts.ReadFile(name)
+			if err != nil {
+				return nil, err
+			}
+			pdf.AddUTF8FontFromBytes(family, style, data)
+			font.styles[style] = true
+			if style == "" {
+				if font.coverage, err = parseCmapCoverage(data); err != nil {
+					return nil, fmt.Errorf("%s: %w", name, err)
+				}
+			}
+		}
+		if family == pdfMonoFamily {
+			set.mono = font
+		} else {
+			set.primary = font
+		}
+	}
+	if err := pdf.Error(); err != nil {
+		return nil, err
+	}
+
+	if dir := os.Getenv(fontDirEnv); dir != "" {
+		set.addFallbackFonts(dir)
+	}
+
+	return set, nil
+}
+
+// addFallbackFonts loads *.ttf files from dir in name order; unusable files are skipped
+func (fs *fontSet) addFallbackFonts(dir string) {
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.ttf"))
+	sort.Strings(paths)
+
+	for i, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("PDF font %s skipped: %v", path, err)
+			continue
+		}
+		coverage, err := parseCmapCoverage(data)
+		if err != nil {
+			log.Printf("PDF font %s skipped: %v", path, err)
+			continue
+		}
+
+		family := fmt.Sprintf("Fallback%d", i)
+		fs.pdf.AddUTF8FontFromBytes(family, "", data)
+		if err := fs.pdf.Error(); err != nil {
+			// gofpdf errors are sticky, so a broken font would break the whole document
+			log.Printf("PDF font %s failed to load: %v", path, err)
+			fs.pdf.ClearError()
+			continue
+		}
+		fs.fallbacks = append(fs.fallbacks, pdfFont{family: family, styles: map[string]bool{"": true}, coverage: coverage})
+	}
+}
+
+// chain returns fonts in lookup order for a style
+func (fs *fontSet) chain(mono bool) []pdfFont {
+	fonts := []pdfFont{fs.primary}
+	if mono {
+		fonts = []pdfFont{fs.mono, fs.primary}
+	}
+	return append(fonts, fs.fallbacks...)
+}
+
+// SetFont selects the first font of the chain for a style
+func (fs *fontSet) SetFont(st textStyle) {
+	font := fs.chain(st.Mono)[0]
+	style := font.style(st.fontStyle())
+	if st.Underline {
+		style += "U"
+	}
+	fs.pdf.SetFont(font.family, style, st.Size)
+}
+
+// Write flows text like Fpdf.Write, switching fonts for runes the
+// first font lacks and replacing runes no font covers
+func (fs *fontSet) Write(h float64, st textStyle, text string) {
+	fonts := fs.chain(st.Mono)
+
+	for _, run := range fs.runs(fonts, text) {
+		font := fonts[run.font]
+		style := font.style(st.fontStyle())
+		if st.Underline {
+			style += "U"
+		}
+		fs.pdf.SetFont(font.family, style, st.Size)
+
+		// gofpdf's Write is quadratic in string length, so feed it word groups
+		for _, chunk := range chunkText(run.text, 200) {
+			switch {
+			case st.Link != "":
+				fs.pdf.WriteLinkString(h, chunk, st.Link)
+			case st.LinkID != 0:
+				fs.pdf.WriteLinkID(h, chunk, st.LinkID)
+			default:
+				fs.pdf.Write(h, chunk)
+			}
+		}
+	}
+
+	fs.SetFont(st)
+}
+
+// Clean replaces runes the first font of the chain lacks, for single-font
+// output such as table cells and code blocks
+func (fs *fontSet) Clean(text string, mono bool) string {
+	font := fs.chain(mono)[0]
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t':
+			return ' '
+		case r == '\n':
+			return r
+		case unicode.IsControl(r) || r == '\uFEFF':
+			return -1
+		case r <= 0xFFFF && font.coverage.contains(r):
+			return r
+		case font.coverage.contains(replacementChar):
+			return replacementChar
+		default:
+			return '?'
+		}
+	}, text)
+}
+
+// fontRun is a piece of text rendered with a single font
+type fontRun struct {
+	font int
+	text string
+}
+
+// runs splits text into font runs; whitespace and punctuation stay in the current run
+func (fs *fontSet) runs(fonts []pdfFont, text string) []fontRun {
+	var runs []fontRun
+	var b strings.Builder
+	current := 0
+
+	flush := func() {
+		if b.Len() > 0 {
+			runs = append(runs, fontRun{font: current, text: b.String()})
+			b.Reset()
+		}
+	}
+
+	for _, r := range text {
+		if r == '\n' || r == '\t' {
+			r = ' '
+		}
+		if unicode.IsControl(r) || r == '\uFEFF' {
+			continue
+		}
+
+		// Keep neutral characters with the current font when it has them
+		if (unicode.IsSpace(r) || unicode.IsPunct(r)) && fonts[current].coverage.contains(r) {
+			b.WriteRune(r)
+			continue
+		}
+
+		font := fontFor(fonts, r)
+		if font < 0 {
+			r, font = replacementChar, 0
+			if !fonts[0].coverage.contains(r) {
+				r = '?'
+			}
+		}
+		if font != current {
+			flush()
+			current = font
+		}
+		b.WriteRune(r)
+	}
+	flush()
+
+	return runs
+}
+
+// fontFor returns the index of the first font covering r, or -1
+func fontFor(fonts []pdfFont, r rune) int {
+	// gofpdf's UTF-8 support stops at the Basic Multilingual Plane
+	if r > 0xFFFF {
+		return -1
+	}
+	for i, font := range fonts {
+		if font.coverage.contains(r) {
+			return i
+		}
+	}
+	return -1
+}
+
+// chunkText splits text after spaces into pieces of roughly max runes
+func chunkText(text string, max int) []string {
+	var chunks []string
+	runes := []rune(text)
+
+	for len(runes) > max {
+		cut := max
+		for i := max; i > max/2; i-- {
+			if runes[i-1] == ' ' {
+				cut = i
+				break
+			}
+		}
+		chunks = append(chunks, string(runes[:cut]))
+		runes = runes[cut:]
+	}
+	if len(runes) > 0 {
+		chunks = append(chunks, string(runes))
+	}
+
+	return chunks
+}
+
+// runeRanges is a sorted list of inclusive rune ranges
+type runeRanges [][2]rune
+
+// contains reports whether r falls in one of the ranges
+func (rr runeRanges) contains(r rune) bool {
+	i := sort.Search(len(rr), func(i int) bool { return rr[i][1] >= r })
+	return i < len(rr) && rr[i][0] <= r
+}
+
+// parseCmapCoverage reads the Unicode BMP (format 4) cmap of a TrueType font
+// and returns the runes that map to a real glyph
+func parseCmapCoverage(data []byte) (runeRanges, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("not a TrueType font")
+	}
+
+	// Table directory
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	cmapOffset := -1
+	for i := 0; i < numTables; i++ {
+		record := 12 + 16*i
+		if record+16 > len(data) {
+			break
+		}
+		if string(data[record:record+4]) == "cmap" {
+			cmapOffset = int(binary.BigEndian.Uint32(data[record+8:]))
+		}
+	}
+	if cmapOffset < 0 || cmapOffset+4 > len(data) {
+		return nil, fmt.Errorf("no cmap table")
+	}
+
+	// Prefer Windows Unicode BMP (3,1), then any Unicode platform subtable
+	subtable := -1
+	numSubtables := int(binary.BigEndian.Uint16(data[cmapOffset+2:]))
+	for i := 0; i < numSubtables; i++ {
+		record := cmapOffset + 4 + 8*i
+		if record+8 > len(data) {
+			break
+		}
+		platform := binary.BigEndian.Uint16(data[record:])
+		encoding := binary.BigEndian.Uint16(data[record+2:])
+		offset := cmapOffset + int(binary.BigEndian.Uint32(data[record+4:]))
+		if offset+2 > len(data) || binary.BigEndian.Uint16(data[offset:]) != 4 {
+			continue
+		}
+		if platform == 3 && encoding == 1 {
+			subtable = offset
+			break
+		}
+		if platform == 0 && subtable < 0 {
+			subtable = offset
+		}
+	}
+	if subtable < 0 {
+		return nil, fmt.Errorf("no Unicode BMP cmap subtable")
+	}
+
+	return parseCmapFormat4(data, subtable)
+}
+
+// parseCmapFormat4 walks the segments of a format 4 cmap subtable
+func parseCmapFormat4(data []byte, offset int) (runeRanges, error) {
+	if offset+14 > len(data) {
+		return nil, fmt.Errorf("truncated cmap")
+	}
+	segCount := int(binary.BigEndian.Uint16(data[offset+6:])) / 2
+	endCodes := offset + 14
+	startCodes := endCodes + 2*segCount + 2 // Skip reservedPad
+	idDeltas := startCodes + 2*segCount
+	idRangeOffsets := idDeltas + 2*segCount
+	if idRangeOffsets+2*segCount > len(data) {
+		return nil, fmt.Errorf("truncated cmap")
+	}
+
+	u16 := func(pos int) int {
+		if pos+2 > len(data) {
+			return 0
+		}
+		return int(binary.BigEndian.Uint16(data[pos:]))
+	}
+
+	var ranges runeRanges
+	add := func(r rune) {
+		if n := len(ranges); n > 0 && ranges[n-1][1] == r-1 {
+			ranges[n-1][1] = r
+			return
+		}
+		ranges = append(ranges, [2]rune{r, r})
+	}
+
+	for seg := 0; seg < segCount; seg++ {
+		end := u16(endCodes + 2*seg)
+		start := u16(startCodes + 2*seg)
+		delta := u16(idDeltas + 2*seg)
+		rangeOffsetPos := idRangeOffsets + 2*seg
+		rangeOffset := u16(rangeOffsetPos)
+
+		for c := start; c <= end && c != 0xFFFF; c++ {
+			glyph := 0
+			if rangeOffset == 0 {
+				glyph = (c + delta) & 0xFFFF
+			} else if glyph = u16(rangeOffsetPos + rangeOffset + 2*(c-start)); glyph != 0 {
+				glyph = (glyph + delta) & 0xFFFF
+			}
+			if glyph != 0 {
+				add(rune(c))
+			}
+		}
+	}
+
+	return ranges, nil
+}
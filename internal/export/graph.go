@@ -0,0 +1,248 @@
+package export
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/user/scrapper/internal/models"
+	"github.com/user/scrapper/internal/scraper"
+)
+
+// BuildLinkGraph builds the link graph of a project from its manifest.
+// startURL is the crawl start, which is never an orphan. If internalOnly
+// is set, links to other domains are left out.
+func BuildLinkGraph(projectID, dataDir, startURL string, internalOnly bool) (*models.LinkGraph, error) {
+	entries, err := scraper.LoadManifest(projectID, dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest: %w", err)
+	}
+
+	graph := &models.LinkGraph{
+		Nodes: []models.GraphNode{},
+		Edges: []models.GraphEdge{},
+	}
+	nodeIndex := make(map[string]int)    // URL -> position in graph.Nodes
+	edgeIndex := make(map[[2]string]int) // source, target -> position in graph.Edges
+
+	addNode := func(nodeURL string, internal bool) *models.GraphNode {
+		if pos, exists := nodeIndex[nodeURL]; exists {
+			return &graph.Nodes[pos]
+		}
+		nodeIndex[nodeURL] = len(graph.Nodes)
+		graph.Nodes = append(graph.Nodes, models.GraphNode{URL: nodeURL, Internal: internal})
+		return &graph.Nodes[len(graph.Nodes)-1]
+	}
+
+	// Crawled pages first so they keep manifest order
+	for _, entry := range entries {
+		if entry.Kind != models.ManifestKindPage {
+			continue
+		}
+		node := addNode(entry.URL, true)
+		node.Crawled = true
+		node.Depth = entry.Depth
+		node.StatusCode = entry.StatusCode
+	}
+
+	for _, entry := range entries {
+		if entry.Kind != models.ManifestKindPage {
+			continue
+		}
+		for _, link := range entry.Links {
+			if internalOnly && !link.Internal {
+				continue
+			}
+
+			key := [2]string{entry.URL, link.URL}
+			if pos, exists := edgeIndex[key]; exists {
+				graph.Edges[pos].Count++
+				continue
+			}
+
+			addNode(link.URL, link.Internal)
+			edgeIndex[key] = len(graph.Edges)
+			graph.Edges = append(graph.Edges, models.GraphEdge{
+				Source:   entry.URL,
+				Target:   link.URL,
+				Text:     link.Text,
+				Rel:      link.Rel,
+				Internal: link.Internal,
+				Count:    1,
+			})
+		}
+	}
+
+	// Degrees count distinct pages, self-links excluded
+	for _, edge := range graph.Edges {
+		if edge.Source == edge.Target {
+			continue
+		}
+		graph.Nodes[nodeIndex[edge.Source]].Outbound++
+		graph.Nodes[nodeIndex[edge.Target]].Inbound++
+	}
+
+	startURL = strings.TrimRight(startURL, "/")
+	for i := range graph.Nodes {
+		node := &graph.Nodes[i]
+		node.Orphan = node.Crawled && node.Inbound == 0 && strings.TrimRight(node.URL, "/") != startURL
+	}
+
+	return graph, nil
+}
+
+// WriteGraphJSON writes the graph as indented JSON
+func WriteGraphJSON(w io.Writer, graph *models.LinkGraph) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(graph)
+}
+
+// WriteGraphDOT writes the graph in Graphviz DOT format
+func WriteGraphDOT(w io.Writer, graph *models.LinkGraph) error {
+	var b strings.Builder
+
+	b.WriteString("digraph site {\n")
+	b.WriteString("  node [shape=box];\n")
+
+	for _, node := range graph.Nodes {
+		style := ""
+		if !node.Internal {
+			style = ", style=dashed"
+		} else if node.Orphan {
+			style = ", color=red"
+		} else if !node.Crawled {
+			style = ", style=dotted"
+		}
+		fmt.Fprintf(&b, "  %s [inbound=%d, outbound=%d%s];\n", dotQuote(node.URL), node.Inbound, node.Outbound, style)
+	}
+
+	for _, edge := range graph.Edges {
+		attrs := []string{fmt.Sprintf("weight=%d", edge.Count)}
+		if edge.Text != "" {
+			attrs = append(attrs, "label="+dotQuote(edge.Text))
+		}
+		if edge.Rel != "" {
+			attrs = append(attrs, "rel="+dotQuote(edge.Rel))
+		}
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", dotQuote(edge.Source), dotQuote(edge.Target), strings.Join(attrs, ", "))
+	}
+
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dotQuote returns s as a quoted DOT identifier
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", " ")
+	return `"` + s + `"`
+}
+
+// GraphML document structure
+type graphMLDoc struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphGraphML writes the graph in GraphML format
+func WriteGraphGraphML(w io.Writer, graph *models.LinkGraph) error {
+	doc := graphMLDoc{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "url", For: "node", AttrName: "url", AttrType: "string"},
+			{ID: "internal", For: "node", AttrName: "internal", AttrType: "boolean"},
+			{ID: "crawled", For: "node", AttrName: "crawled", AttrType: "boolean"},
+			{ID: "depth", For: "node", AttrName: "depth", AttrType: "int"},
+			{ID: "status", For: "node", AttrName: "status_code", AttrType: "int"},
+			{ID: "inbound", For: "node", AttrName: "inbound_links", AttrType: "int"},
+			{ID: "outbound", For: "node", AttrName: "outbound_links", AttrType: "int"},
+			{ID: "orphan", For: "node", AttrName: "orphan", AttrType: "boolean"},
+			{ID: "text", For: "edge", AttrName: "text", AttrType: "string"},
+			{ID: "rel", For: "edge", AttrName: "rel", AttrType: "string"},
+			{ID: "count", For: "edge", AttrName: "count", AttrType: "int"},
+		},
+		Graph: graphMLGraph{ID: "site", EdgeDefault: "directed"},
+	}
+
+	nodeIDs := make(map[string]string, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		id := fmt.Sprintf("n%d", i)
+		nodeIDs[node.URL] = id
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID: id,
+			Data: []graphMLData{
+				{Key: "url", Value: node.URL},
+				{Key: "internal", Value: fmt.Sprint(node.Internal)},
+				{Key: "crawled", Value: fmt.Sprint(node.Crawled)},
+				{Key: "depth", Value: fmt.Sprint(node.Depth)},
+				{Key: "status", Value: fmt.Sprint(node.StatusCode)},
+				{Key: "inbound", Value: fmt.Sprint(node.Inbound)},
+				{Key: "outbound", Value: fmt.Sprint(node.Outbound)},
+				{Key: "orphan", Value: fmt.Sprint(node.Orphan)},
+			},
+		})
+	}
+
+	for _, edge := range graph.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			Source: nodeIDs[edge.Source],
+			Target: nodeIDs[edge.Target],
+			Data: []graphMLData{
+				{Key: "text", Value: edge.Text},
+				{Key: "rel", Value: edge.Rel},
+				{Key: "count", Value: fmt.Sprint(edge.Count)},
+			},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
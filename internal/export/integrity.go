@@ -0,0 +1,370 @@
+package export
+
+import (
+	"archive/zip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/user/scrapper/internal/models"
+	"github.com/user/scrapper/internal/scraper"
+)
+
+// Integrity files at the root of a signed ZIP export. The signature file
+// holds the base64 Ed25519 signature of the exact manifest bytes.
+const (
+	IntegrityManifestName  = "integrity.json"
+	IntegritySignatureName = "integrity.json.sig"
+)
+
+// ErrNoIntegrityManifest is returned when a ZIP has no integrity manifest
+var ErrNoIntegrityManifest = errors.New("archive has no integrity manifest")
+
+// ErrInvalidKey is returned for key files that hold no Ed25519 key
+var ErrInvalidKey = errors.New("not an Ed25519 key")
+
+// LoadSigningKey reads the PEM (PKCS #8) Ed25519 private key at path,
+// generating and saving a new one if the file does not exist
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return createSigningKey(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, ErrInvalidKey
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	return key, nil
+}
+
+// createSigningKey generates a key and saves it readable by the owner only
+func createSigningKey(path string) (ed25519.PrivateKey, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	// O_EXCL so a key created concurrently is never overwritten
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		return LoadSigningKey(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if err := pem.Encode(file, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		return nil, err
+	}
+	return key, file.Close()
+}
+
+// ParsePublicKey reads an Ed25519 public key from PEM data. A private key
+// is accepted as well, so the server key file can be used directly.
+func ParsePublicKey(data []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrInvalidKey
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := parsed.(type) {
+	case ed25519.PublicKey:
+		return key, nil
+	case ed25519.PrivateKey:
+		return key.Public().(ed25519.PublicKey), nil
+	}
+	return nil, ErrInvalidKey
+}
+
+// EncodePublicKey returns the PEM (PKIX) form of a public key
+func EncodePublicKey(key ed25519.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// KeyID identifies a public key in integrity manifests
+func KeyID(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// integrityRecorder collects the manifest of a ZIP export while its files
+// are written
+type integrityRecorder struct {
+	key     ed25519.PrivateKey
+	project string
+	sources map[string]models.ManifestEntry // Project-relative path -> crawl entry
+	files   []models.IntegrityFile
+}
+
+// newIntegrityRecorder maps the files of a project to the URLs they were
+// captured from
+func newIntegrityRecorder(projectDir string, key ed25519.PrivateKey) (*integrityRecorder, error) {
+	projectID := filepath.Base(projectDir)
+	entries, err := scraper.LoadManifest(projectID, filepath.Dir(projectDir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest: %w", err)
+	}
+
+	sources := make(map[string]models.ManifestEntry)
+	for _, entry := range entries {
+		if entry.LocalPath != "" {
+			sources[entry.LocalPath] = entry
+		}
+		if entry.ContentPath != "" {
+			sources[entry.ContentPath] = entry
+		}
+	}
+	return &integrityRecorder{key: key, project: projectID, sources: sources}, nil
+}
+
+// add records a file written to the archive
+func (r *integrityRecorder) add(name string, h hash.Hash, size int64) {
+	file := models.IntegrityFile{Path: name, SHA256: hex.EncodeToString(h.Sum(nil)), Size: size}
+	if entry, ok := r.sources[name]; ok {
+		file.SourceURL = entry.URL
+		if !entry.FetchedAt.IsZero() {
+			capturedAt := entry.FetchedAt.UTC()
+			file.CapturedAt = &capturedAt
+		}
+	}
+	r.files = append(r.files, file)
+}
+
+// lastCapture returns the latest capture time of the recorded files, the
+// creation time of deterministic manifests
+func (r *integrityRecorder) lastCapture() time.Time {
+	latest := deterministicTime
+	for _, file := range r.files {
+		if file.CapturedAt != nil && file.CapturedAt.After(latest) {
+			latest = *file.CapturedAt
+		}
+	}
+	return latest
+}
+
+// write adds the signed manifest, created at now, to the archive
+func (r *integrityRecorder) write(zipWriter *zip.Writer, now time.Time) error {
+	files := r.files
+	if files == nil {
+		files = []models.IntegrityFile{}
+	}
+	manifest := models.IntegrityManifest{
+		Version:            models.IntegrityManifestVersion,
+		ProjectID:          r.project,
+		CreatedAt:          now,
+		HashAlgorithm:      "sha256",
+		SignatureAlgorithm: "ed25519",
+		KeyID:              KeyID(r.key.Public().(ed25519.PublicKey)),
+		Files:              files,
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(r.key, data)) + "\n"
+	if err := writeZipEntry(zipWriter, IntegrityManifestName, data, now); err != nil {
+		return err
+	}
+	return writeZipEntry(zipWriter, IntegritySignatureName, []byte(signature), now)
+}
+
+// isIntegrityFile reports whether an archive path is reserved for the
+// integrity manifest
+func isIntegrityFile(name string) bool {
+	return name == IntegrityManifestName || name == IntegritySignatureName
+}
+
+// VerifyZip checks the files of a signed ZIP export against its manifest
+// and the manifest against its signature made with key. Archive errors and
+// a missing manifest are returned as errors; everything else is reported.
+func VerifyZip(r io.ReaderAt, size int64, key ed25519.PublicKey) (*models.IntegrityReport, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]*zip.File)
+	report := &models.IntegrityReport{Problems: []models.IntegrityProblem{}}
+	problem := func(kind, path, format string, args ...any) {
+		report.Problems = append(report.Problems, models.IntegrityProblem{Kind: kind, Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	for _, file := range archive.File {
+		if strings.HasSuffix(file.Name, "/") {
+			continue
+		}
+		if _, ok := entries[file.Name]; ok {
+			problem(models.IntegrityDuplicate, file.Name, "archive has more than one entry with this name")
+			continue
+		}
+		entries[file.Name] = file
+	}
+
+	manifestFile, ok := entries[IntegrityManifestName]
+	if !ok {
+		return nil, ErrNoIntegrityManifest
+	}
+	data, err := readZipFile(manifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", IntegrityManifestName, err)
+	}
+
+	var manifest models.IntegrityManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", IntegrityManifestName, err)
+	}
+	report.ProjectID = manifest.ProjectID
+	report.CreatedAt = manifest.CreatedAt
+	report.KeyID = manifest.KeyID
+	report.Files = len(manifest.Files)
+
+	report.SignatureValid = verifySignature(entries[IntegritySignatureName], data, key)
+	switch {
+	case entries[IntegritySignatureName] == nil:
+		problem(models.IntegritySignature, IntegritySignatureName, "signature file is missing")
+	case !report.SignatureValid && manifest.KeyID != KeyID(key):
+		problem(models.IntegritySignature, IntegritySignatureName, "manifest was signed with key %s, not %s", manifest.KeyID, KeyID(key))
+	case !report.SignatureValid:
+		problem(models.IntegritySignature, IntegritySignatureName, "signature does not match the manifest")
+	}
+
+	listed := make(map[string]bool, len(manifest.Files))
+	for _, expected := range manifest.Files {
+		listed[expected.Path] = true
+		file, ok := entries[expected.Path]
+		if !ok {
+			problem(models.IntegrityMissing, expected.Path, "file is missing from the archive")
+			continue
+		}
+
+		sum, size, err := hashZipFile(file)
+		if err != nil {
+			problem(models.IntegrityModified, expected.Path, "file cannot be read: %v", err)
+			continue
+		}
+		if size != expected.Size || sum != expected.SHA256 {
+			problem(models.IntegrityModified, expected.Path, "content differs from the manifest (sha256 %s, %d bytes)", sum, size)
+			continue
+		}
+		report.Verified++
+	}
+
+	var unlisted []string
+	for name := range entries {
+		if !listed[name] && !isIntegrityFile(name) {
+			unlisted = append(unlisted, name)
+		}
+	}
+	sort.Strings(unlisted)
+	for _, name := range unlisted {
+		problem(models.IntegrityUnlisted, name, "file is not listed in the manifest")
+	}
+
+	report.Valid = report.SignatureValid && len(report.Problems) == 0
+	return report, nil
+}
+
+// verifySignature checks the signature file against the manifest bytes
+func verifySignature(file *zip.File, manifest []byte, key ed25519.PublicKey) bool {
+	if file == nil {
+		return false
+	}
+	data, err := readZipFile(file)
+	if err != nil {
+		return false
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(key, manifest, signature)
+}
+
+// maxIntegrityFileSize bounds the manifest and signature read into memory
+const maxIntegrityFileSize = 256 << 20
+
+// readZipFile reads a small archive entry
+func readZipFile(file *zip.File) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(reader, maxIntegrityFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxIntegrityFileSize {
+		return nil, fmt.Errorf("file is larger than %d bytes", maxIntegrityFileSize)
+	}
+	return data, nil
+}
+
+// hashZipFile returns the hex SHA-256 and size of an archive entry
+func hashZipFile(file *zip.File) (string, int64, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return "", 0, err
+	}
+	defer reader.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, reader)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
@@ -0,0 +1,237 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/user/scrapper/internal/models"
+)
+
+// zipEntry is a file read back from a test archive
+type zipEntry struct {
+	name    string
+	content []byte
+}
+
+// readZipEntries returns the files of a ZIP in archive order
+func readZipEntries(t *testing.T, data []byte) []zipEntry {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []zipEntry
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, zipEntry{file.Name, content})
+	}
+	return entries
+}
+
+// writeZipEntries builds a ZIP from entries, keeping duplicate names
+func writeZipEntries(t *testing.T, entries []zipEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zipWriter := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		writer, err := zipWriter.Create(entry.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write(entry.content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// signedTestZip exports the test project as a ZIP signed with key
+func signedTestZip(t *testing.T, key ed25519.PrivateKey) []byte {
+	t.Helper()
+	const projectID = "project"
+	dataDir := t.TempDir()
+	writeTestProject(t, dataDir, projectID)
+
+	zipPath := filepath.Join(t.TempDir(), "export.zip")
+	opts := ZipOptions{SigningKey: key}
+	if err := CreateZipArchive(zipPath, projectID, dataDir, opts, nil); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestVerifyZip(t *testing.T) {
+	public, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := signedTestZip(t, key)
+
+	tests := []struct {
+		name     string
+		key      ed25519.PublicKey
+		change   func([]zipEntry) []zipEntry
+		problems map[string]string // Path -> problem kind
+	}{
+		{
+			name:   "intact",
+			key:    public,
+			change: func(entries []zipEntry) []zipEntry { return entries },
+		},
+		{
+			name: "modified",
+			key:  public,
+			change: func(entries []zipEntry) []zipEntry {
+				for i := range entries {
+					if entries[i].name == "pages/b.html" {
+						entries[i].content = []byte("<html><body>changed</body></html>")
+					}
+				}
+				return entries
+			},
+			problems: map[string]string{"pages/b.html": models.IntegrityModified},
+		},
+		{
+			name: "unlisted",
+			key:  public,
+			change: func(entries []zipEntry) []zipEntry {
+				return append(entries, zipEntry{"pages/extra.html", []byte("<p>extra</p>")})
+			},
+			problems: map[string]string{"pages/extra.html": models.IntegrityUnlisted},
+		},
+		{
+			name: "missing",
+			key:  public,
+			change: func(entries []zipEntry) []zipEntry {
+				var kept []zipEntry
+				for _, entry := range entries {
+					if entry.name != "assets/css/site.css" {
+						kept = append(kept, entry)
+					}
+				}
+				return kept
+			},
+			problems: map[string]string{"assets/css/site.css": models.IntegrityMissing},
+		},
+		{
+			name: "duplicate",
+			key:  public,
+			change: func(entries []zipEntry) []zipEntry {
+				return append(entries, zipEntry{"index.html", []byte("<p>shadowed</p>")})
+			},
+			problems: map[string]string{"index.html": models.IntegrityDuplicate},
+		},
+		{
+			name:     "other key",
+			key:      otherPublic,
+			change:   func(entries []zipEntry) []zipEntry { return entries },
+			problems: map[string]string{IntegritySignatureName: models.IntegritySignature},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := writeZipEntries(t, tt.change(readZipEntries(t, signed)))
+			report, err := VerifyZip(bytes.NewReader(data), int64(len(data)), tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if report.ProjectID != "project" || report.KeyID != KeyID(public) {
+				t.Errorf("report identifies project %q key %q, want %q key %q", report.ProjectID, report.KeyID, "project", KeyID(public))
+			}
+			if want := tt.key.Equal(public); report.SignatureValid != want {
+				t.Errorf("SignatureValid = %v, want %v", report.SignatureValid, want)
+			}
+			if want := len(tt.problems) == 0; report.Valid != want {
+				t.Errorf("Valid = %v, want %v", report.Valid, want)
+			}
+			if len(report.Problems) != len(tt.problems) {
+				t.Fatalf("problems = %+v, want %v", report.Problems, tt.problems)
+			}
+			for _, problem := range report.Problems {
+				if tt.problems[problem.Path] != problem.Kind {
+					t.Errorf("unexpected problem %+v, want %v", problem, tt.problems)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyZipWithoutManifest(t *testing.T) {
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := writeZipEntries(t, []zipEntry{{"index.html", []byte("<p>unsigned</p>")}})
+	if _, err := VerifyZip(bytes.NewReader(data), int64(len(data)), public); err != ErrNoIntegrityManifest {
+		t.Errorf("err = %v, want %v", err, ErrNoIntegrityManifest)
+	}
+}
+
+func TestSigningKeyRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "signing.key")
+	key, err := LoadSigningKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("key file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	reloaded, err := LoadSigningKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !key.Equal(reloaded) {
+		t.Fatal("reloaded key differs from the generated one")
+	}
+
+	public := key.Public().(ed25519.PublicKey)
+	encoded, err := EncodePublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string][]byte{"public key": encoded, "key file": keyFile} {
+		parsed, err := ParsePublicKey(data)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if KeyID(parsed) != KeyID(public) {
+			t.Errorf("%s: key ID = %s, want %s", name, KeyID(parsed), KeyID(public))
+		}
+	}
+}
@@ -0,0 +1,515 @@
+package export
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/user/scrapper/internal/models"
+	"github.com/user/scrapper/internal/scraper"
+)
+
+// ExportsDirName holds cached export artifacts inside the project directory
+const ExportsDirName = "exports"
+
+// cacheVersion is part of every job ID; bump it when generators change output
+const cacheVersion = 2
+
+// jobIDPattern matches IDs produced by JobID
+var jobIDPattern = regexp.MustCompile(`^[0-9a-f]{16}$`)
+
+// ErrJobNotFound is returned for unknown jobs and artifacts
+var ErrJobNotFound = errors.New("export job not found")
+
+// ProgressFunc reports how many of total work items are done
+type ProgressFunc func(done, total int)
+
+// GenerateFunc writes an export artifact to path
+type GenerateFunc func(path string, progress ProgressFunc) error
+
+// artifactInfo is stored as exports/<job>.info.json next to the artifact
+type artifactInfo struct {
+	Format           string          `json:"format"`
+	Extension        string          `json:"extension"`
+	Options          json.RawMessage `json:"options,omitempty"`
+	SHA256           string          `json:"sha256"`
+	Size             int64           `json:"size"`
+	CreatedAt        time.Time       `json:"created_at"`
+	ProjectUpdatedAt time.Time       `json:"project_updated_at"` // Artifacts of re-saved projects are stale
+}
+
+// exportJob is a job with its runtime state
+type exportJob struct {
+	models.ExportJob
+	dataDir   string
+	extension string
+	err       error
+	done      chan struct{} // Closed when the job finishes
+}
+
+// JobManager runs export jobs in the background, a few at a time, and
+// reuses cached artifacts for repeated requests
+type JobManager struct {
+	mu    sync.Mutex
+	jobs  map[string]*exportJob // Keyed by project ID + "/" + job ID
+	slots chan struct{}
+}
+
+// NewJobManager creates a manager running at most concurrency jobs at once
+func NewJobManager(concurrency int) *JobManager {
+	return &JobManager{
+		jobs:  make(map[string]*exportJob),
+		slots: make(chan struct{}, concurrency),
+	}
+}
+
+// JobID derives the job and artifact ID from the format and its options
+func JobID(format string, options any) (string, error) {
+	data, err := json.Marshal(options)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d\n%s\n%s", cacheVersion, format, data)))
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// ValidJobID reports whether id can be a job ID
+func ValidJobID(id string) bool {
+	return jobIDPattern.MatchString(id)
+}
+
+// Start returns the job exporting a project in format with options. A
+// running job or a valid cached artifact is reused; otherwise generate runs
+// in the background and writes a file with the given extension.
+func (m *JobManager) Start(projectID, dataDir, format, extension string, options any, generate GenerateFunc) (models.ExportJob, error) {
+	project, err := scraper.LoadProject(projectID, dataDir)
+	if err != nil {
+		return models.ExportJob{}, err
+	}
+
+	id, err := JobID(format, options)
+	if err != nil {
+		return models.ExportJob{}, err
+	}
+	optionsJSON, _ := json.Marshal(options)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := projectID + "/" + id
+	if job, ok := m.jobs[key]; ok && (job.Status == models.ExportQueued || job.Status == models.ExportRunning) {
+		return job.ExportJob, nil
+	}
+
+	if info, ok := loadArtifact(projectID, dataDir, id, project.UpdatedAt); ok {
+		job := completedJob(projectID, dataDir, id, info)
+		if previous, ok := m.jobs[key]; ok && previous.Status == models.ExportCompleted {
+			job.ExportJob = previous.ExportJob
+		} else {
+			job.Cached = true
+		}
+		m.jobs[key] = job
+		return job.ExportJob, nil
+	}
+
+	job := &exportJob{
+		ExportJob: models.ExportJob{
+			ID:        id,
+			ProjectID: projectID,
+			Format:    format,
+			Status:    models.ExportQueued,
+			CreatedAt: time.Now(),
+		},
+		dataDir:   dataDir,
+		extension: extension,
+		done:      make(chan struct{}),
+	}
+	m.jobs[key] = job
+
+	go m.run(job, project.UpdatedAt, optionsJSON, generate)
+
+	return job.ExportJob, nil
+}
+
+// run generates the artifact of a job into a temporary file, then moves it
+// into place and records its checksum
+func (m *JobManager) run(job *exportJob, projectUpdated time.Time, options json.RawMessage, generate GenerateFunc) {
+	defer close(job.done)
+
+	m.slots <- struct{}{}
+	defer func() { <-m.slots }()
+
+	m.update(job, func(j *exportJob) { j.Status = models.ExportRunning })
+
+	path := artifactPath(job.ProjectID, job.dataDir, job.ID, job.extension)
+	tmpPath := path + ".tmp"
+
+	info := artifactInfo{
+		Format:           job.Format,
+		Extension:        job.extension,
+		Options:          options,
+		ProjectUpdatedAt: projectUpdated,
+	}
+
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err == nil {
+		err = generate(tmpPath, func(done, total int) {
+			if total > 0 && done <= total {
+				m.update(job, func(j *exportJob) { j.Progress = done * 99 / total })
+			}
+		})
+	}
+	if err == nil {
+		info.SHA256, info.Size, err = fileChecksum(tmpPath)
+	}
+	if err == nil {
+		// The old description must not vouch for the new file
+		os.Remove(infoPath(job.ProjectID, job.dataDir, job.ID))
+		err = os.Rename(tmpPath, path)
+	}
+	if err == nil {
+		info.CreatedAt = time.Now()
+		err = writeArtifactInfo(job.ProjectID, job.dataDir, job.ID, info)
+	}
+
+	if err != nil {
+		os.Remove(tmpPath)
+		log.Printf("Export %s of project %s failed: %v", job.Format, job.ProjectID, err)
+		m.update(job, func(j *exportJob) {
+			j.Status = models.ExportFailed
+			j.Error = err.Error()
+			j.err = err
+		})
+		return
+	}
+
+	m.update(job, func(j *exportJob) {
+		j.Status = models.ExportCompleted
+		j.Progress = 100
+		j.Size = info.Size
+		j.SHA256 = info.SHA256
+		j.CompletedAt = &info.CreatedAt
+	})
+}
+
+// update changes a job under the manager lock
+func (m *JobManager) update(job *exportJob, fn func(*exportJob)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fn(job)
+}
+
+// Get returns a job by ID; after a restart finished jobs are found from
+// their cached artifacts
+func (m *JobManager) Get(projectID, dataDir, id string) (models.ExportJob, error) {
+	m.mu.Lock()
+	job, ok := m.jobs[projectID+"/"+id]
+	var current models.ExportJob
+	if ok {
+		current = job.ExportJob
+	}
+	m.mu.Unlock()
+	if ok {
+		return current, nil
+	}
+
+	if !ValidJobID(id) {
+		return models.ExportJob{}, ErrJobNotFound
+	}
+	project, err := scraper.LoadProject(projectID, dataDir)
+	if err != nil {
+		return models.ExportJob{}, ErrJobNotFound
+	}
+	info, ok := loadArtifact(projectID, dataDir, id, project.UpdatedAt)
+	if !ok {
+		return models.ExportJob{}, ErrJobNotFound
+	}
+	found := completedJob(projectID, dataDir, id, info)
+	found.Cached = true
+	return found.ExportJob, nil
+}
+
+// Wait blocks until a job finishes and returns its error
+func (m *JobManager) Wait(ctx context.Context, projectID, id string) error {
+	m.mu.Lock()
+	job, ok := m.jobs[projectID+"/"+id]
+	m.mu.Unlock()
+	if !ok {
+		return ErrJobNotFound
+	}
+
+	select {
+	case <-job.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return job.err
+}
+
+// Artifact opens the file of a completed job
+func (m *JobManager) Artifact(projectID, dataDir, id string) (*os.File, models.ExportJob, error) {
+	job, err := m.Get(projectID, dataDir, id)
+	if err != nil {
+		return nil, job, err
+	}
+	if job.Status != models.ExportCompleted {
+		return nil, job, fmt.Errorf("export job is %s", job.Status)
+	}
+
+	info, err := readArtifactInfo(projectID, dataDir, id)
+	if err != nil {
+		return nil, job, ErrJobNotFound
+	}
+	file, err := os.Open(artifactPath(projectID, dataDir, id, info.Extension))
+	if err != nil {
+		return nil, job, ErrJobNotFound
+	}
+	return file, job, nil
+}
+
+// CleanupFinishedJobs forgets jobs finished more than maxAge ago; their
+// artifacts stay cached on disk until CleanupArtifacts removes them
+func (m *JobManager) CleanupFinishedJobs(maxAge time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, job := range m.jobs {
+		select {
+		case <-job.done:
+		default:
+			continue // Still running
+		}
+		if time.Since(job.CreatedAt) > maxAge {
+			delete(m.jobs, key)
+		}
+	}
+}
+
+// CleanupArtifacts deletes cached artifacts of the projects in dataDir that
+// are stale because the project was saved again, older than maxAge, or
+// beyond the newest maxSize bytes of their project. Files of queued and
+// running jobs are kept.
+func (m *JobManager) CleanupArtifacts(dataDir string, maxAge time.Duration, maxSize int64) {
+	projects, err := scraper.ListProjects(dataDir)
+	if err != nil {
+		log.Printf("Failed to list projects for export cleanup: %v", err)
+		return
+	}
+
+	for _, projectID := range projects {
+		if err := m.cleanupProjectArtifacts(projectID, dataDir, maxAge, maxSize); err != nil {
+			log.Printf("Failed to clean up exports of project %s: %v", projectID, err)
+		}
+	}
+}
+
+// cachedArtifact is an artifact found in a project exports directory
+type cachedArtifact struct {
+	id   string
+	info *artifactInfo // nil when the description is missing or invalid
+	size int64         // Bytes of all files of the artifact
+}
+
+// cleanupProjectArtifacts applies CleanupArtifacts to one project
+func (m *JobManager) cleanupProjectArtifacts(projectID, dataDir string, maxAge time.Duration, maxSize int64) error {
+	exportsDir := filepath.Join(dataDir, projectID, ExportsDirName)
+	entries, err := os.ReadDir(exportsDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Projects that cannot be loaded keep their artifacts until they can
+	project, err := scraper.LoadProject(projectID, dataDir)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	artifacts := make(map[string]*cachedArtifact)
+	for _, entry := range entries {
+		id, _, _ := strings.Cut(entry.Name(), ".")
+		if entry.IsDir() || !ValidJobID(id) {
+			continue
+		}
+		if job, ok := m.jobs[projectID+"/"+id]; ok && (job.Status == models.ExportQueued || job.Status == models.ExportRunning) {
+			continue
+		}
+
+		artifact, ok := artifacts[id]
+		if !ok {
+			artifact = &cachedArtifact{id: id}
+			artifact.info, _ = readArtifactInfo(projectID, dataDir, id)
+			artifacts[id] = artifact
+		}
+		if stat, err := entry.Info(); err == nil {
+			artifact.size += stat.Size()
+		}
+	}
+
+	// Newest first, so the size limit keeps the most recent artifacts
+	var kept []*cachedArtifact
+	for _, artifact := range artifacts {
+		info := artifact.info
+		switch {
+		case info == nil, !info.ProjectUpdatedAt.Equal(project.UpdatedAt), time.Since(info.CreatedAt) > maxAge:
+			m.removeArtifact(projectID, dataDir, artifact)
+		default:
+			kept = append(kept, artifact)
+		}
+	}
+	sort.Slice(kept, func(i, j int) bool { return kept[i].info.CreatedAt.After(kept[j].info.CreatedAt) })
+
+	var total int64
+	for _, artifact := range kept {
+		total += artifact.size
+		if total > maxSize {
+			m.removeArtifact(projectID, dataDir, artifact)
+		}
+	}
+	return nil
+}
+
+// removeArtifact deletes the files of a cached artifact and forgets its
+// job; caller must hold m.mu
+func (m *JobManager) removeArtifact(projectID, dataDir string, artifact *cachedArtifact) {
+	paths := []string{infoPath(projectID, dataDir, artifact.id)}
+	if artifact.info != nil {
+		path := artifactPath(projectID, dataDir, artifact.id, artifact.info.Extension)
+		paths = append(paths, path, path+".tmp")
+	} else {
+		// Without a description the extension is unknown
+		matches, _ := filepath.Glob(filepath.Join(dataDir, projectID, ExportsDirName, artifact.id+".*"))
+		paths = append(paths, matches...)
+	}
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove export artifact %s: %v", path, err)
+		}
+	}
+	delete(m.jobs, projectID+"/"+artifact.id)
+}
+
+// Cached artifact limits applied by the cleanup routine
+const (
+	maxArtifactAge         = 7 * 24 * time.Hour
+	maxProjectArtifactSize = 2 << 30
+)
+
+// StartCleanupRoutine runs periodic cleanup of jobs and of the cached
+// artifacts of the projects in dataDir
+func (m *JobManager) StartCleanupRoutine(dataDir string) {
+	ticker := time.NewTicker(5 * time.Minute)
+	go func() {
+		for range ticker.C {
+			m.CleanupFinishedJobs(30 * time.Minute)
+			m.CleanupArtifacts(dataDir, maxArtifactAge, maxProjectArtifactSize)
+		}
+	}()
+}
+
+// completedJob describes a cached artifact as a finished job
+func completedJob(projectID, dataDir, id string, info *artifactInfo) *exportJob {
+	done := make(chan struct{})
+	close(done)
+	completedAt := info.CreatedAt
+	return &exportJob{
+		ExportJob: models.ExportJob{
+			ID:          id,
+			ProjectID:   projectID,
+			Format:      info.Format,
+			Status:      models.ExportCompleted,
+			Progress:    100,
+			Size:        info.Size,
+			SHA256:      info.SHA256,
+			CreatedAt:   info.CreatedAt,
+			CompletedAt: &completedAt,
+		},
+		dataDir:   dataDir,
+		extension: info.Extension,
+		done:      done,
+	}
+}
+
+// loadArtifact returns the description of a cached artifact that still
+// matches its file and the saved project
+func loadArtifact(projectID, dataDir, id string, projectUpdated time.Time) (*artifactInfo, bool) {
+	info, err := readArtifactInfo(projectID, dataDir, id)
+	if err != nil || !info.ProjectUpdatedAt.Equal(projectUpdated) {
+		return nil, false
+	}
+
+	stat, err := os.Stat(artifactPath(projectID, dataDir, id, info.Extension))
+	if err != nil || stat.Size() != info.Size {
+		return nil, false
+	}
+
+	return info, true
+}
+
+// readArtifactInfo reads the description of a cached artifact
+func readArtifactInfo(projectID, dataDir, id string) (*artifactInfo, error) {
+	data, err := os.ReadFile(infoPath(projectID, dataDir, id))
+	if err != nil {
+		return nil, err
+	}
+	var info artifactInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// writeArtifactInfo writes the description of a cached artifact
+func writeArtifactInfo(projectID, dataDir, id string, info artifactInfo) error {
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(infoPath(projectID, dataDir, id), data, 0644)
+}
+
+// artifactPath is where the artifact of a job is cached
+func artifactPath(projectID, dataDir, id, extension string) string {
+	return filepath.Join(dataDir, projectID, ExportsDirName, id+"."+extension)
+}
+
+// infoPath is where the description of a cached artifact is stored
+func infoPath(projectID, dataDir, id string) string {
+	return filepath.Join(dataDir, projectID, ExportsDirName, id+".info.json")
+}
+
+// fileChecksum returns the hex SHA-256 and size of a file
+func fileChecksum(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
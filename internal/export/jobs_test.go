@@ -0,0 +1,126 @@
+package export
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// startTestExport runs a job writing content and waits for it
+func startTestExport(t *testing.T, m *JobManager, dataDir, projectID, format, content string) string {
+	t.Helper()
+	job, err := m.Start(projectID, dataDir, format, "txt", nil, func(path string, progress ProgressFunc) error {
+		return os.WriteFile(path, []byte(content), 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Wait(context.Background(), projectID, job.ID); err != nil {
+		t.Fatal(err)
+	}
+	return job.ID
+}
+
+// artifactExists reports whether the artifact and its description are on disk
+func artifactExists(dataDir, projectID, id string) bool {
+	_, artifactErr := os.Stat(artifactPath(projectID, dataDir, id, "txt"))
+	_, infoErr := os.Stat(infoPath(projectID, dataDir, id))
+	return artifactErr == nil && infoErr == nil
+}
+
+func TestCleanupArtifacts(t *testing.T) {
+	const projectID = "project"
+
+	t.Run("keeps fresh artifacts", func(t *testing.T) {
+		dataDir := t.TempDir()
+		writeTestProject(t, dataDir, projectID)
+		m := NewJobManager(1)
+		id := startTestExport(t, m, dataDir, projectID, "a", "content")
+
+		m.CleanupArtifacts(dataDir, time.Hour, 1<<20)
+		if !artifactExists(dataDir, projectID, id) {
+			t.Error("fresh artifact was removed")
+		}
+	})
+
+	t.Run("removes stale artifacts", func(t *testing.T) {
+		dataDir := t.TempDir()
+		projectDir := writeTestProject(t, dataDir, projectID)
+		m := NewJobManager(1)
+		id := startTestExport(t, m, dataDir, projectID, "a", "content")
+
+		// The project is saved again
+		project := `{"project_id":"` + projectID + `","updated_at":"2026-01-02T03:04:05Z"}`
+		if err := os.WriteFile(filepath.Join(projectDir, "project.json"), []byte(project), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		m.CleanupArtifacts(dataDir, time.Hour, 1<<20)
+		if artifactExists(dataDir, projectID, id) {
+			t.Error("stale artifact was kept")
+		}
+		if _, err := m.Get(projectID, dataDir, id); err != ErrJobNotFound {
+			t.Errorf("job of a removed artifact: err = %v, want %v", err, ErrJobNotFound)
+		}
+	})
+
+	t.Run("removes old artifacts", func(t *testing.T) {
+		dataDir := t.TempDir()
+		writeTestProject(t, dataDir, projectID)
+		m := NewJobManager(1)
+		id := startTestExport(t, m, dataDir, projectID, "a", "content")
+
+		m.CleanupArtifacts(dataDir, 0, 1<<20)
+		if artifactExists(dataDir, projectID, id) {
+			t.Error("old artifact was kept")
+		}
+	})
+
+	t.Run("keeps the newest within the size limit", func(t *testing.T) {
+		dataDir := t.TempDir()
+		writeTestProject(t, dataDir, projectID)
+		m := NewJobManager(1)
+		older := startTestExport(t, m, dataDir, projectID, "a", "older content")
+		time.Sleep(10 * time.Millisecond)
+		newer := startTestExport(t, m, dataDir, projectID, "b", "newer content")
+
+		info, err := readArtifactInfo(projectID, dataDir, newer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stat, err := os.Stat(infoPath(projectID, dataDir, newer))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m.CleanupArtifacts(dataDir, time.Hour, info.Size+stat.Size())
+		if artifactExists(dataDir, projectID, older) {
+			t.Error("older artifact over the size limit was kept")
+		}
+		if !artifactExists(dataDir, projectID, newer) {
+			t.Error("newest artifact was removed")
+		}
+	})
+
+	t.Run("removes artifacts without a description", func(t *testing.T) {
+		dataDir := t.TempDir()
+		writeTestProject(t, dataDir, projectID)
+		orphan := filepath.Join(dataDir, projectID, ExportsDirName, "0123456789abcdef.pdf")
+		other := filepath.Join(dataDir, projectID, ExportsDirName, "old.zip")
+		for _, path := range []string{orphan, other} {
+			if err := os.WriteFile(path, []byte("left over"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		NewJobManager(1).CleanupArtifacts(dataDir, time.Hour, 1<<20)
+		if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+			t.Error("artifact without a description was kept")
+		}
+		if _, err := os.Stat(other); err != nil {
+			t.Error("file not named like an artifact was removed")
+		}
+	})
+}
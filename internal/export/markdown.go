@@ -0,0 +1,537 @@
+package export
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/user/scrapper/internal/models"
+	"golang.org/x/net/html"
+)
+
+// pageExtensions are stripped from URL paths before adding ".md"
+var pageExtensions = map[string]bool{
+	".html": true, ".htm": true, ".xhtml": true, ".php": true, ".asp": true, ".aspx": true, ".jsp": true,
+}
+
+// markdownBlockStart matches paragraph openings Markdown would read as syntax
+var markdownBlockStart = regexp.MustCompile(`^(#|>|[-+*] |\d+[.)] |={3,}|-{3,})`)
+
+// markdownEscaper escapes inline Markdown syntax in text
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`, `<`, `\<`,
+)
+
+// CreateMarkdownArchive writes a ZIP with one Markdown file per saved page to
+// zipPath. Paths mirror the site, links between pages point to the .md
+// files and linked images are included. progress, if set, is called after
+// each page.
+func CreateMarkdownArchive(zipPath, projectID, dataDir string, progress ProgressFunc) error {
+	projectDir := filepath.Join(dataDir, projectID)
+
+	chapters, err := loadChapters(projectID, dataDir, models.PDFOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list pages: %w", err)
+	}
+	if len(chapters) == 0 {
+		return ErrNoChapters
+	}
+
+	links := newArchiveLinks(projectDir, nil)
+
+	// Archive paths mirroring the site hierarchy
+	paths := make([]string, len(chapters))
+	used := make(map[string]bool)
+	for i, chapter := range chapters {
+		var mdPath string
+		if chapter.URL != "" {
+			mdPath = sitePath(chapter.URL, ".md")
+		} else {
+			rel, _ := filepath.Rel(projectDir, chapter.Path)
+			mdPath = strings.TrimSuffix(filepath.ToSlash(rel), filepath.Ext(rel)) + ".md"
+		}
+		mdPath = uniqueArchivePath(mdPath, used)
+
+		paths[i] = mdPath
+		links.addPage(chapter, mdPath)
+	}
+
+	file, err := os.Create(zipPath)
+	if err != nil {
+		return fmt.Errorf("failed to create zip file: %w", err)
+	}
+	defer file.Close()
+
+	zipWriter := zip.NewWriter(file)
+
+	for i, chapter := range chapters {
+		markdown, err := pageMarkdown(chapter, paths[i], links)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s: %w", chapter.Source, err)
+		}
+
+		if err := writeZipEntry(zipWriter, paths[i], []byte(markdown), chapter.Date); err != nil {
+			return err
+		}
+
+		if progress != nil {
+			progress(i+1, len(chapters))
+		}
+	}
+
+	// Images and files the pages link to, under their project paths
+	if err := links.writeAttachments(zipWriter, ""); err != nil {
+		return err
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// pageMarkdown converts one page to Markdown with YAML front matter
+func pageMarkdown(chapter *pdfChapter, mdPath string, links *archiveLinks) (string, error) {
+	doc, err := parseHTMLFile(chapter.Source)
+	if err != nil {
+		return "", err
+	}
+
+	root := findElement(doc, "body")
+	if root == nil {
+		root = doc
+	}
+
+	htmlDir := filepath.Dir(chapter.Source)
+	conv := &markdownConverter{
+		rewrite: func(href string) string {
+			target, _ := links.rewrite(mdPath, htmlDir, chapter.URL, href)
+			return target
+		},
+	}
+
+	var b strings.Builder
+	b.WriteString("---\n")
+	writeFrontMatter(&b, "title", chapter.Title)
+	if chapter.URL != "" {
+		writeFrontMatter(&b, "source_url", chapter.URL)
+	}
+	if !chapter.Date.IsZero() {
+		writeFrontMatter(&b, "captured_at", chapter.Date.UTC().Format(time.RFC3339))
+	}
+	b.WriteString("---\n\n")
+
+	if body := strings.Join(conv.blocks(root), "\n\n"); body != "" {
+		b.WriteString(body)
+		b.WriteString("\n")
+	}
+
+	return b.String(), nil
+}
+
+// sitePath maps a page URL to an archive path mirroring the site with the
+// given extension: for ".md", "/" is index.md and "/docs/intro.html" is
+// docs/intro.md
+func sitePath(pageURL, extension string) string {
+	parsed, err := url.Parse(pageURL)
+	if err != nil {
+		return "page" + extension
+	}
+
+	p := parsed.Path
+	if p == "" || strings.HasSuffix(p, "/") {
+		p += "index"
+	}
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	if ext := path.Ext(p); pageExtensions[strings.ToLower(ext)] {
+		p = strings.TrimSuffix(p, ext)
+	}
+
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segments[i] = sanitizeSegment(segment)
+	}
+	p = strings.Join(segments, "/")
+
+	// Pages differing only by query get distinct files
+	if parsed.RawQuery != "" {
+		sum := sha256.Sum256([]byte(parsed.RawQuery))
+		p += "-" + hex.EncodeToString(sum[:4])
+	}
+
+	return p + extension
+}
+
+// sanitizeSegment keeps letters, digits, '.', '_' and '-' in a path segment
+func sanitizeSegment(segment string) string {
+	segment = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '_' || r == '-' {
+			return r
+		}
+		return '-'
+	}, segment)
+	if strings.Trim(segment, ".") == "" {
+		return "page"
+	}
+	return segment
+}
+
+// writeFrontMatter writes a YAML key with a double-quoted string value
+func writeFrontMatter(b *strings.Builder, key, value string) {
+	quoted, _ := json.Marshal(value) // JSON strings are valid YAML
+	fmt.Fprintf(b, "%s: %s\n", key, quoted)
+}
+
+// markdownConverter turns an HTML tree into CommonMark with GFM tables
+type markdownConverter struct {
+	rewrite func(href string) string // Maps link and image URLs
+}
+
+// blocks converts the children of node to Markdown blocks; runs of inline
+// content become paragraphs
+func (c *markdownConverter) blocks(node *html.Node) []string {
+	var blocks []string
+	var paragraph strings.Builder
+
+	flush := func() {
+		if text := cleanInline(paragraph.String()); text != "" {
+			if markdownBlockStart.MatchString(text) {
+				text = `\` + text
+			}
+			blocks = append(blocks, text)
+		}
+		paragraph.Reset()
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && skippedElements[child.Data] {
+			continue
+		}
+		if child.Type == html.ElementNode && markdownBlocks[child.Data] {
+			flush()
+			if block := c.block(child); block != "" {
+				blocks = append(blocks, block)
+			}
+			continue
+		}
+		paragraph.WriteString(c.inline(child))
+	}
+	flush()
+
+	return blocks
+}
+
+// markdownBlocks are elements converted as blocks
+var markdownBlocks = map[string]bool{
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"p": true, "ul": true, "ol": true, "pre": true, "blockquote": true, "hr": true,
+	"table": true, "dl": true, "dt": true, "dd": true, "figcaption": true, "li": true,
+	"div": true, "section": true, "article": true, "main": true, "header": true,
+	"footer": true, "nav": true, "aside": true, "figure": true, "form": true,
+	"address": true, "details": true, "summary": true, "center": true,
+	"fieldset": true, "caption": true, "body": true,
+}
+
+// block converts one block element
+func (c *markdownConverter) block(node *html.Node) string {
+	switch node.Data {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		text := cleanInline(c.inlineChildren(node))
+		if text == "" {
+			return ""
+		}
+		return strings.Repeat("#", int(node.Data[1]-'0')) + " " + strings.ReplaceAll(text, "\\\n", " ")
+	case "p", "dt", "figcaption", "summary", "caption":
+		text := cleanInline(c.inlineChildren(node))
+		if text == "" {
+			return ""
+		}
+		switch node.Data {
+		case "dt":
+			return "**" + text + "**"
+		case "figcaption", "caption":
+			return "_" + text + "_"
+		}
+		if markdownBlockStart.MatchString(text) {
+			text = `\` + text
+		}
+		return text
+	case "ul", "ol":
+		return c.list(node)
+	case "pre":
+		return codeBlock(node)
+	case "blockquote":
+		return prefixLines(strings.Join(c.blocks(node), "\n\n"), "> ", ">")
+	case "hr":
+		return "---"
+	case "table":
+		return c.table(node)
+	case "dd":
+		return prefixLines(strings.Join(c.blocks(node), "\n\n"), ": ", "")
+	}
+	return strings.Join(c.blocks(node), "\n\n")
+}
+
+// list converts ul/ol; item content is indented under its marker
+func (c *markdownConverter) list(node *html.Node) string {
+	ordered := node.Data == "ol"
+	counter := 1
+	if start := getAttr(node, "start"); ordered && start != "" {
+		fmt.Sscanf(start, "%d", &counter)
+	}
+
+	var items []string
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || child.Data != "li" {
+			continue
+		}
+
+		marker := "- "
+		if ordered {
+			marker = fmt.Sprintf("%d. ", counter)
+			counter++
+		}
+
+		blocks := c.blocks(child)
+		separator := "\n"
+		if countParagraphs(child) > 1 {
+			separator = "\n\n"
+		}
+		content := strings.Join(blocks, separator)
+		indent := strings.Repeat(" ", len(marker))
+		items = append(items, marker+prefixLines(content, indent, "")[len(indent):])
+	}
+
+	return strings.Join(items, "\n")
+}
+
+// countParagraphs counts direct paragraph children of a list item
+func countParagraphs(node *html.Node) int {
+	count := 0
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.Data == "p" {
+			count++
+		}
+	}
+	return count
+}
+
+// codeBlock converts pre to a fenced block longer than any backtick run inside
+func codeBlock(node *html.Node) string {
+	text := strings.Trim(strings.ReplaceAll(textContent(node), "\r\n", "\n"), "\n")
+
+	lang := codeLanguage(node)
+	if code := findElement(node, "code"); lang == "" && code != nil {
+		lang = codeLanguage(code)
+	}
+
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + text + "\n" + fence
+}
+
+// codeLanguage reads a language-* or lang-* class
+func codeLanguage(node *html.Node) string {
+	for _, class := range strings.Fields(getAttr(node, "class")) {
+		for _, prefix := range []string{"language-", "lang-"} {
+			if strings.HasPrefix(class, prefix) {
+				return strings.TrimPrefix(class, prefix)
+			}
+		}
+	}
+	return ""
+}
+
+// table converts a table to GFM; the first row is the header and colspan
+// cells are followed by empty cells
+func (c *markdownConverter) table(node *html.Node) string {
+	var rows [][]string
+	columns := 0
+	for _, tr := range tableRows(node) {
+		var row []string
+		for cell := tr.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.Type != html.ElementNode || (cell.Data != "td" && cell.Data != "th") {
+				continue
+			}
+			text := strings.Join(c.blocks(cell), " ")
+			text = strings.ReplaceAll(strings.ReplaceAll(text, "\\\n", "<br>"), "\n", " ")
+			row = append(row, strings.ReplaceAll(text, "|", `\|`))
+
+			span := 1
+			fmt.Sscanf(getAttr(cell, "colspan"), "%d", &span)
+			for i := 1; i < span && i < 100; i++ {
+				row = append(row, "")
+			}
+		}
+		if len(row) > columns {
+			columns = len(row)
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 || columns == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	writeRow := func(row []string) {
+		b.WriteString("|")
+		for i := 0; i < columns; i++ {
+			cell := ""
+			if i < len(row) {
+				cell = row[i]
+			}
+			b.WriteString(" " + cell + " |")
+		}
+		b.WriteString("\n")
+	}
+
+	writeRow(rows[0])
+	b.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// inlineChildren converts the children of node as inline content
+func (c *markdownConverter) inlineChildren(node *html.Node) string {
+	var b strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(c.inline(child))
+	}
+	return b.String()
+}
+
+// inline converts a node inside a paragraph; whitespace is collapsed later
+func (c *markdownConverter) inline(node *html.Node) string {
+	switch node.Type {
+	case html.TextNode:
+		return markdownEscaper.Replace(node.Data)
+	case html.ElementNode:
+	default:
+		return ""
+	}
+
+	if skippedElements[node.Data] {
+		return ""
+	}
+
+	switch node.Data {
+	case "br":
+		return "\\\n"
+	case "strong", "b":
+		return wrapInline(c.inlineChildren(node), "**")
+	case "em", "i", "cite", "dfn":
+		return wrapInline(c.inlineChildren(node), "_")
+	case "del", "s", "strike":
+		return wrapInline(c.inlineChildren(node), "~~")
+	case "code", "kbd", "samp", "tt":
+		return inlineCode(textContent(node))
+	case "img":
+		src := getAttr(node, "src")
+		if src == "" {
+			return ""
+		}
+		alt := strings.Join(strings.Fields(markdownEscaper.Replace(getAttr(node, "alt"))), " ")
+		return "![" + alt + "](" + markdownURL(c.rewrite(src)) + ")"
+	case "a":
+		text := c.inlineChildren(node)
+		href := strings.TrimSpace(getAttr(node, "href"))
+		if href == "" || strings.HasPrefix(strings.ToLower(href), "javascript:") || strings.TrimSpace(text) == "" {
+			return text
+		}
+		lead, inner, trail := splitSpace(text)
+		return lead + "[" + inner + "](" + markdownURL(c.rewrite(href)) + ")" + trail
+	}
+
+	return c.inlineChildren(node)
+}
+
+// wrapInline puts emphasis markers around text, keeping outer spaces outside
+func wrapInline(text, marker string) string {
+	lead, inner, trail := splitSpace(text)
+	if inner == "" {
+		return text
+	}
+	return lead + marker + inner + marker + trail
+}
+
+// splitSpace separates leading and trailing whitespace from text
+func splitSpace(text string) (string, string, string) {
+	inner := strings.TrimSpace(text)
+	if inner == "" {
+		return text, "", ""
+	}
+	start := strings.Index(text, inner)
+	return text[:start], inner, text[start+len(inner):]
+}
+
+// inlineCode wraps text in enough backticks to contain its own
+func inlineCode(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return ""
+	}
+	fence := "`"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		return fence + " " + text + " " + fence
+	}
+	return fence + text + fence
+}
+
+// markdownURL makes a link destination safe inside (...)
+func markdownURL(target string) string {
+	if strings.ContainsAny(target, " ()<>") {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(target) + ">"
+	}
+	return target
+}
+
+// cleanInline collapses whitespace of converted inline content; hard line
+// breaks are kept
+func cleanInline(text string) string {
+	lines := strings.Split(text, "\\\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	// No hard breaks at the start or end of a block
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\\\n")
+}
+
+// prefixLines prefixes every line of text; empty lines get emptyPrefix
+func prefixLines(text, prefix, emptyPrefix string) string {
+	if text == "" {
+		return ""
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = emptyPrefix
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,306 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/user/scrapper/internal/models"
+	"github.com/user/scrapper/internal/scraper"
+	"golang.org/x/net/html"
+)
+
+// textBreaks end a paragraph of the plain text of a page
+var textBreaks = map[string]bool{"br": true, "tr": true}
+
+// WritePagesJSONL writes one models.PageRecord per crawled page as JSON
+// Lines, in the order pages were first recorded. Pages that failed or were
+// not saved are included with empty text and assets.
+func WritePagesJSONL(w io.Writer, projectID, dataDir string) error {
+	projectDir := filepath.Join(dataDir, projectID)
+
+	entries, err := scraper.LoadManifest(projectID, dataDir)
+	if err != nil {
+		return err
+	}
+
+	// Downloaded assets by project path, to map page references to URLs
+	assets := make(map[string]string)
+	for _, entry := range entries {
+		if entry.Kind == models.ManifestKindAsset && entry.LocalPath != "" {
+			assets[entry.LocalPath] = entry.URL
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	for _, entry := range entries {
+		if entry.Kind != models.ManifestKindPage {
+			continue
+		}
+		if err := encoder.Encode(pageRecord(entry, projectDir, assets)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pageRecord builds the export record of a manifest page
+func pageRecord(entry models.ManifestEntry, projectDir string, assets map[string]string) models.PageRecord {
+	record := models.PageRecord{
+		SchemaVersion: models.PageRecordSchemaVersion,
+		URL:           entry.URL,
+		Depth:         entry.Depth,
+		ParentURL:     entry.ParentURL,
+		StatusCode:    entry.StatusCode,
+		FetchedAt:     entry.FetchedAt,
+		Headings:      []models.Heading{},
+		Links:         []models.Link{},
+		Assets:        []string{},
+	}
+	if entry.Metadata != nil {
+		record.CanonicalURL = entry.Metadata.Canonical
+		record.Title = entry.Metadata.Title
+		record.Language = entry.Metadata.Language
+		if entry.Metadata.Headings != nil {
+			record.Headings = entry.Metadata.Headings
+		}
+	}
+	if entry.Links != nil {
+		record.Links = entry.Links
+	}
+
+	if entry.LocalPath == "" {
+		return record
+	}
+	pagePath := filepath.Join(projectDir, filepath.FromSlash(entry.LocalPath))
+	if !fileExists(pagePath) {
+		return record
+	}
+
+	if content := pageContent(entry, projectDir); content != nil {
+		record.Text = plainText(content)
+	}
+	record.Assets = pageAssets(pagePath, entry.URL, projectDir, assets)
+
+	return record
+}
+
+// pageContent returns the main content of a saved page: its extracted
+// content file, or else the content found by the extraction heuristics
+func pageContent(entry models.ManifestEntry, projectDir string) *html.Node {
+	if entry.ContentPath != "" {
+		if doc, err := parseHTMLFile(filepath.Join(projectDir, filepath.FromSlash(entry.ContentPath))); err == nil {
+			return doc
+		}
+	}
+
+	file, err := os.Open(filepath.Join(projectDir, filepath.FromSlash(entry.LocalPath)))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	doc, err := goquery.NewDocumentFromReader(file)
+	if err != nil {
+		return nil
+	}
+	content, err := html.Parse(strings.NewReader(scraper.ExtractMainContent(doc, entry.URL, nil)))
+	if err != nil {
+		return nil
+	}
+	return content
+}
+
+// plainText returns the visible text below node with whitespace collapsed;
+// blocks become paragraphs separated by blank lines
+func plainText(node *html.Node) string {
+	blocks := textBlocks(node)
+	paragraphs := make([]string, len(blocks))
+	for i, block := range blocks {
+		paragraphs[i] = block.text
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// textBlock is a paragraph or heading of the plain text of a page
+type textBlock struct {
+	heading int // Heading level 1-6, 0 for paragraphs
+	text    string
+	id      string // Anchor id of a heading
+}
+
+// textBlocks splits the visible text below node into headings and
+// paragraphs with whitespace collapsed
+func textBlocks(node *html.Node) []textBlock {
+	var blocks []textBlock
+	var current strings.Builder
+
+	flush := func() {
+		if text := strings.Join(strings.Fields(current.String()), " "); text != "" {
+			blocks = append(blocks, textBlock{text: text})
+		}
+		current.Reset()
+	}
+
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		switch node.Type {
+		case html.TextNode:
+			current.WriteString(node.Data)
+			return
+		case html.ElementNode:
+			if skippedElements[node.Data] || hasAttr(node, "hidden") {
+				return
+			}
+			if level := headingLevel(node.Data); level > 0 {
+				flush()
+				for child := node.FirstChild; child != nil; child = child.NextSibling {
+					walk(child)
+				}
+				if text := strings.Join(strings.Fields(current.String()), " "); text != "" {
+					blocks = append(blocks, textBlock{heading: level, text: text, id: headingID(node)})
+				}
+				current.Reset()
+				return
+			}
+		}
+
+		block := node.Type == html.ElementNode && (markdownBlocks[node.Data] || textBreaks[node.Data])
+		if block {
+			flush()
+		}
+		// Keep table cells of a row apart
+		if node.Type == html.ElementNode && (node.Data == "td" || node.Data == "th") {
+			current.WriteByte(' ')
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+		if block {
+			flush()
+		}
+	}
+	walk(node)
+	flush()
+
+	return blocks
+}
+
+// headingLevel returns 1-6 for h1-h6 and 0 for other elements
+func headingLevel(tag string) int {
+	if len(tag) == 2 && tag[0] == 'h' && tag[1] >= '1' && tag[1] <= '6' {
+		return int(tag[1] - '0')
+	}
+	return 0
+}
+
+// headingID is the anchor of a heading: its id, or the name or id of an
+// anchor inside it
+func headingID(node *html.Node) string {
+	if id := getAttr(node, "id"); id != "" {
+		return id
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || child.Data != "a" {
+			continue
+		}
+		if id := getAttr(child, "id"); id != "" {
+			return id
+		}
+		if name := getAttr(child, "name"); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// pageAssets lists the URLs of downloaded assets a saved page refers to,
+// in document order. Besides attributes, url() references in style
+// attributes and <style> blocks count; references link rewriting left
+// alone are resolved against pageURL.
+func pageAssets(pagePath, pageURL, projectDir string, assets map[string]string) []string {
+	refs := []string{}
+
+	doc, err := parseHTMLFile(pagePath)
+	if err != nil {
+		return refs
+	}
+
+	known := make(map[string]bool, len(assets)) // Asset URLs
+	for _, assetURL := range assets {
+		known[assetURL] = true
+	}
+	base, _ := url.Parse(pageURL)
+
+	htmlDir := filepath.Dir(pagePath)
+	seen := make(map[string]bool)
+	add := func(ref string) {
+		var assetURL string
+		if file := resolveProjectFile(projectDir, htmlDir, ref); file != "" {
+			if rel, err := filepath.Rel(projectDir, file); err == nil {
+				assetURL = assets[filepath.ToSlash(rel)]
+			}
+		}
+		if assetURL == "" && base != nil {
+			if parsed, err := base.Parse(strings.TrimSpace(ref)); err == nil {
+				parsed.Fragment = ""
+				if known[parsed.String()] {
+					assetURL = parsed.String()
+				}
+			}
+		}
+		if assetURL != "" && !seen[assetURL] {
+			seen[assetURL] = true
+			refs = append(refs, assetURL)
+		}
+	}
+	addCSS := func(css string) {
+		for _, match := range cssURLPattern.FindAllStringSubmatch(css, -1) {
+			if ref := strings.TrimSpace(match[1]); ref != "" && !strings.HasPrefix(ref, "data:") {
+				add(ref)
+			}
+		}
+	}
+
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			for _, attr := range node.Attr {
+				switch attr.Key {
+				case "src", "poster", "data":
+					add(attr.Val)
+				case "href":
+					if node.Data == "link" {
+						add(attr.Val)
+					}
+				case "srcset":
+					for _, candidate := range strings.Split(attr.Val, ",") {
+						if fields := strings.Fields(candidate); len(fields) > 0 {
+							add(fields[0])
+						}
+					}
+				case "style":
+					addCSS(attr.Val)
+				}
+			}
+			if node.Data == "style" {
+				for child := node.FirstChild; child != nil; child = child.NextSibling {
+					if child.Type == html.TextNode {
+						addCSS(child.Data)
+					}
+				}
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+
+	return refs
+}
@@ -0,0 +1,54 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPageAssetsInlineCSS(t *testing.T) {
+	projectDir := t.TempDir()
+	assets := map[string]string{ // Project file -> URL
+		"assets/img/hero.png":   "https://example.com/img/hero.png",
+		"assets/font/a.woff2":   "https://example.com/fonts/a.woff2",
+		"assets/img/logo.png":   "https://example.com/img/logo.png",
+		"assets/img/unused.png": "https://example.com/img/unused.png",
+	}
+	for name := range assets {
+		path := filepath.Join(projectDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Link rewriting turns style attributes into project paths but leaves
+	// <style> blocks with the original URLs
+	page := `<html><head><style>
+		@font-face { font-family: A; src: url("/fonts/a.woff2#v1") format("woff2"); }
+		.x { background: url(data:image/png;base64,AAAA); }
+	</style></head><body>
+		<div style="background-image: url('../assets/img/hero.png')"></div>
+		<img src="../assets/img/logo.png">
+	</body></html>`
+	pagePath := filepath.Join(projectDir, "pages", "p.html")
+	if err := os.MkdirAll(filepath.Dir(pagePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pagePath, []byte(page), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := pageAssets(pagePath, "https://example.com/docs/p.html", projectDir, assets)
+	want := []string{
+		"https://example.com/fonts/a.woff2",
+		"https://example.com/img/hero.png",
+		"https://example.com/img/logo.png",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("pageAssets = %q, want %q", got, want)
+	}
+}
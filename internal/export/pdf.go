@@ -2,52 +2,99 @@ package export
 
 import (
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 
 	"github.com/jung-kurt/gofpdf"
+	"github.com/user/scrapper/internal/models"
+	"github.com/user/scrapper/internal/scraper"
 )
 
-// CreateConsolidatedPDF generates a single PDF from all HTML pages
-func CreateConsolidatedPDF(projectID, dataDir string) (string, error) {
+// CreateConsolidatedPDF writes a single PDF from all HTML pages to pdfPath:
+// an optional cover, a table of contents, then one chapter per page with a
+// matching outline entry. progress, if set, is called after each chapter.
+func CreateConsolidatedPDF(pdfPath, projectID, dataDir string, opts models.PDFOptions, progress ProgressFunc) error {
 	projectDir := filepath.Join(dataDir, projectID)
-	pdfPath := filepath.Join(dataDir, projectID+".pdf")
+
+	project, err := scraper.LoadProject(projectID, dataDir)
+	if err != nil {
+		return fmt.Errorf("failed to load project: %w", err)
+	}
 
 	// Initialize PDF
-	pdf := gofpdf.New("P", "mm", "A4", "")
-	pdf.SetMargins(20, 20, 20)
-	pdf.SetFont("Arial", "", 12)
+	orientation, size, margins := pageLayout(opts)
+	pdf := gofpdf.New(orientation, "mm", size.name, "")
+	pdf.SetMargins(margins.Left, margins.Top, margins.Right)
+	pdf.SetAutoPageBreak(true, margins.Bottom)
+
+	// UTF-8 fonts (core fonts only cover cp1252)
+	fonts, err := newFontSet(pdf)
+	if err != nil {
+		return fmt.Errorf("failed to load PDF fonts: %w", err)
+	}
+	renderer := newHTMLRenderer(pdf, fonts, projectDir)
+	renderer.noImages = opts.ExcludeImages
+	if opts.FontSize > 0 {
+		renderer.bodySize = opts.FontSize
+	}
 
-	// Find all HTML files
-	htmlFiles, err := findHTMLFiles(projectDir)
+	// Saved pages in chapter order
+	chapters, err := loadChapters(projectID, dataDir, opts)
 	if err != nil {
-		return "", fmt.Errorf("failed to find HTML files: %w", err)
+		return fmt.Errorf("failed to list chapters: %w", err)
+	}
+
+	if len(chapters) == 0 {
+		return ErrNoChapters
+	}
+
+	// Links are allocated up front so pages can point to later chapters
+	for _, chapter := range chapters {
+		chapter.link = pdf.AddLink()
+		renderer.pageLinks[chapter.Path] = chapter.link
+		if chapter.URL != "" {
+			renderer.pageLinks[chapter.URL] = chapter.link
+		}
 	}
 
-	if len(htmlFiles) == 0 {
-		return "", fmt.Errorf("no HTML files found in project")
+	if opts.Cover != nil {
+		addCoverToPDF(pdf, renderer, opts.Cover, project, dataDir, chapters[0].Title)
 	}
 
-	// Process each HTML file as a chapter
-	for i, htmlPath := range htmlFiles {
-		// Determine chapter title
-		chapterTitle := getChapterTitle(htmlPath, projectDir, i)
+	// Reserve table of contents pages; filled once page numbers are known
+	tocStart := pdf.PageCount() + 1
+	tocPages := tocPageCount(pdf, len(chapters))
+	for i := 0; i < tocPages; i++ {
+		pdf.AddPage()
+		if i == 0 {
+			fonts.SetFont(textStyle{Size: bodyFontSize})
+			pdf.Bookmark("Table of Contents", 0, -1)
+		}
+	}
 
-		// Add chapter
-		if err := addChapterToPDF(pdf, htmlPath, chapterTitle); err != nil {
-			return "", fmt.Errorf("failed to add chapter %s: %w", chapterTitle, err)
+	// Process each page as a chapter
+	for i, chapter := range chapters {
+		if err := addChapterToPDF(pdf, renderer, chapter); err != nil {
+			return fmt.Errorf("failed to add chapter %s: %w", chapter.Title, err)
+		}
+		if progress != nil {
+			progress(i+1, len(chapters))
 		}
 	}
 
+	writeTOC(pdf, fonts, tocStart, chapters)
+
+	if opts.Header != nil || opts.Footer != nil {
+		decoratePages(pdf, fonts, opts, tocStart, chapters, project.CreatedAt)
+	}
+
 	// Save PDF
 	if err := pdf.OutputFileAndClose(pdfPath); err != nil {
-		return "", fmt.Errorf("failed to save PDF: %w", err)
+		return fmt.Errorf("failed to save PDF: %w", err)
 	}
 
-	return pdfPath, nil
+	return nil
 }
 
 // findHTMLFiles recursively finds all HTML files in project
@@ -67,7 +114,7 @@ func findHTMLFiles(projectDir string) ([]string, error) {
 			if err != nil {
 				return err
 			}
-			if !info.IsDir() && strings.HasSuffix(info.Name(), ".html") {
+			if !info.IsDir() && strings.HasSuffix(info.Name(), ".html") && !strings.HasSuffix(info.Name(), scraper.ContentSuffix) {
 				htmlFiles = append(htmlFiles, path)
 			}
 			return nil
@@ -80,114 +127,41 @@ func findHTMLFiles(projectDir string) ([]string, error) {
 	return htmlFiles, nil
 }
 
-// getChapterTitle determines chapter title from file path
-func getChapterTitle(htmlPath, projectDir string, index int) string {
-	relPath, _ := filepath.Rel(projectDir, htmlPath)
-	
+// fileExists reports whether path is an existing regular file
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// getChapterTitle derives a chapter title from the page file path
+func getChapterTitle(relPath string, index int) string {
 	if relPath == "index.html" {
 		return "Main Page"
 	}
 
 	// Use filename without extension
-	base := filepath.Base(htmlPath)
+	base := filepath.Base(relPath)
 	name := strings.TrimSuffix(base, filepath.Ext(base))
-	
+
 	return fmt.Sprintf("Chapter %d: %s", index+1, name)
 }
 
 // addChapterToPDF adds HTML content as PDF chapter
-func addChapterToPDF(pdf *gofpdf.Fpdf, htmlPath, title string) error {
-	// Read HTML
-	htmlBytes, err := os.ReadFile(htmlPath)
-	if err != nil {
-		return err
-	}
-
-	// Convert HTML to plain text
-	text := htmlToText(string(htmlBytes))
-
+func addChapterToPDF(pdf *gofpdf.Fpdf, renderer *htmlRenderer, chapter *pdfChapter) error {
 	// Add page with chapter title
 	pdf.AddPage()
-	
-	// Chapter heading
-	pdf.SetFont("Arial", "B", 16)
-	pdf.CellFormat(0, 10, title, "", 1, "L", false, 0, "")
-	pdf.Ln(5)
-
-	// Content
-	pdf.SetFont("Arial", "", 11)
-	
-	// Split text into lines and add to PDF
-	lines := strings.Split(text, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			pdf.Ln(4)
-			continue
-		}
-
-		// MultiCell for text wrapping
-		pdf.MultiCell(0, 5, line, "", "L", false)
-	}
+	chapter.page = pdf.PageNo()
+	pdf.SetLink(chapter.link, 0, chapter.page)
 
-	return nil
-}
-
-// htmlToText strips HTML tags and returns plain text
-func htmlToText(html string) string {
-	// Remove script and style tags with content
-	reScript := regexp.MustCompile(`(?i)<script[^>]*>.*?</script>`)
-	html = reScript.ReplaceAllString(html, "")
-
-	reStyle := regexp.MustCompile(`(?i)<style[^>]*>.*?</style>`)
-	html = reStyle.ReplaceAllString(html, "")
-
-	// Remove HTML comments
-	reComment := regexp.MustCompile(`<!--.*?-->`)
-	html = reComment.ReplaceAllString(html, "")
-
-	// Remove all HTML tags
-	reTag := regexp.MustCompile(`<[^>]*>`)
-	text := reTag.ReplaceAllString(html, "")
-
-	// Decode HTML entities (basic)
-	text = strings.ReplaceAll(text, "&nbsp;", " ")
-	text = strings.ReplaceAll(text, "&lt;", "<")
-	text = strings.ReplaceAll(text, "&gt;", ">")
-	text = strings.ReplaceAll(text, "&amp;", "&")
-	text = strings.ReplaceAll(text, "&quot;", "\"")
-	text = strings.ReplaceAll(text, "&#39;", "'")
-
-	// Clean up whitespace
-	text = strings.TrimSpace(text)
-	
-	// Normalize multiple spaces
-	reSpaces := regexp.MustCompile(`\s+`)
-	text = reSpaces.ReplaceAllString(text, " ")
-
-	// Normalize newlines
-	reNewlines := regexp.MustCompile(`\n{3,}`)
-	text = reNewlines.ReplaceAllString(text, "\n\n")
-
-	return text
-}
-
-// StreamPDFToWriter generates and streams PDF directly to writer
-func StreamPDFToWriter(w io.Writer, projectID, dataDir string) error {
-	// Generate PDF to temp file first (gofpdf limitation)
-	pdfPath, err := CreateConsolidatedPDF(projectID, dataDir)
-	if err != nil {
-		return err
-	}
-	defer os.Remove(pdfPath) // Cleanup temp file
+	// Outline entry; titles are encoded as UTF-16 only while a UTF-8 font is set
+	heading := textStyle{Bold: true, Size: renderer.scaled(16)}
+	renderer.fonts.SetFont(heading)
+	pdf.Bookmark(chapter.Title, chapter.Level, -1)
 
-	// Stream to writer
-	file, err := os.Open(pdfPath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
+	// Chapter heading
+	renderer.fonts.Write(10, heading, chapter.Title)
+	pdf.Ln(15)
 
-	_, err = io.Copy(w, file)
-	return err
+	// Content with headings, lists, tables, code, links and images
+	return renderer.RenderFile(chapter.Source)
 }
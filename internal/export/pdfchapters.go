@@ -0,0 +1,320 @@
+package export
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/user/scrapper/internal/models"
+	"github.com/user/scrapper/internal/scraper"
+)
+
+// Table of contents layout (mm)
+const (
+	tocTitleHeight = 20.0
+	tocLineHeight  = 6.0
+	tocLevelIndent = 5.0
+	tocPageColumn  = 15.0
+)
+
+// pdfChapter is a saved page rendered as one PDF chapter
+type pdfChapter struct {
+	URL      string
+	Path     string // Raw page file (absolute); internal links point here
+	Source   string // File rendered: extracted content if present, else the raw page
+	Title    string
+	Level    int       // Outline level
+	Date     time.Time // When the page was captured
+	Language string    // Page language from its metadata
+	link     int       // gofpdf internal link to the chapter start
+	page     int
+}
+
+// ValidateChapterOrder checks a chapter order option
+func ValidateChapterOrder(order string) error {
+	switch order {
+	case "", models.ChapterOrderCrawl, models.ChapterOrderPath:
+		return nil
+	}
+	return fmt.Errorf("chapter order must be %s or %s", models.ChapterOrderCrawl, models.ChapterOrderPath)
+}
+
+// loadChapters lists chapters from the manifest in the requested order,
+// keeping pages that pass the include/exclude patterns. Projects without a
+// manifest fall back to the files on disk.
+func loadChapters(projectID, dataDir string, opts models.PDFOptions) ([]*pdfChapter, error) {
+	projectDir := filepath.Join(dataDir, projectID)
+
+	include, err := chapterFilter(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := scraper.LoadManifest(projectID, dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var pages []models.ManifestEntry
+	saved := 0
+	for _, entry := range entries {
+		if entry.Kind != models.ManifestKindPage || entry.LocalPath == "" || entry.Error != "" {
+			continue
+		}
+		if !fileExists(filepath.Join(projectDir, filepath.FromSlash(entry.LocalPath))) {
+			continue
+		}
+		saved++
+		if include(entry.URL) {
+			pages = append(pages, entry)
+		}
+	}
+
+	if saved == 0 {
+		return filesystemChapters(projectDir, include)
+	}
+
+	var ordered []orderedPage
+	if opts.ChapterOrder == models.ChapterOrderPath {
+		ordered = orderByPath(pages)
+	} else {
+		ordered = orderByCrawl(pages)
+	}
+
+	chapters := make([]*pdfChapter, 0, len(ordered))
+	for i, item := range ordered {
+		path := filepath.Join(projectDir, filepath.FromSlash(item.entry.LocalPath))
+		chapters = append(chapters, &pdfChapter{
+			URL:      item.entry.URL,
+			Path:     path,
+			Source:   chapterSource(path),
+			Title:    chapterTitle(item.entry, i),
+			Level:    item.level,
+			Date:     item.entry.FetchedAt,
+			Language: pageLanguage(item.entry),
+		})
+	}
+
+	return chapters, nil
+}
+
+// filesystemChapters builds flat chapters from HTML files on disk; patterns
+// match the path relative to the project
+func filesystemChapters(projectDir string, include func(string) bool) ([]*pdfChapter, error) {
+	htmlFiles, err := findHTMLFiles(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	chapters := make([]*pdfChapter, 0, len(htmlFiles))
+	for i, path := range htmlFiles {
+		relPath, _ := filepath.Rel(projectDir, path)
+		if !include(filepath.ToSlash(relPath)) {
+			continue
+		}
+		chapters = append(chapters, &pdfChapter{
+			Path:   path,
+			Source: chapterSource(path),
+			Title:  getChapterTitle(filepath.ToSlash(relPath), i),
+		})
+	}
+
+	return chapters, nil
+}
+
+// chapterSource prefers extracted main content over the raw page
+func chapterSource(path string) string {
+	if contentPath := strings.TrimSuffix(path, ".html") + scraper.ContentSuffix; fileExists(contentPath) {
+		return contentPath
+	}
+	return path
+}
+
+// pageLanguage returns the language recorded in page metadata
+func pageLanguage(entry models.ManifestEntry) string {
+	if entry.Metadata == nil {
+		return ""
+	}
+	return entry.Metadata.Language
+}
+
+// chapterTitle uses the page <title>, then its first h1, then the file name
+func chapterTitle(entry models.ManifestEntry, index int) string {
+	if entry.Metadata != nil {
+		if entry.Metadata.Title != "" {
+			return entry.Metadata.Title
+		}
+		for _, heading := range entry.Metadata.Headings {
+			if heading.Level == 1 {
+				return heading.Text
+			}
+		}
+	}
+	return getChapterTitle(entry.LocalPath, index)
+}
+
+// orderedPage is a manifest page with its outline level
+type orderedPage struct {
+	entry models.ManifestEntry
+	level int
+}
+
+// orderByCrawl walks the crawl tree depth-first: every page is followed by
+// the pages first discovered on it, in fetch order
+func orderByCrawl(pages []models.ManifestEntry) []orderedPage {
+	known := make(map[string]bool, len(pages))
+	for _, page := range pages {
+		known[page.URL] = true
+	}
+
+	children := make(map[string][]models.ManifestEntry)
+	var roots []models.ManifestEntry
+	for _, page := range pages {
+		if page.ParentURL == "" || page.ParentURL == page.URL || !known[page.ParentURL] {
+			roots = append(roots, page)
+			continue
+		}
+		children[page.ParentURL] = append(children[page.ParentURL], page)
+	}
+
+	ordered := make([]orderedPage, 0, len(pages))
+	visited := make(map[string]bool, len(pages))
+	var walk func(page models.ManifestEntry, level int)
+	walk = func(page models.ManifestEntry, level int) {
+		if visited[page.URL] {
+			return
+		}
+		visited[page.URL] = true
+		ordered = append(ordered, orderedPage{entry: page, level: level})
+		for _, child := range children[page.URL] {
+			walk(child, level+1)
+		}
+	}
+	for _, root := range roots {
+		walk(root, 0)
+	}
+
+	// Pages caught in parent cycles
+	for _, page := range pages {
+		walk(page, 0)
+	}
+
+	return ordered
+}
+
+// orderByPath sorts pages by URL path; the outline follows path depth
+func orderByPath(pages []models.ManifestEntry) []orderedPage {
+	sorted := append([]models.ManifestEntry(nil), pages...)
+	segments := func(pageURL string) []string {
+		parsed, err := url.Parse(pageURL)
+		if err != nil {
+			return []string{pageURL}
+		}
+		trimmed := strings.Trim(parsed.Path, "/")
+		if trimmed == "" {
+			return nil
+		}
+		return strings.Split(trimmed, "/")
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := segments(sorted[i].URL), segments(sorted[j].URL)
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return sorted[i].URL < sorted[j].URL
+	})
+
+	ordered := make([]orderedPage, 0, len(sorted))
+	previous := -1
+	for _, page := range sorted {
+		level := len(segments(page.URL)) - 1
+		if level < 0 {
+			level = 0
+		}
+		// Outline levels may only grow one step at a time
+		if level > previous+1 {
+			level = previous + 1
+		}
+		ordered = append(ordered, orderedPage{entry: page, level: level})
+		previous = level
+	}
+
+	return ordered
+}
+
+// tocPageCount is the number of pages reserved for the table of contents
+func tocPageCount(pdf *gofpdf.Fpdf, entries int) int {
+	_, pageHeight := pdf.GetPageSize()
+	_, top, _, bottom := pdf.GetMargins()
+	usable := pageHeight - top - bottom
+
+	perFirst := int((usable - tocTitleHeight) / tocLineHeight)
+	perPage := int(usable / tocLineHeight)
+	if entries <= perFirst {
+		return 1
+	}
+	return 1 + (entries-perFirst+perPage-1)/perPage
+}
+
+// writeTOC fills the reserved pages starting at firstPage with chapter titles
+// and page numbers; each line links to its chapter
+func writeTOC(pdf *gofpdf.Fpdf, fonts *fontSet, firstPage int, chapters []*pdfChapter) {
+	pageWidth, pageHeight := pdf.GetPageSize()
+	left, top, right, bottom := pdf.GetMargins()
+	auto, margin := pdf.GetAutoPageBreak()
+	lastPage := pdf.PageCount()
+	pdf.SetAutoPageBreak(false, margin)
+
+	// gofpdf only outputs pages up to the current one
+	defer func() {
+		pdf.SetPage(lastPage)
+		pdf.SetAutoPageBreak(auto, margin)
+	}()
+
+	page := firstPage
+	pdf.SetPage(page)
+	pdf.SetXY(left, top)
+	fonts.Write(10, textStyle{Bold: true, Size: 16}, "Table of Contents")
+	y := top + tocTitleHeight
+
+	st := textStyle{Size: 10}
+	for _, chapter := range chapters {
+		if y+tocLineHeight > pageHeight-bottom {
+			page++
+			pdf.SetPage(page)
+			y = top
+		}
+
+		indent := float64(chapter.Level) * tocLevelIndent
+		width := pageWidth - left - right - indent - tocPageColumn
+		fonts.SetFont(st)
+		title := fitText(pdf, fonts.Clean(chapter.Title, false), width)
+
+		pdf.SetXY(left+indent, y)
+		pdf.CellFormat(width, tocLineHeight, title, "", 0, "L", false, chapter.link, "")
+		pdf.CellFormat(tocPageColumn, tocLineHeight, fmt.Sprint(chapter.page), "", 0, "R", false, chapter.link, "")
+		y += tocLineHeight
+	}
+}
+
+// fitText shortens text with an ellipsis to fit width in the current font
+func fitText(pdf *gofpdf.Fpdf, text string, width float64) string {
+	if pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
@@ -0,0 +1,776 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"  // GIF decoder for image.Decode
+	_ "image/jpeg" // JPEG decoder for image.Decode
+	"image/png"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jung-kurt/gofpdf"
+	"golang.org/x/net/html"
+)
+
+// Layout constants (sizes in pt, distances in mm)
+const (
+	bodyFontSize  = 11.0
+	codeFontSize  = 9.0
+	ptToMM        = 25.4 / 72
+	lineSpacing   = 1.35
+	listIndent    = 6.0
+	quoteIndent   = 8.0
+	cellPadding   = 1.5
+	maxImageBytes = 20 << 20
+	maxImagePx    = 10000
+)
+
+// headingSizes are font sizes for h1-h6
+var headingSizes = [6]float64{20, 17, 15, 13, 12, 11}
+
+// skippedElements are never rendered
+var skippedElements = map[string]bool{
+	"head": true, "script": true, "style": true, "noscript": true, "template": true,
+	"iframe": true, "svg": true, "button": true, "input": true, "select": true,
+	"textarea": true, "object": true, "embed": true, "canvas": true,
+}
+
+// plainBlocks start on a new line without extra spacing
+var plainBlocks = map[string]bool{
+	"div": true, "section": true, "article": true, "main": true, "header": true,
+	"footer": true, "nav": true, "aside": true, "figure": true, "form": true,
+	"address": true, "details": true, "summary": true, "dl": true, "center": true,
+	"fieldset": true, "caption": true,
+}
+
+// listState tracks numbering of an open list
+type listState struct {
+	ordered bool
+	counter int
+}
+
+// pdfImage is an image registered with gofpdf
+type pdfImage struct {
+	name   string
+	width  int // Pixels
+	height int
+}
+
+// htmlRenderer lays out an HTML document on gofpdf pages
+type htmlRenderer struct {
+	pdf        *gofpdf.Fpdf
+	fonts      *fontSet
+	htmlDir    string // Directory of the rendered file, for relative image paths
+	projectDir string
+	baseLeft   float64
+	indent     float64
+	style      textStyle
+	color      [3]int
+	lists      []listState
+	images     map[string]*pdfImage // Keyed by file path; nil marks unusable files
+	pageLinks  map[string]int       // Chapter links keyed by page file path and URL
+	bodySize   float64              // Body text size; other sizes scale with it
+	noImages   bool                 // Render images as their alt text
+
+	// atLineStart is true until something is written on the current line;
+	// lastSpace suppresses repeated whitespace across text nodes;
+	// lineMax is the tallest line height used on the current line
+	atLineStart bool
+	lastSpace   bool
+	lineMax     float64
+}
+
+// newHTMLRenderer prepares a renderer for files of one project
+func newHTMLRenderer(pdf *gofpdf.Fpdf, fonts *fontSet, projectDir string) *htmlRenderer {
+	left, _, _, _ := pdf.GetMargins()
+	return &htmlRenderer{
+		pdf:         pdf,
+		fonts:       fonts,
+		projectDir:  projectDir,
+		baseLeft:    left,
+		style:       textStyle{Size: bodyFontSize},
+		bodySize:    bodyFontSize,
+		images:      make(map[string]*pdfImage),
+		pageLinks:   make(map[string]int),
+		atLineStart: true,
+		lastSpace:   true,
+	}
+}
+
+// RenderFile renders the body of an HTML file at the current position
+func (r *htmlRenderer) RenderFile(htmlPath string) error {
+	file, err := os.Open(htmlPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	doc, err := html.Parse(file)
+	if err != nil {
+		return err
+	}
+
+	r.htmlDir = filepath.Dir(htmlPath)
+	r.reset()
+
+	root := findElement(doc, "body")
+	if root == nil {
+		root = doc
+	}
+	r.renderChildren(root)
+	r.newLine()
+
+	return r.pdf.Error()
+}
+
+// reset restores default state between documents
+func (r *htmlRenderer) reset() {
+	r.style = textStyle{Size: r.bodySize}
+	r.color = [3]int{0, 0, 0}
+	r.lists = nil
+	r.setIndent(0)
+	r.pdf.SetTextColor(0, 0, 0)
+	r.fonts.SetFont(r.style)
+	r.atLineStart = r.pdf.GetX() <= r.baseLeft+0.01
+	r.lastSpace = true
+}
+
+// scaled converts a size designed for the default body size to the current one
+func (r *htmlRenderer) scaled(size float64) float64 {
+	return size * r.bodySize / bodyFontSize
+}
+
+// lineHeight is the line height of the current font size in mm
+func (r *htmlRenderer) lineHeight() float64 {
+	return r.style.Size * ptToMM * lineSpacing
+}
+
+// renderChildren renders all child nodes in order
+func (r *htmlRenderer) renderChildren(node *html.Node) {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		r.render(child)
+	}
+}
+
+// render dispatches on node type and element name
+func (r *htmlRenderer) render(node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		r.text(node.Data)
+		return
+	case html.ElementNode:
+	default:
+		r.renderChildren(node)
+		return
+	}
+
+	tag := node.Data
+	if skippedElements[tag] || hasAttr(node, "hidden") {
+		return
+	}
+
+	switch tag {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level := int(tag[1] - '0')
+		r.block(3, 2, func() {
+			r.withStyle(func(st *textStyle) {
+				st.Bold = true
+				st.Size = r.scaled(headingSizes[level-1])
+			}, func() { r.renderChildren(node) })
+		})
+	case "p":
+		r.block(0, 2, func() { r.renderChildren(node) })
+	case "br":
+		r.endLine()
+		r.atLineStart, r.lastSpace = true, true
+	case "hr":
+		r.newLine()
+		left, _, right, _ := r.pdf.GetMargins()
+		pageWidth, _ := r.pdf.GetPageSize()
+		y := r.pdf.GetY() + 2
+		r.pdf.SetDrawColor(180, 180, 180)
+		r.pdf.Line(left, y, pageWidth-right, y)
+		r.pdf.SetDrawColor(0, 0, 0)
+		r.pdf.Ln(4)
+	case "ul", "ol":
+		r.renderList(node, tag == "ol")
+	case "li":
+		r.renderListItem(node)
+	case "pre":
+		r.renderPre(node)
+	case "blockquote":
+		r.block(1, 2, func() {
+			r.setIndent(r.indent + quoteIndent)
+			r.withColor([3]int{85, 85, 85}, func() {
+				r.withStyle(func(st *textStyle) { st.Italic = true }, func() { r.renderChildren(node) })
+			})
+			r.newLine()
+			r.setIndent(r.indent - quoteIndent)
+		})
+	case "dt":
+		r.block(1, 0, func() {
+			r.withStyle(func(st *textStyle) { st.Bold = true }, func() { r.renderChildren(node) })
+		})
+	case "dd":
+		r.block(0, 1, func() {
+			r.setIndent(r.indent + listIndent)
+			r.renderChildren(node)
+			r.newLine()
+			r.setIndent(r.indent - listIndent)
+		})
+	case "figcaption":
+		r.block(0, 2, func() {
+			r.withStyle(func(st *textStyle) {
+				st.Italic = true
+				st.Size--
+			}, func() { r.renderChildren(node) })
+		})
+	case "table":
+		r.renderTable(node)
+	case "img":
+		r.renderImage(node)
+	case "a":
+		r.renderLink(node)
+	case "code", "kbd", "samp", "tt":
+		r.withStyle(func(st *textStyle) { st.Mono = true }, func() { r.renderChildren(node) })
+	case "strong", "b":
+		r.withStyle(func(st *textStyle) { st.Bold = true }, func() { r.renderChildren(node) })
+	case "em", "i", "cite", "var", "dfn":
+		r.withStyle(func(st *textStyle) { st.Italic = true }, func() { r.renderChildren(node) })
+	case "u", "ins":
+		r.withStyle(func(st *textStyle) { st.Underline = true }, func() { r.renderChildren(node) })
+	case "small":
+		r.withStyle(func(st *textStyle) { st.Size = st.Size * 0.85 }, func() { r.renderChildren(node) })
+	default:
+		if plainBlocks[tag] {
+			r.block(0, 0, func() { r.renderChildren(node) })
+			return
+		}
+		r.renderChildren(node)
+	}
+}
+
+// text writes an inline text node with collapsed whitespace
+func (r *htmlRenderer) text(data string) {
+	var b strings.Builder
+	space := r.lastSpace
+	for _, c := range data {
+		if unicode.IsSpace(c) {
+			if !space {
+				b.WriteByte(' ')
+				space = true
+			}
+			continue
+		}
+		b.WriteRune(c)
+		space = false
+	}
+
+	text := b.String()
+	if text == "" || (text == " " && r.atLineStart) {
+		return
+	}
+
+	r.fonts.Write(r.lineHeight(), r.style, text)
+	r.atLineStart = false
+	r.lastSpace = space
+	if r.lineHeight() > r.lineMax {
+		r.lineMax = r.lineHeight()
+	}
+}
+
+// endLine moves below the current line
+func (r *htmlRenderer) endLine() {
+	h := r.lineMax
+	if h == 0 {
+		h = r.lineHeight()
+	}
+	r.pdf.Ln(h)
+	r.lineMax = 0
+}
+
+// newLine ends the current line if anything was written on it
+func (r *htmlRenderer) newLine() {
+	if !r.atLineStart {
+		r.endLine()
+	}
+	r.pdf.SetX(r.baseLeft + r.indent)
+	r.atLineStart, r.lastSpace = true, true
+}
+
+// block renders content on its own lines with spacing before and after (mm)
+func (r *htmlRenderer) block(before, after float64, content func()) {
+	r.newLine()
+	if before > 0 && r.pdf.GetY() > r.topMargin()+0.01 {
+		r.pdf.Ln(before)
+		r.pdf.SetX(r.baseLeft + r.indent)
+	}
+	content()
+	r.newLine()
+	if after > 0 {
+		r.pdf.Ln(after)
+		r.pdf.SetX(r.baseLeft + r.indent)
+	}
+}
+
+// topMargin is the top page margin
+func (r *htmlRenderer) topMargin() float64 {
+	_, top, _, _ := r.pdf.GetMargins()
+	return top
+}
+
+// setIndent moves the left margin used for wrapping
+func (r *htmlRenderer) setIndent(indent float64) {
+	r.indent = indent
+	r.pdf.SetLeftMargin(r.baseLeft + indent)
+	if r.atLineStart {
+		r.pdf.SetX(r.baseLeft + indent)
+	}
+}
+
+// withStyle renders content with a modified text style
+func (r *htmlRenderer) withStyle(change func(*textStyle), content func()) {
+	saved := r.style
+	change(&r.style)
+	r.fonts.SetFont(r.style)
+	content()
+	r.style = saved
+	r.fonts.SetFont(r.style)
+}
+
+// withColor renders content in a text color
+func (r *htmlRenderer) withColor(color [3]int, content func()) {
+	saved := r.color
+	r.color = color
+	r.pdf.SetTextColor(color[0], color[1], color[2])
+	content()
+	r.color = saved
+	r.pdf.SetTextColor(saved[0], saved[1], saved[2])
+}
+
+// renderList renders ul/ol items with an increased indent
+func (r *htmlRenderer) renderList(node *html.Node, ordered bool) {
+	start := 1
+	if value, err := strconv.Atoi(getAttr(node, "start")); err == nil {
+		start = value
+	}
+
+	r.newLine()
+	r.lists = append(r.lists, listState{ordered: ordered, counter: start - 1})
+	r.setIndent(r.indent + listIndent)
+	r.renderChildren(node)
+	r.newLine()
+	r.setIndent(r.indent - listIndent)
+	r.lists = r.lists[:len(r.lists)-1]
+
+	if len(r.lists) == 0 {
+		r.pdf.Ln(2)
+		r.pdf.SetX(r.baseLeft + r.indent)
+	}
+}
+
+// renderListItem writes the bullet or number left of the item text
+func (r *htmlRenderer) renderListItem(node *html.Node) {
+	r.newLine()
+
+	marker := "•"
+	if n := len(r.lists); n > 0 {
+		list := &r.lists[n-1]
+		list.counter++
+		if list.ordered {
+			marker = fmt.Sprintf("%d.", list.counter)
+		}
+	}
+
+	r.pdf.SetX(r.baseLeft + r.indent - listIndent + 1)
+	r.fonts.Write(r.lineHeight(), r.style, marker)
+	r.pdf.SetX(r.baseLeft + r.indent)
+	r.atLineStart, r.lastSpace = true, true
+
+	r.renderChildren(node)
+	r.newLine()
+}
+
+// renderPre writes preformatted text line by line in monospace on a shaded background
+func (r *htmlRenderer) renderPre(node *html.Node) {
+	text := strings.ReplaceAll(textContent(node), "\t", "    ")
+	text = strings.TrimPrefix(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	text = strings.TrimRight(text, "\n ")
+
+	r.block(1, 2, func() {
+		st := textStyle{Mono: true, Size: r.scaled(codeFontSize)}
+		r.fonts.SetFont(st)
+		r.pdf.SetFillColor(245, 245, 245)
+
+		h := st.Size * ptToMM * lineSpacing
+		for _, line := range strings.Split(r.fonts.Clean(text, true), "\n") {
+			if line == "" {
+				line = " "
+			}
+			r.pdf.SetX(r.baseLeft + r.indent)
+			r.pdf.MultiCell(0, h, line, "", "L", true)
+		}
+
+		r.pdf.SetFillColor(255, 255, 255)
+		r.fonts.SetFont(r.style)
+		r.atLineStart, r.lastSpace = true, true
+	})
+}
+
+// renderLink writes link text; links to exported pages jump within the
+// document and absolute URLs open externally
+func (r *htmlRenderer) renderLink(node *html.Node) {
+	href := strings.TrimSpace(getAttr(node, "href"))
+
+	if link := r.internalLink(href); link != 0 {
+		r.withColor([3]int{0, 0, 200}, func() {
+			r.withStyle(func(st *textStyle) {
+				st.Underline = true
+				st.LinkID = link
+			}, func() { r.renderChildren(node) })
+		})
+		return
+	}
+
+	target := ""
+	if parsed, err := url.Parse(href); err == nil {
+		switch parsed.Scheme {
+		case "http", "https", "mailto":
+			target = href
+		}
+	}
+
+	if target == "" {
+		r.renderChildren(node)
+		return
+	}
+
+	r.withColor([3]int{0, 0, 200}, func() {
+		r.withStyle(func(st *textStyle) {
+			st.Underline = true
+			st.Link = target
+		}, func() { r.renderChildren(node) })
+	})
+}
+
+// internalLink returns the chapter link for an href pointing at a saved
+// page, by local path or original URL, or 0
+func (r *htmlRenderer) internalLink(href string) int {
+	if len(r.pageLinks) == 0 || href == "" {
+		return 0
+	}
+	if i := strings.IndexByte(href, '#'); i >= 0 {
+		href = href[:i]
+	}
+	if link := r.pageLinks[href]; link != 0 {
+		return link
+	}
+	if path := r.resolveLocal(href); path != "" {
+		return r.pageLinks[path]
+	}
+	return 0
+}
+
+// renderImage places a downloaded image scaled to the text width; missing,
+// unsupported or excluded images fall back to their alt text
+func (r *htmlRenderer) renderImage(node *html.Node) {
+	var img *pdfImage
+	if !r.noImages {
+		img = r.loadImage(getAttr(node, "src"))
+	}
+	if img == nil {
+		if alt := strings.TrimSpace(getAttr(node, "alt")); alt != "" {
+			r.withStyle(func(st *textStyle) { st.Italic = true }, func() { r.text("[" + alt + "]") })
+		}
+		return
+	}
+
+	pageWidth, pageHeight := r.pdf.GetPageSize()
+	left, top, right, bottom := r.pdf.GetMargins()
+	maxWidth := pageWidth - left - right
+	maxHeight := pageHeight - top - bottom
+
+	// Natural size at 96 dpi, shrunk to fit the text box
+	width := float64(img.width) * 25.4 / 96
+	height := float64(img.height) * 25.4 / 96
+	if width > maxWidth {
+		height, width = height*maxWidth/width, maxWidth
+	}
+	if height > maxHeight {
+		width, height = width*maxHeight/height, maxHeight
+	}
+
+	r.newLine()
+	if r.pdf.GetY()+height > pageHeight-bottom {
+		r.pdf.AddPage()
+	}
+
+	x, y := r.pdf.GetX(), r.pdf.GetY()
+	r.pdf.ImageOptions(img.name, x, y, width, height, false, gofpdf.ImageOptions{}, 0, "")
+	r.pdf.SetY(y + height + 2)
+	r.pdf.SetX(r.baseLeft + r.indent)
+	r.atLineStart, r.lastSpace = true, true
+}
+
+// loadImage registers a project image file with gofpdf, converting PNG and
+// GIF to plain 8-bit PNG since gofpdf rejects interlaced and 16-bit files
+func (r *htmlRenderer) loadImage(src string) *pdfImage {
+	path := r.resolveLocal(src)
+	if path == "" {
+		return nil
+	}
+	if img, seen := r.images[path]; seen {
+		return img
+	}
+	r.images[path] = nil
+
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > maxImageBytes {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || config.Width == 0 || config.Height == 0 || config.Width > maxImagePx || config.Height > maxImagePx {
+		return nil
+	}
+
+	options := gofpdf.ImageOptions{ImageType: "JPG"}
+	if format != "jpeg" {
+		decoded, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil
+		}
+		rgba := image.NewNRGBA(decoded.Bounds())
+		draw.Draw(rgba, rgba.Bounds(), decoded, decoded.Bounds().Min, draw.Src)
+
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, rgba); err != nil {
+			return nil
+		}
+		data = buf.Bytes()
+		options.ImageType = "PNG"
+	}
+
+	name := fmt.Sprintf("img%d", len(r.images))
+	r.pdf.RegisterImageOptionsReader(name, options, bytes.NewReader(data))
+	if r.pdf.Error() != nil {
+		// Image errors are sticky; drop the image instead of failing the document
+		r.pdf.ClearError()
+		return nil
+	}
+
+	img := &pdfImage{name: name, width: config.Width, height: config.Height}
+	r.images[path] = img
+	return img
+}
+
+// resolveLocal maps an image src to a file inside the project directory
+func (r *htmlRenderer) resolveLocal(src string) string {
+	return resolveProjectFile(r.projectDir, r.htmlDir, src)
+}
+
+// resolveProjectFile maps a relative URL found in a saved file in htmlDir to
+// an existing file inside the project directory, or ""
+func resolveProjectFile(projectDir, htmlDir, src string) string {
+	parsed, err := url.Parse(strings.TrimSpace(src))
+	if err != nil || parsed.Scheme != "" || parsed.Host != "" || parsed.Path == "" {
+		return ""
+	}
+
+	// Saved pages use paths relative to the page, the project root, or both
+	for _, base := range []string{htmlDir, projectDir} {
+		path := filepath.Join(base, filepath.FromSlash(parsed.Path))
+		rel, err := filepath.Rel(projectDir, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if fileExists(path) {
+			return path
+		}
+	}
+
+	return ""
+}
+
+// tableCell is a table cell prepared for layout
+type tableCell struct {
+	text   string
+	header bool
+	span   int
+}
+
+// renderTable draws a bordered grid with equal column widths; each row
+// grows to fit its tallest cell and moves to the next page when needed
+func (r *htmlRenderer) renderTable(table *html.Node) {
+	var rows [][]tableCell
+	columns := 0
+	for _, tr := range tableRows(table) {
+		var row []tableCell
+		width := 0
+		for cell := tr.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.Type != html.ElementNode || (cell.Data != "td" && cell.Data != "th") {
+				continue
+			}
+			span, err := strconv.Atoi(getAttr(cell, "colspan"))
+			if err != nil || span < 1 {
+				span = 1
+			}
+			row = append(row, tableCell{
+				text:   strings.Join(strings.Fields(textContent(cell)), " "),
+				header: cell.Data == "th",
+				span:   span,
+			})
+			width += span
+		}
+		if width > columns {
+			columns = width
+		}
+		rows = append(rows, row)
+	}
+	if columns == 0 {
+		return
+	}
+
+	r.block(1, 3, func() {
+		pageWidth, pageHeight := r.pdf.GetPageSize()
+		_, top, right, bottom := r.pdf.GetMargins()
+		left := r.baseLeft + r.indent
+		columnWidth := (pageWidth - left - right) / float64(columns)
+
+		size := r.style.Size - 1
+		lineHeight := size * ptToMM * lineSpacing
+		maxLines := int((pageHeight - top - bottom - 2*cellPadding) / lineHeight)
+
+		r.pdf.SetFillColor(235, 235, 235)
+		r.pdf.SetDrawColor(160, 160, 160)
+
+		for _, row := range rows {
+			// Wrap every cell to measure the row
+			lines := make([][]string, len(row))
+			rowLines := 1
+			for i, cell := range row {
+				r.fonts.SetFont(textStyle{Bold: cell.header, Size: size})
+				width := columnWidth*float64(cell.span) - 2*cellPadding
+				lines[i] = r.pdf.SplitText(r.fonts.Clean(cell.text, false), width)
+				if len(lines[i]) > maxLines {
+					lines[i] = lines[i][:maxLines]
+				}
+				if len(lines[i]) > rowLines {
+					rowLines = len(lines[i])
+				}
+			}
+			rowHeight := float64(rowLines)*lineHeight + 2*cellPadding
+
+			y := r.pdf.GetY()
+			if y+rowHeight > pageHeight-bottom {
+				r.pdf.AddPage()
+				y = r.pdf.GetY()
+			}
+
+			x := left
+			for i, cell := range row {
+				width := columnWidth * float64(cell.span)
+				style := "D"
+				if cell.header {
+					style = "FD"
+				}
+				r.pdf.Rect(x, y, width, rowHeight, style)
+
+				r.fonts.SetFont(textStyle{Bold: cell.header, Size: size})
+				for j, line := range lines[i] {
+					r.pdf.SetXY(x+cellPadding, y+cellPadding+float64(j)*lineHeight)
+					r.pdf.CellFormat(width-2*cellPadding, lineHeight, line, "", 0, "L", false, 0, "")
+				}
+				x += width
+			}
+
+			r.pdf.SetXY(left, y+rowHeight)
+		}
+
+		r.pdf.SetFillColor(255, 255, 255)
+		r.pdf.SetDrawColor(0, 0, 0)
+		r.fonts.SetFont(r.style)
+		r.atLineStart, r.lastSpace = true, true
+	})
+}
+
+// tableRows returns the rows of a table, excluding rows of nested tables
+func tableRows(table *html.Node) []*html.Node {
+	var rows []*html.Node
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.Data {
+			case "tr":
+				rows = append(rows, child)
+			case "thead", "tbody", "tfoot":
+				walk(child)
+			}
+		}
+	}
+	walk(table)
+	return rows
+}
+
+// findElement returns the first element with the given tag in document order
+func findElement(node *html.Node, tag string) *html.Node {
+	if node.Type == html.ElementNode && node.Data == tag {
+		return node
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if found := findElement(child, tag); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// textContent concatenates all text below node
+func textContent(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+	var b strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.Data == "br" {
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString(textContent(child))
+	}
+	return b.String()
+}
+
+// hasAttr reports whether node has an attribute, even an empty one
+func hasAttr(node *html.Node, key string) bool {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// getAttr returns an attribute value or ""
+func getAttr(node *html.Node, key string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,255 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/user/scrapper/internal/models"
+)
+
+// PDFPresetsFileName stores named PDF options in the project directory
+const PDFPresetsFileName = "pdf_presets.json"
+
+// Option limits
+const (
+	defaultMargin   = 20.0
+	maxMargin       = 100.0
+	minFontSize     = 6.0
+	maxFontSize     = 24.0
+	maxPresetName   = 64
+	maxTemplateText = 200
+	minTextArea     = 50.0 // Smallest width and height left inside the margins
+)
+
+// pdfPageSize is a portrait page size in mm
+type pdfPageSize struct {
+	name          string // gofpdf size name
+	width, height float64
+}
+
+// pdfPageSizes maps accepted page_size values (case-insensitive) to sizes
+var pdfPageSizes = map[string]pdfPageSize{
+	"a3":     {"A3", 297, 420},
+	"a4":     {"A4", 210, 297},
+	"a5":     {"A5", 148, 210},
+	"letter": {"Letter", 215.9, 279.4},
+	"legal":  {"Legal", 215.9, 355.6},
+}
+
+// presetName allows file-system and URL safe preset names
+var presetName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// presetsMu serializes read-modify-write of preset files
+var presetsMu sync.Mutex
+
+// ErrNoChapters is returned when no saved page is left to export
+var ErrNoChapters = errors.New("no pages to export")
+
+// ErrPresetNotFound is returned for unknown preset names
+var ErrPresetNotFound = errors.New("preset not found")
+
+// ValidatePDFOptions checks PDF export options from a request or preset
+func ValidatePDFOptions(opts *models.PDFOptions) error {
+	if err := ValidateChapterOrder(opts.ChapterOrder); err != nil {
+		return err
+	}
+
+	if opts.PageSize != "" {
+		if _, ok := pdfPageSizes[strings.ToLower(opts.PageSize)]; !ok {
+			return fmt.Errorf("page_size must be one of A3, A4, A5, Letter, Legal")
+		}
+	}
+
+	switch opts.Orientation {
+	case "", "portrait", "landscape":
+	default:
+		return fmt.Errorf("orientation must be portrait or landscape")
+	}
+
+	if m := opts.Margins; m != nil {
+		for _, v := range []float64{m.Top, m.Right, m.Bottom, m.Left} {
+			if v < 0 || v > maxMargin {
+				return fmt.Errorf("margins must be between 0 and %.0f mm", maxMargin)
+			}
+		}
+	}
+
+	orientation, size, margins := pageLayout(*opts)
+	width, height := size.width, size.height
+	if orientation == "L" {
+		width, height = height, width
+	}
+	if width-margins.Left-margins.Right < minTextArea || height-margins.Top-margins.Bottom < minTextArea {
+		return fmt.Errorf("margins leave less than %.0f mm for text", minTextArea)
+	}
+
+	if opts.FontSize != 0 && (opts.FontSize < minFontSize || opts.FontSize > maxFontSize) {
+		return fmt.Errorf("font_size must be between %.0f and %.0f", minFontSize, maxFontSize)
+	}
+
+	if _, err := compilePatterns(opts.IncludePatterns); err != nil {
+		return fmt.Errorf("include_patterns: %w", err)
+	}
+	if _, err := compilePatterns(opts.ExcludePatterns); err != nil {
+		return fmt.Errorf("exclude_patterns: %w", err)
+	}
+
+	for name, tpl := range map[string]*models.PDFPageTemplate{"header": opts.Header, "footer": opts.Footer} {
+		if tpl != nil && len(tpl.Left)+len(tpl.Center)+len(tpl.Right) > maxTemplateText {
+			return fmt.Errorf("%s text is longer than %d characters", name, maxTemplateText)
+		}
+	}
+
+	return nil
+}
+
+// compilePatterns compiles URL regular expressions
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for i, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %d: %v", i, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// chapterFilter reports whether a page URL passes include and exclude patterns
+func chapterFilter(opts models.PDFOptions) (func(pageURL string) bool, error) {
+	include, err := compilePatterns(opts.IncludePatterns)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := compilePatterns(opts.ExcludePatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(pageURL string) bool {
+		if len(include) > 0 && !matchesAny(include, pageURL) {
+			return false
+		}
+		return !matchesAny(exclude, pageURL)
+	}, nil
+}
+
+// matchesAny reports whether s matches one of the patterns
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// pageLayout returns gofpdf orientation, page size and margins for options
+func pageLayout(opts models.PDFOptions) (string, pdfPageSize, models.PDFMargins) {
+	orientation := "P"
+	if opts.Orientation == "landscape" {
+		orientation = "L"
+	}
+
+	size := pdfPageSizes["a4"]
+	if s, ok := pdfPageSizes[strings.ToLower(opts.PageSize)]; ok {
+		size = s
+	}
+
+	margins := models.PDFMargins{Top: defaultMargin, Right: defaultMargin, Bottom: defaultMargin, Left: defaultMargin}
+	if opts.Margins != nil {
+		margins = *opts.Margins
+	}
+
+	return orientation, size, margins
+}
+
+// ValidatePresetName checks a preset name taken from the URL
+func ValidatePresetName(name string) error {
+	if len(name) > maxPresetName || !presetName.MatchString(name) {
+		return fmt.Errorf("preset name must be 1-%d letters, digits, '.', '_' or '-'", maxPresetName)
+	}
+	return nil
+}
+
+// LoadPDFPresets reads the saved presets of a project; none saved yields an empty map
+func LoadPDFPresets(projectID, dataDir string) (map[string]models.PDFOptions, error) {
+	data, err := os.ReadFile(filepath.Join(dataDir, projectID, PDFPresetsFileName))
+	if os.IsNotExist(err) {
+		return map[string]models.PDFOptions{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	presets := make(map[string]models.PDFOptions)
+	if err := json.Unmarshal(data, &presets); err != nil {
+		return nil, err
+	}
+	return presets, nil
+}
+
+// LoadPDFPreset returns one saved preset
+func LoadPDFPreset(projectID, dataDir, name string) (models.PDFOptions, error) {
+	presets, err := LoadPDFPresets(projectID, dataDir)
+	if err != nil {
+		return models.PDFOptions{}, err
+	}
+	opts, ok := presets[name]
+	if !ok {
+		return models.PDFOptions{}, ErrPresetNotFound
+	}
+	return opts, nil
+}
+
+// SavePDFPreset creates or replaces a named preset
+func SavePDFPreset(projectID, dataDir, name string, opts models.PDFOptions) error {
+	return updatePDFPresets(projectID, dataDir, func(presets map[string]models.PDFOptions) error {
+		presets[name] = opts
+		return nil
+	})
+}
+
+// DeletePDFPreset removes a named preset
+func DeletePDFPreset(projectID, dataDir, name string) error {
+	return updatePDFPresets(projectID, dataDir, func(presets map[string]models.PDFOptions) error {
+		if _, ok := presets[name]; !ok {
+			return ErrPresetNotFound
+		}
+		delete(presets, name)
+		return nil
+	})
+}
+
+// updatePDFPresets applies fn to the saved presets and writes them back atomically
+func updatePDFPresets(projectID, dataDir string, fn func(map[string]models.PDFOptions) error) error {
+	presetsMu.Lock()
+	defer presetsMu.Unlock()
+
+	presets, err := LoadPDFPresets(projectID, dataDir)
+	if err != nil {
+		return err
+	}
+	if err := fn(presets); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(presets, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dataDir, projectID, PDFPresetsFileName)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
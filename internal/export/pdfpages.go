@@ -0,0 +1,178 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/user/scrapper/internal/models"
+	"github.com/user/scrapper/internal/scraper"
+)
+
+// Cover and header/footer layout (sizes in pt, distances in mm)
+const (
+	coverLogoWidth    = 60.0
+	coverLogoHeight   = 40.0
+	coverTitleSize    = 26.0
+	coverSubtitleSize = 14.0
+	pageTemplateSize  = 8.0
+	dateLayout        = "2006-01-02"
+)
+
+// addCoverToPDF adds a title page with an optional logo, the title and
+// subtitle, the project URL and capture date
+func addCoverToPDF(pdf *gofpdf.Fpdf, renderer *htmlRenderer, cover *models.PDFCover, project *models.Project, dataDir, defaultTitle string) {
+	pdf.AddPage()
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	left, top, right, _ := pdf.GetMargins()
+	width := pageWidth - left - right
+	y := top + (pageHeight-top)/6
+
+	if img := coverLogo(renderer, project.ID, dataDir, cover.Logo); img != nil {
+		w := float64(img.width) * 25.4 / 96
+		h := float64(img.height) * 25.4 / 96
+		if w > coverLogoWidth {
+			h, w = h*coverLogoWidth/w, coverLogoWidth
+		}
+		if h > coverLogoHeight {
+			w, h = w*coverLogoHeight/h, coverLogoHeight
+		}
+		pdf.ImageOptions(img.name, left+(width-w)/2, y, w, h, false, gofpdf.ImageOptions{}, 0, "")
+		y += h + 15
+	}
+
+	title := cover.Title
+	if title == "" {
+		title = defaultTitle
+	}
+
+	centered := func(st textStyle, text string, after float64) {
+		if text == "" {
+			return
+		}
+		renderer.fonts.SetFont(st)
+		pdf.SetXY(left, y)
+		pdf.MultiCell(width, st.Size*ptToMM*lineSpacing, renderer.fonts.Clean(text, false), "", "C", false)
+		y = pdf.GetY() + after
+	}
+
+	centered(textStyle{Bold: true, Size: coverTitleSize}, title, 6)
+	centered(textStyle{Size: coverSubtitleSize}, cover.Subtitle, 15)
+
+	pdf.SetTextColor(100, 100, 100)
+	centered(textStyle{Size: bodyFontSize}, project.URL, 2)
+	centered(textStyle{Size: bodyFontSize}, project.CreatedAt.Format(dateLayout), 0)
+	pdf.SetTextColor(0, 0, 0)
+}
+
+// coverLogo loads the cover logo, given as a project path or the URL of a
+// downloaded image
+func coverLogo(renderer *htmlRenderer, projectID, dataDir, logo string) *pdfImage {
+	if logo == "" {
+		return nil
+	}
+
+	src := logo
+	if strings.Contains(logo, "://") {
+		src = ""
+		entries, _ := scraper.LoadManifest(projectID, dataDir)
+		for _, entry := range entries {
+			if entry.Kind == models.ManifestKindAsset && entry.URL == logo && entry.LocalPath != "" {
+				src = entry.LocalPath
+				break
+			}
+		}
+		if src == "" {
+			return nil
+		}
+	}
+
+	renderer.htmlDir = renderer.projectDir
+	return renderer.loadImage(src)
+}
+
+// decoratePages draws the header and footer on every page from firstPage on,
+// filling placeholders from the chapter each page belongs to
+func decoratePages(pdf *gofpdf.Fpdf, fonts *fontSet, opts models.PDFOptions, firstPage int, chapters []*pdfChapter, captured time.Time) {
+	pageWidth, pageHeight := pdf.GetPageSize()
+	left, top, right, bottom := pdf.GetMargins()
+	auto, margin := pdf.GetAutoPageBreak()
+	lastPage := pdf.PageCount()
+	pdf.SetAutoPageBreak(false, margin)
+
+	// gofpdf only outputs pages up to the current one
+	defer func() {
+		pdf.SetPage(lastPage)
+		pdf.SetAutoPageBreak(auto, margin)
+	}()
+
+	st := textStyle{Size: pageTemplateSize}
+	h := pageTemplateSize * ptToMM * lineSpacing
+	pages := fmt.Sprint(lastPage)
+
+	next := 0
+	var chapter *pdfChapter
+	for page := firstPage; page <= lastPage; page++ {
+		for next < len(chapters) && chapters[next].page <= page {
+			chapter = chapters[next]
+			next++
+		}
+
+		pageURL, title, date := "", "Table of Contents", captured
+		if chapter != nil {
+			pageURL, title = chapter.URL, chapter.Title
+			if !chapter.Date.IsZero() {
+				date = chapter.Date
+			}
+		}
+		values := strings.NewReplacer(
+			"{url}", pageURL,
+			"{title}", title,
+			"{date}", date.Format(dateLayout),
+			"{page}", fmt.Sprint(page),
+			"{pages}", pages,
+		)
+
+		pdf.SetPage(page)
+		pdf.SetTextColor(100, 100, 100)
+		fonts.SetFont(st)
+		if opts.Header != nil {
+			writePageTemplate(pdf, fonts, opts.Header, values, left, (top-h)/2, pageWidth-left-right, h)
+		}
+		if opts.Footer != nil {
+			writePageTemplate(pdf, fonts, opts.Footer, values, left, pageHeight-(bottom+h)/2, pageWidth-left-right, h)
+		}
+		pdf.SetTextColor(0, 0, 0)
+	}
+}
+
+// writePageTemplate writes the left, center and right parts of a header or
+// footer line; each part is shortened to its share of the width
+func writePageTemplate(pdf *gofpdf.Fpdf, fonts *fontSet, tpl *models.PDFPageTemplate, values *strings.Replacer, x, y, width, h float64) {
+	parts := []struct{ text, align string }{
+		{tpl.Left, "L"}, {tpl.Center, "C"}, {tpl.Right, "R"},
+	}
+
+	used := 0
+	for _, part := range parts {
+		if part.text != "" {
+			used++
+		}
+	}
+	if used == 0 {
+		return
+	}
+	share := width / float64(used)
+
+	for _, part := range parts {
+		if part.text == "" {
+			continue
+		}
+		text := fitText(pdf, fonts.Clean(values.Replace(part.text), false), share)
+
+		pdf.SetXY(x, y)
+		pdf.CellFormat(width, h, text, "", 0, part.align, false, 0, "")
+	}
+}
@@ -0,0 +1,288 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/user/scrapper/internal/models"
+	"github.com/user/scrapper/internal/scraper"
+)
+
+// assetTypes are the asset types recorded in the manifest
+var assetTypes = []string{"css", "js", "img", "font", "other"}
+
+// Selection errors
+var (
+	ErrNoPagesSelected  = errors.New("no pages match the selection")
+	ErrArchiveTooLarge  = errors.New("selected files exceed the maximum archive size")
+	errUnknownAssetType = fmt.Errorf("asset types must be among %s", strings.Join(assetTypes, ", "))
+)
+
+// ValidateArchiveSelection checks archive selection options
+func ValidateArchiveSelection(sel *models.ArchiveSelection) error {
+	if _, err := compilePageMatchers(sel); err != nil {
+		return err
+	}
+	for _, types := range [][]string{sel.AssetTypes, sel.ExcludeAssetTypes} {
+		for _, assetType := range types {
+			if !isAssetType(assetType) {
+				return errUnknownAssetType
+			}
+		}
+	}
+	if sel.MaxSize < 0 {
+		return fmt.Errorf("max_size must not be negative")
+	}
+	return nil
+}
+
+// isAssetType reports whether t is a manifest asset type
+func isAssetType(t string) bool {
+	for _, known := range assetTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckArchiveSelection reports selections that match no page or exceed
+// their size limit, so handlers can refuse them before streaming
+func CheckArchiveSelection(projectID, dataDir string, sel *models.ArchiveSelection) error {
+	_, err := projectFiles(filepath.Join(dataDir, projectID), ArchiveOptions{Selection: sel})
+	return err
+}
+
+// pageMatchers are the compiled page filters of a selection
+type pageMatchers struct {
+	include, exclude []*regexp.Regexp
+}
+
+// compilePageMatchers compiles the URL globs and expressions of a selection
+func compilePageMatchers(sel *models.ArchiveSelection) (pageMatchers, error) {
+	var matchers pageMatchers
+	for _, glob := range sel.Include {
+		re, err := globPattern(glob)
+		if err != nil {
+			return matchers, fmt.Errorf("include: %v", err)
+		}
+		matchers.include = append(matchers.include, re)
+	}
+	for _, glob := range sel.Exclude {
+		re, err := globPattern(glob)
+		if err != nil {
+			return matchers, fmt.Errorf("exclude: %v", err)
+		}
+		matchers.exclude = append(matchers.exclude, re)
+	}
+
+	include, err := compilePatterns(sel.IncludePatterns)
+	if err != nil {
+		return matchers, fmt.Errorf("include_patterns: %w", err)
+	}
+	exclude, err := compilePatterns(sel.ExcludePatterns)
+	if err != nil {
+		return matchers, fmt.Errorf("exclude_patterns: %w", err)
+	}
+	matchers.include = append(matchers.include, include...)
+	matchers.exclude = append(matchers.exclude, exclude...)
+	return matchers, nil
+}
+
+// active reports whether the selection filters pages
+func (m pageMatchers) active() bool {
+	return len(m.include) > 0 || len(m.exclude) > 0
+}
+
+// matches reports whether a page URL is selected
+func (m pageMatchers) matches(pageURL string) bool {
+	if len(m.include) > 0 && !matchesAny(m.include, pageURL) {
+		return false
+	}
+	return !matchesAny(m.exclude, pageURL)
+}
+
+// internalMetadata are the project files describing the crawl itself
+var internalMetadata = map[string]bool{
+	"project.json":           true,
+	"filters.json":           true,
+	scraper.ManifestFileName: true,
+	PDFPresetsFileName:       true,
+}
+
+// archiveSelection decides which project files go into an archive. Files
+// the manifest records as pages, extracted content or assets are selected
+// individually; every other file is project metadata. Data derived from
+// every page, such as the WARC file or extracted tables, is left out when
+// pages are filtered.
+type archiveSelection struct {
+	selected map[string]bool // Archive names of selected pages and assets
+	managed  map[string]bool // Archive names of all pages and assets
+	metadata bool
+	subset   bool // Pages are filtered
+}
+
+// newArchiveSelection resolves a selection against the project manifest
+func newArchiveSelection(projectDir string, sel *models.ArchiveSelection) (*archiveSelection, error) {
+	matchers, err := compilePageMatchers(sel)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := scraper.LoadManifest(filepath.Base(projectDir), filepath.Dir(projectDir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest: %w", err)
+	}
+
+	selection := &archiveSelection{
+		selected: make(map[string]bool),
+		managed:  make(map[string]bool),
+		metadata: !sel.ExcludeMetadata,
+	}
+	assets := make(map[string]models.ManifestEntry) // Asset URL -> entry
+	byFile := make(map[string]string)               // Asset file -> URL
+	for _, entry := range entries {
+		for _, name := range []string{entry.LocalPath, entry.ContentPath} {
+			if name != "" {
+				selection.managed[name] = true
+			}
+		}
+		if entry.Kind == models.ManifestKindAsset && entry.LocalPath != "" {
+			assets[entry.URL] = entry
+			byFile[entry.LocalPath] = entry.URL
+		}
+	}
+
+	allowed := func(entry models.ManifestEntry) bool {
+		if len(sel.AssetTypes) > 0 && !containsString(sel.AssetTypes, entry.Type) {
+			return false
+		}
+		return !containsString(sel.ExcludeAssetTypes, entry.Type)
+	}
+
+	// Without page filters every asset of an allowed type is kept
+	pages := 0
+	for _, entry := range entries {
+		if entry.Kind != models.ManifestKindPage || entry.LocalPath == "" || !matchers.matches(entry.URL) {
+			continue
+		}
+		pages++
+		selection.selected[entry.LocalPath] = true
+		if entry.ContentPath != "" {
+			selection.selected[entry.ContentPath] = true
+		}
+
+		if !matchers.active() {
+			continue
+		}
+		for _, assetURL := range pageAssets(filepath.Join(projectDir, filepath.FromSlash(entry.LocalPath)), entry.URL, projectDir, byFile) {
+			if asset := assets[assetURL]; allowed(asset) {
+				selection.addAsset(projectDir, asset, assets, byFile, allowed)
+			}
+		}
+	}
+	if pages == 0 && matchers.active() {
+		return nil, ErrNoPagesSelected
+	}
+	selection.subset = matchers.active()
+
+	if !matchers.active() {
+		for _, asset := range assets {
+			if allowed(asset) {
+				selection.selected[asset.LocalPath] = true
+			}
+		}
+	}
+
+	return selection, nil
+}
+
+// addAsset selects an asset and, for stylesheets, the assets their url()
+// references point to
+func (s *archiveSelection) addAsset(projectDir string, asset models.ManifestEntry, assets map[string]models.ManifestEntry, byFile map[string]string, allowed func(models.ManifestEntry) bool) {
+	if s.selected[asset.LocalPath] {
+		return
+	}
+	s.selected[asset.LocalPath] = true
+	if asset.Type != "css" {
+		return
+	}
+
+	filePath := filepath.Join(projectDir, filepath.FromSlash(asset.LocalPath))
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return
+	}
+	base, _ := url.Parse(asset.URL)
+	for _, match := range cssURLPattern.FindAllStringSubmatch(string(data), -1) {
+		ref := strings.TrimSpace(match[1])
+		if ref == "" || strings.HasPrefix(ref, "data:") || strings.HasPrefix(ref, "#") {
+			continue
+		}
+
+		// Stylesheets keep their original references or point to saved files
+		var target models.ManifestEntry
+		var found bool
+		if file := resolveProjectFile(projectDir, filepath.Dir(filePath), ref); file != "" {
+			if rel, err := filepath.Rel(projectDir, file); err == nil {
+				target, found = assets[byFile[filepath.ToSlash(rel)]]
+			}
+		}
+		if !found && base != nil {
+			if parsed, err := base.Parse(ref); err == nil {
+				parsed.Fragment = ""
+				target, found = assets[parsed.String()]
+			}
+		}
+		if found && allowed(target) {
+			s.addAsset(projectDir, target, assets, byFile, allowed)
+		}
+	}
+}
+
+// includes reports whether a project file goes into the archive
+func (s *archiveSelection) includes(name string) bool {
+	if s.managed[name] {
+		return s.selected[name]
+	}
+	return s.metadata && (!s.subset || internalMetadata[name])
+}
+
+// containsString reports whether values holds s
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
+
+// globPattern compiles a URL glob: "*" matches within a path segment, "**"
+// across segments and "?" one character other than "/"
+func globPattern(glob string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
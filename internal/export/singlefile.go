@@ -0,0 +1,689 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/user/scrapper/internal/models"
+	"github.com/user/scrapper/internal/scraper"
+	"golang.org/x/net/html"
+)
+
+// ErrPageNotFound is returned when a requested page was not saved
+var ErrPageNotFound = errors.New("page not found")
+
+// maxCSSImportDepth limits nested @import rules inlined into a page
+const maxCSSImportDepth = 5
+
+// maxInlineSize is the largest file inlined into a page or included as an
+// MHTML part; larger files, such as videos, stay links to the site
+const maxInlineSize = 5 << 20
+
+// scraperName identifies the exporter in MHTML headers
+const scraperName = "Scrapper"
+
+var (
+	// cssImportPattern matches @import rules with their media list
+	cssImportPattern = regexp.MustCompile(`@import\s+(?:url\(\s*)?['"]?([^'")\s;]+)['"]?\s*\)?([^;]*);`)
+	// rawTextEnd matches end tags that would close inlined code early
+	rawTextEnd = map[string]*regexp.Regexp{
+		"script": regexp.MustCompile(`(?i)</(script)`),
+		"style":  regexp.MustCompile(`(?i)</(style)`),
+	}
+)
+
+// ValidateSingleFileFormat checks a single-file export format
+func ValidateSingleFileFormat(format string) error {
+	switch format {
+	case models.SingleFileHTML, models.SingleFileMHTML:
+		return nil
+	}
+	return fmt.Errorf("format must be %s or %s", models.SingleFileHTML, models.SingleFileMHTML)
+}
+
+// SingleFileName is the download name of a page exported as one file
+func SingleFileName(pageURL, format string) string {
+	return path.Base(sitePath(pageURL, "."+format))
+}
+
+// WriteSingleFile writes one saved page as a self-contained file: HTML with
+// its CSS, images, fonts and scripts inlined, or an MHTML archive. Links to
+// other pages point back to the site. It returns ErrPageNotFound unless
+// pageURL is a saved page.
+func WriteSingleFile(w io.Writer, projectID, dataDir, pageURL, format string) error {
+	chapters, err := loadChapters(projectID, dataDir, models.PDFOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, chapter := range chapters {
+		if chapter.URL != "" && sameURL(chapter.URL, pageURL) {
+			inliner, err := newInliner(projectID, dataDir, format)
+			if err != nil {
+				return err
+			}
+			return inliner.write(w, chapter)
+		}
+	}
+	return ErrPageNotFound
+}
+
+// sameURL compares page URLs ignoring a trailing slash
+func sameURL(a, b string) bool {
+	return strings.TrimSuffix(a, "/") == strings.TrimSuffix(b, "/")
+}
+
+// CreateSingleFileArchive writes a ZIP with every saved page as a
+// self-contained file to zipPath. Paths mirror the site; in HTML archives
+// links between pages point to their files. progress, if set, is called
+// after each page.
+func CreateSingleFileArchive(zipPath, projectID, dataDir, format string, progress ProgressFunc) error {
+	projectDir := filepath.Join(dataDir, projectID)
+
+	chapters, err := loadChapters(projectID, dataDir, models.PDFOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list pages: %w", err)
+	}
+	if len(chapters) == 0 {
+		return ErrNoChapters
+	}
+
+	inliner, err := newInliner(projectID, dataDir, format)
+	if err != nil {
+		return err
+	}
+
+	paths := make([]string, len(chapters))
+	used := make(map[string]bool)
+	for i, chapter := range chapters {
+		var filePath string
+		if chapter.URL != "" {
+			filePath = sitePath(chapter.URL, "."+format)
+		} else {
+			rel, _ := filepath.Rel(projectDir, chapter.Path)
+			filePath = strings.TrimSuffix(filepath.ToSlash(rel), filepath.Ext(rel)) + "." + format
+		}
+		paths[i] = uniqueArchivePath(filePath, used)
+		if format == models.SingleFileHTML {
+			inliner.archivePages[chapter.Path] = paths[i]
+		}
+	}
+
+	file, err := os.Create(zipPath)
+	if err != nil {
+		return fmt.Errorf("failed to create zip file: %w", err)
+	}
+	defer file.Close()
+
+	zipWriter := zip.NewWriter(file)
+
+	for i, chapter := range chapters {
+		var page bytes.Buffer
+		inliner.archivePath = paths[i]
+		if err := inliner.write(&page, chapter); err != nil {
+			return fmt.Errorf("failed to export %s: %w", chapter.Path, err)
+		}
+
+		if err := writeZipEntry(zipWriter, paths[i], page.Bytes(), chapter.Date); err != nil {
+			return err
+		}
+
+		if progress != nil {
+			progress(i+1, len(chapters))
+		}
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// inlinedFile is a project file a page refers to
+type inlinedFile struct {
+	path        string // Absolute file path
+	url         string // Original URL, if known
+	contentType string
+}
+
+// inliner makes saved pages self-contained using the URL to file mapping of
+// the manifest
+type inliner struct {
+	projectDir string
+	mhtml      bool
+	byURL      map[string]models.ManifestEntry // Asset URL -> manifest entry
+	byFile     map[string]models.ManifestEntry // Project path (slash) -> manifest entry
+
+	// Archive exports: raw page file -> archive path, and the current page
+	archivePages map[string]string
+	archivePath  string
+
+	// MHTML parts of the current page by URL, in order of first use
+	parts     map[string]inlinedFile
+	partOrder []string
+	css       map[string]string // MHTML stylesheets with rewritten references
+}
+
+// newInliner indexes the manifest of a project
+func newInliner(projectID, dataDir, format string) (*inliner, error) {
+	if err := ValidateSingleFileFormat(format); err != nil {
+		return nil, err
+	}
+
+	entries, err := scraper.LoadManifest(projectID, dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	in := &inliner{
+		projectDir:   filepath.Join(dataDir, projectID),
+		mhtml:        format == models.SingleFileMHTML,
+		byURL:        make(map[string]models.ManifestEntry),
+		byFile:       make(map[string]models.ManifestEntry),
+		archivePages: make(map[string]string),
+	}
+	for _, entry := range entries {
+		if entry.LocalPath == "" {
+			continue
+		}
+		in.byFile[entry.LocalPath] = entry
+		if entry.Kind == models.ManifestKindAsset {
+			in.byURL[entry.URL] = entry
+		}
+	}
+	return in, nil
+}
+
+// write outputs one page in the inliner's format
+func (in *inliner) write(w io.Writer, chapter *pdfChapter) error {
+	doc, err := parseHTMLFile(chapter.Path)
+	if err != nil {
+		return err
+	}
+
+	in.parts = make(map[string]inlinedFile)
+	in.partOrder = nil
+	in.css = make(map[string]string)
+
+	in.rewriteNode(doc, filepath.Dir(chapter.Path), chapter.URL)
+
+	if !in.mhtml {
+		return html.Render(w, doc)
+	}
+
+	var page bytes.Buffer
+	if err := html.Render(&page, doc); err != nil {
+		return err
+	}
+	return in.writeMHTML(w, chapter, page.Bytes())
+}
+
+// rewriteNode replaces references to project files below node
+func (in *inliner) rewriteNode(node *html.Node, dir, pageURL string) {
+	if node.Type == html.ElementNode {
+		in.rewriteElement(node, dir, pageURL)
+	}
+
+	for child := node.FirstChild; child != nil; {
+		next := child.NextSibling
+		in.rewriteNode(child, dir, pageURL)
+		child = next
+	}
+}
+
+// rewriteElement inlines or relinks the references of one element
+func (in *inliner) rewriteElement(node *html.Node, dir, pageURL string) {
+	switch node.Data {
+	case "link":
+		rel := strings.ToLower(getAttr(node, "rel"))
+		if strings.Contains(rel, "stylesheet") {
+			if in.inlineStylesheet(node, dir, pageURL) {
+				return
+			}
+		} else if strings.Contains(rel, "icon") || strings.Contains(rel, "preload") {
+			in.rewriteAttr(node, "href", dir, pageURL)
+		} else {
+			in.rewriteLink(node, dir, pageURL)
+		}
+	case "script":
+		if getAttr(node, "src") != "" && in.inlineScript(node, dir, pageURL) {
+			return
+		}
+	case "style":
+		if child := node.FirstChild; child != nil && child.Type == html.TextNode {
+			child.Data = in.rewriteCSS(child.Data, dir, pageURL, 0)
+		}
+	case "a", "area":
+		in.rewriteLink(node, dir, pageURL)
+	}
+
+	for i, attr := range node.Attr {
+		switch attr.Key {
+		case "src", "poster", "data-src":
+			if node.Data != "script" && node.Data != "iframe" && node.Data != "frame" {
+				in.rewriteAttr(node, attr.Key, dir, pageURL)
+			}
+		case "srcset":
+			node.Attr[i].Val = in.rewriteSrcset(attr.Val, dir, pageURL)
+		case "style":
+			if strings.Contains(attr.Val, "url(") {
+				node.Attr[i].Val = in.rewriteCSS(attr.Val, dir, pageURL, 0)
+			}
+		}
+	}
+}
+
+// inlineStylesheet replaces a stylesheet link with a <style> block, or in
+// MHTML points it to the stylesheet part
+func (in *inliner) inlineStylesheet(node *html.Node, dir, pageURL string) bool {
+	file, ok := in.resolve(getAttr(node, "href"), dir, pageURL)
+	if !ok || tooLargeToInline(file) {
+		in.rewriteAttr(node, "href", dir, pageURL)
+		return true
+	}
+	css, err := in.stylesheet(file, 0)
+	if err != nil {
+		return false
+	}
+
+	if in.mhtml {
+		setAttr(node, "href", in.addPart(file))
+		removeAttrs(node, "integrity")
+		return true
+	}
+
+	style := &html.Node{Type: html.ElementNode, Data: "style"}
+	if media := getAttr(node, "media"); media != "" {
+		style.Attr = []html.Attribute{{Key: "media", Val: media}}
+	}
+	style.AppendChild(&html.Node{Type: html.TextNode, Data: escapeRawText(css, "style")})
+	node.Parent.InsertBefore(style, node)
+	node.Parent.RemoveChild(node)
+	return true
+}
+
+// inlineScript moves an external script into the page
+func (in *inliner) inlineScript(node *html.Node, dir, pageURL string) bool {
+	file, ok := in.resolve(getAttr(node, "src"), dir, pageURL)
+	if !ok || tooLargeToInline(file) {
+		in.rewriteAttr(node, "src", dir, pageURL)
+		return true
+	}
+
+	if in.mhtml {
+		setAttr(node, "src", in.addPart(file))
+		removeAttrs(node, "integrity")
+		return true
+	}
+
+	data, err := os.ReadFile(file.path)
+	if err != nil {
+		return false
+	}
+	removeAttrs(node, "src", "integrity", "crossorigin", "async", "defer")
+	for node.FirstChild != nil {
+		node.RemoveChild(node.FirstChild)
+	}
+	node.AppendChild(&html.Node{Type: html.TextNode, Data: escapeRawText(string(data), "script")})
+	return true
+}
+
+// rewriteLink points links to pages and files back to the site, or in HTML
+// archives to the exported pages
+func (in *inliner) rewriteLink(node *html.Node, dir, pageURL string) {
+	href := strings.TrimSpace(getAttr(node, "href"))
+	if href == "" || strings.HasPrefix(href, "#") {
+		return
+	}
+	target, fragment, _ := strings.Cut(href, "#")
+	if fragment != "" {
+		fragment = "#" + fragment
+	}
+
+	if file := resolveProjectFile(in.projectDir, dir, target); file != "" {
+		if archivePath, ok := in.archivePages[file]; ok {
+			setAttr(node, "href", relativeArchivePath(in.archivePath, archivePath)+fragment)
+			return
+		}
+		if rel, err := filepath.Rel(in.projectDir, file); err == nil {
+			if entry, ok := in.byFile[filepath.ToSlash(rel)]; ok {
+				setAttr(node, "href", entry.URL+fragment)
+				return
+			}
+		}
+	}
+
+	if absolute := resolveURL(pageURL, target); absolute != "" {
+		setAttr(node, "href", absolute+fragment)
+	}
+}
+
+// rewriteAttr replaces a reference to a project file with its inlined form
+func (in *inliner) rewriteAttr(node *html.Node, key, dir, pageURL string) {
+	if ref, ok := in.reference(getAttr(node, key), dir, pageURL); ok {
+		setAttr(node, key, ref)
+	}
+}
+
+// rewriteSrcset replaces the candidate URLs of a srcset attribute
+func (in *inliner) rewriteSrcset(srcset, dir, pageURL string) string {
+	candidates := strings.Split(srcset, ",")
+	for i, candidate := range candidates {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		if ref, ok := in.reference(fields[0], dir, pageURL); ok {
+			fields[0] = ref
+		}
+		candidates[i] = strings.Join(fields, " ")
+	}
+	return strings.Join(candidates, ", ")
+}
+
+// rewriteCSS inlines url() references and @import rules of a stylesheet
+func (in *inliner) rewriteCSS(css, dir, baseURL string, depth int) string {
+	css = cssImportPattern.ReplaceAllStringFunc(css, func(match string) string {
+		groups := cssImportPattern.FindStringSubmatch(match)
+		file, ok := in.resolve(groups[1], dir, baseURL)
+		if !ok || depth >= maxCSSImportDepth {
+			return match
+		}
+		imported, err := in.stylesheet(file, depth+1)
+		if err != nil {
+			return match
+		}
+		media := strings.TrimSpace(groups[2])
+
+		if in.mhtml {
+			return fmt.Sprintf(`@import url("%s")%s;`, in.addPart(file), prefixSpace(media))
+		}
+		if media != "" {
+			return "@media " + media + " {\n" + imported + "\n}"
+		}
+		return imported
+	})
+
+	return cssURLPattern.ReplaceAllStringFunc(css, func(match string) string {
+		ref := strings.TrimSpace(cssURLPattern.FindStringSubmatch(match)[1])
+		if replacement, ok := in.reference(ref, dir, baseURL); ok {
+			return `url("` + replacement + `")`
+		}
+		return match
+	})
+}
+
+// stylesheet returns a project stylesheet with its references rewritten
+func (in *inliner) stylesheet(file inlinedFile, depth int) (string, error) {
+	if css, ok := in.css[file.path]; ok {
+		return css, nil
+	}
+
+	data, err := os.ReadFile(file.path)
+	if err != nil {
+		return "", err
+	}
+	css := in.rewriteCSS(string(data), filepath.Dir(file.path), file.url, depth)
+	in.css[file.path] = css
+	return css, nil
+}
+
+// reference returns what replaces a reference: for project files a data URI,
+// or in MHTML the URL of its part; files over maxInlineSize and anything
+// else are resolved against baseURL so they still load from the site
+func (in *inliner) reference(ref, dir, baseURL string) (string, bool) {
+	file, ok := in.resolve(ref, dir, baseURL)
+	if !ok {
+		ref = strings.TrimSpace(ref)
+		parsed, err := url.Parse(ref)
+		if ref == "" || strings.HasPrefix(ref, "#") || err != nil || (parsed.Scheme != "" && parsed.Scheme != "http" && parsed.Scheme != "https") {
+			return "", false
+		}
+		absolute := resolveURL(baseURL, ref)
+		return absolute, absolute != ""
+	}
+	if tooLargeToInline(file) {
+		// Files missing from the manifest have no address on the site
+		if strings.HasPrefix(file.url, "file:") {
+			return "", false
+		}
+		return file.url, true
+	}
+	if in.mhtml {
+		return in.addPart(file), true
+	}
+
+	data, err := os.ReadFile(file.path)
+	if err != nil {
+		return "", false
+	}
+	if strings.HasPrefix(file.contentType, "text/css") {
+		data = []byte(in.rewriteCSS(string(data), filepath.Dir(file.path), file.url, maxCSSImportDepth))
+	}
+	// Parameters such as charset are left out of data URIs
+	mediaType, _, err := mime.ParseMediaType(file.contentType)
+	if err != nil {
+		mediaType = "application/octet-stream"
+	}
+	return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data), true
+}
+
+// resolve finds the project file a reference points to: a local path as in
+// saved pages, or a URL of a downloaded asset resolved against baseURL
+func (in *inliner) resolve(ref, dir, baseURL string) (inlinedFile, bool) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") || strings.HasPrefix(ref, "data:") {
+		return inlinedFile{}, false
+	}
+	ref, _, _ = strings.Cut(ref, "#")
+
+	if file := resolveProjectFile(in.projectDir, dir, ref); file != "" {
+		rel, err := filepath.Rel(in.projectDir, file)
+		if err != nil {
+			return inlinedFile{}, false
+		}
+		entry := in.byFile[filepath.ToSlash(rel)]
+		if entry.Kind == models.ManifestKindPage {
+			return inlinedFile{}, false
+		}
+		return in.file(file, entry), true
+	}
+
+	if absolute := resolveURL(baseURL, ref); absolute != "" {
+		if entry, ok := in.byURL[absolute]; ok {
+			file := filepath.Join(in.projectDir, filepath.FromSlash(entry.LocalPath))
+			if fileExists(file) {
+				return in.file(file, entry), true
+			}
+		}
+	}
+	return inlinedFile{}, false
+}
+
+// file describes a project file; files missing from the manifest get a
+// URL under the project
+func (in *inliner) file(filePath string, entry models.ManifestEntry) inlinedFile {
+	contentType := entry.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(filePath))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	fileURL := entry.URL
+	if fileURL == "" {
+		rel, _ := filepath.Rel(in.projectDir, filePath)
+		fileURL = "file:///" + filepath.ToSlash(rel)
+	}
+	return inlinedFile{path: filePath, url: fileURL, contentType: contentType}
+}
+
+// tooLargeToInline reports whether a file is over maxInlineSize
+func tooLargeToInline(file inlinedFile) bool {
+	info, err := os.Stat(file.path)
+	return err == nil && info.Size() > maxInlineSize
+}
+
+// addPart includes a file in the MHTML archive and returns its URL
+func (in *inliner) addPart(file inlinedFile) string {
+	if _, ok := in.parts[file.url]; !ok {
+		in.parts[file.url] = file
+		in.partOrder = append(in.partOrder, file.url)
+	}
+	return file.url
+}
+
+// writeMHTML writes a page and its parts as a multipart/related message
+func (in *inliner) writeMHTML(w io.Writer, chapter *pdfChapter, page []byte) error {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	location := chapter.URL
+	if location == "" {
+		rel, _ := filepath.Rel(in.projectDir, chapter.Path)
+		location = "file:///" + filepath.ToSlash(rel)
+	}
+	date := chapter.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	fmt.Fprintf(w, "From: <Saved by %s>\r\n", scraperName)
+	fmt.Fprintf(w, "Snapshot-Content-Location: %s\r\n", location)
+	fmt.Fprintf(w, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", chapter.Title))
+	fmt.Fprintf(w, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(w, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(w, "Content-Type: multipart/related;\r\n\ttype=\"text/html\";\r\n\tboundary=\"%s\"\r\n\r\n", mw.Boundary())
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", "text/html; charset=utf-8")
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	header.Set("Content-Location", location)
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write(page); err != nil {
+		return err
+	}
+	if err := qp.Close(); err != nil {
+		return err
+	}
+
+	for _, partURL := range in.partOrder {
+		file := in.parts[partURL]
+		data, err := os.ReadFile(file.path)
+		if err != nil {
+			return err
+		}
+		if css, ok := in.css[file.path]; ok {
+			data = []byte(css)
+		}
+
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", file.contentType)
+		header.Set("Content-Transfer-Encoding", "base64")
+		header.Set("Content-Location", file.url)
+		part, err := mw.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		if err := writeBase64Lines(part, data); err != nil {
+			return err
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return err
+	}
+	_, err = w.Write(body.Bytes())
+	return err
+}
+
+// writeBase64Lines writes data base64-encoded in lines of 76 characters
+func writeBase64Lines(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 0 {
+		n := min(76, len(encoded))
+		if _, err := io.WriteString(w, encoded[:n]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[n:]
+	}
+	return nil
+}
+
+// resolveURL resolves a reference against a base URL; it returns "" for
+// unusable input
+func resolveURL(baseURL, ref string) string {
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	if parsed.IsAbs() {
+		return parsed.String()
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil || baseURL == "" || !base.IsAbs() {
+		return ""
+	}
+	return base.ResolveReference(parsed).String()
+}
+
+// escapeRawText keeps inlined code from closing its element early
+func escapeRawText(text, tag string) string {
+	return rawTextEnd[tag].ReplaceAllString(text, `<\/$1`)
+}
+
+// prefixSpace puts a space before non-empty text
+func prefixSpace(text string) string {
+	if text == "" {
+		return ""
+	}
+	return " " + text
+}
+
+// setAttr sets or adds an attribute
+func setAttr(node *html.Node, key, value string) {
+	for i, attr := range node.Attr {
+		if attr.Key == key {
+			node.Attr[i].Val = value
+			return
+		}
+	}
+	node.Attr = append(node.Attr, html.Attribute{Key: key, Val: value})
+}
+
+// removeAttrs drops attributes from an element
+func removeAttrs(node *html.Node, keys ...string) {
+	kept := node.Attr[:0]
+	for _, attr := range node.Attr {
+		drop := false
+		for _, key := range keys {
+			if attr.Key == key {
+				drop = true
+			}
+		}
+		if !drop {
+			kept = append(kept, attr)
+		}
+	}
+	node.Attr = kept
+}
@@ -0,0 +1,94 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/user/scrapper/internal/models"
+)
+
+// testInliner creates an inliner for a project with a small image, a video
+// over maxInlineSize and a large file missing from the manifest
+func testInliner(t *testing.T, format string) (*inliner, string) {
+	t.Helper()
+	projectDir := t.TempDir()
+	files := map[string]int64{
+		"assets/img/logo.png":    4,
+		"assets/media/clip.mp4":  maxInlineSize + 1,
+		"assets/media/local.mp4": maxInlineSize + 1,
+	}
+	for name, size := range files {
+		path := filepath.Join(projectDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		file, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = file.Truncate(size)
+		file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	in := &inliner{
+		projectDir: projectDir,
+		mhtml:      format == models.SingleFileMHTML,
+		byURL:      make(map[string]models.ManifestEntry),
+		byFile:     make(map[string]models.ManifestEntry),
+		parts:      make(map[string]inlinedFile),
+		css:        make(map[string]string),
+	}
+	for _, entry := range []models.ManifestEntry{
+		{URL: "https://example.com/logo.png", LocalPath: "assets/img/logo.png", Kind: models.ManifestKindAsset, ContentType: "image/png"},
+		{URL: "https://cdn.example.com/clip.mp4", LocalPath: "assets/media/clip.mp4", Kind: models.ManifestKindAsset, ContentType: "video/mp4"},
+	} {
+		in.byURL[entry.URL] = entry
+		in.byFile[entry.LocalPath] = entry
+	}
+	return in, filepath.Join(projectDir, "pages")
+}
+
+func TestInlinerReferenceSizeLimit(t *testing.T) {
+	tests := []struct {
+		format string
+		ref    string
+		want   string // Prefix of the replacement; empty to keep the reference
+	}{
+		{models.SingleFileHTML, "../assets/img/logo.png", "data:image/png;base64,AAAAAA=="},
+		{models.SingleFileHTML, "https://example.com/logo.png", "data:image/png;base64,"},
+		{models.SingleFileHTML, "../assets/media/clip.mp4", "https://cdn.example.com/clip.mp4"},
+		{models.SingleFileHTML, "https://cdn.example.com/clip.mp4", "https://cdn.example.com/clip.mp4"},
+		{models.SingleFileHTML, "../assets/media/local.mp4", ""},
+		{models.SingleFileMHTML, "../assets/img/logo.png", "https://example.com/logo.png"},
+		{models.SingleFileMHTML, "../assets/media/clip.mp4", "https://cdn.example.com/clip.mp4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format+" "+tt.ref, func(t *testing.T) {
+			in, dir := testInliner(t, tt.format)
+			got, ok := in.reference(tt.ref, dir, "https://example.com/page")
+			if tt.want == "" {
+				if ok {
+					t.Errorf("reference = %q, want it kept", got)
+				}
+				return
+			}
+			if !ok || !strings.HasPrefix(got, tt.want) {
+				t.Errorf("reference = %q, %v, want %q", got, ok, tt.want)
+			}
+			if strings.HasPrefix(got, "data:") && len(got) > maxInlineSize {
+				t.Errorf("inlined %d bytes", len(got))
+			}
+
+			_, isPart := in.parts[got]
+			if wantPart := in.mhtml && strings.HasSuffix(tt.ref, ".png"); isPart != wantPart {
+				t.Errorf("MHTML part added = %v, want %v", isPart, wantPart)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/user/scrapper/internal/scraper"
+	"github.com/xuri/excelize/v2"
+)
+
+// ErrNoTables is returned when a project has no extracted tables
+var ErrNoTables = errors.New("no tables to export")
+
+// tablesIndexSheet lists the source of every table in table workbooks
+const tablesIndexSheet = "Tables"
+
+// tablesIndexColumns are the columns of the index sheet
+var tablesIndexColumns = []string{"sheet", "page_url", "position", "caption", "rows", "columns"}
+
+// numberPattern matches plain decimal numbers
+var numberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]{0,14})(\.[0-9]{1,6})?$`)
+
+// CreateTablesWorkbook writes the extracted tables of a project to an XLSX
+// workbook at path: an index sheet with the source page and position of
+// each table, then one sheet per table. progress, if set, is called after
+// each table.
+func CreateTablesWorkbook(path, projectID, dataDir string, progress ProgressFunc) error {
+	tables, err := scraper.LoadTables(projectID, dataDir)
+	if err != nil {
+		return fmt.Errorf("failed to load tables: %w", err)
+	}
+	if len(tables) == 0 {
+		return ErrNoTables
+	}
+
+	workbook := excelize.NewFile()
+	defer workbook.Close()
+
+	if err := workbook.SetSheetName("Sheet1", tablesIndexSheet); err != nil {
+		return err
+	}
+	index := [][]any{toAnyRow(tablesIndexColumns)}
+	for _, table := range tables {
+		index = append(index, []any{table.ID, table.PageURL, table.Position, table.Caption, table.Rows, table.Columns})
+	}
+	if err := writeSheetValues(workbook, tablesIndexSheet, index); err != nil {
+		return err
+	}
+
+	projectDir := filepath.Join(dataDir, projectID)
+	for i, table := range tables {
+		records, err := readCSVFile(filepath.Join(projectDir, filepath.FromSlash(table.File)))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", table.File, err)
+		}
+
+		if _, err := workbook.NewSheet(table.ID); err != nil {
+			return err
+		}
+		rows := make([][]any, len(records))
+		for r, record := range records {
+			if r == 0 && table.Header != nil {
+				rows[r] = toAnyRow(record)
+				continue
+			}
+			rows[r] = cellValues(record)
+		}
+		if err := writeSheetValues(workbook, table.ID, rows); err != nil {
+			return err
+		}
+
+		if progress != nil {
+			progress(i+1, len(tables))
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := workbook.Write(file); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// CreateTablesArchive writes a ZIP with the CSV file of every extracted
+// table and the tables index to zipPath
+func CreateTablesArchive(zipPath, projectID, dataDir string, progress ProgressFunc) error {
+	tables, err := scraper.LoadTables(projectID, dataDir)
+	if err != nil {
+		return fmt.Errorf("failed to load tables: %w", err)
+	}
+	if len(tables) == 0 {
+		return ErrNoTables
+	}
+
+	file, err := os.Create(zipPath)
+	if err != nil {
+		return fmt.Errorf("failed to create zip file: %w", err)
+	}
+	defer file.Close()
+
+	zipWriter := zip.NewWriter(file)
+
+	index, err := json.MarshalIndent(tables, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeZipEntry(zipWriter, scraper.TablesIndexFileName, index, time.Now()); err != nil {
+		return err
+	}
+
+	projectDir := filepath.Join(dataDir, projectID)
+	for i, table := range tables {
+		if err := addFileToZip(zipWriter, filepath.Join(projectDir, filepath.FromSlash(table.File)), table.ID+".csv"); err != nil {
+			return err
+		}
+		if progress != nil {
+			progress(i+1, len(tables))
+		}
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// readCSVFile reads all records of a CSV file
+func readCSVFile(path string) ([][]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	return reader.ReadAll()
+}
+
+// cellValues stores plain numbers as numbers and everything else as text;
+// numbers whose text would change, such as "10.50" or "007", stay text
+func cellValues(record []string) []any {
+	values := make([]any, len(record))
+	for i, value := range record {
+		values[i] = value
+		if numberPattern.MatchString(value) {
+			if n, err := strconv.ParseFloat(value, 64); err == nil && strconv.FormatFloat(n, 'f', -1, 64) == value {
+				values[i] = n
+			}
+		}
+	}
+	return values
+}
+
+// toAnyRow converts strings to sheet values
+func toAnyRow(record []string) []any {
+	values := make([]any, len(record))
+	for i, value := range record {
+		values[i] = value
+	}
+	return values
+}
+
+// writeSheetValues writes rows of values to a sheet starting at A1
+func writeSheetValues(workbook *excelize.File, sheet string, rows [][]any) error {
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return err
+		}
+		if err := workbook.SetSheetRow(sheet, cell, &row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
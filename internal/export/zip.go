@@ -2,146 +2,177 @@ package export
 
 import (
 	"archive/zip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 )
 
-// CreateZipArchive creates a ZIP file from project directory
-func CreateZipArchive(projectID, dataDir string) (string, error) {
+// Option combinations a ZIP export cannot honor: the manifest of an
+// encrypted archive could not be verified, and encryption uses a random salt
+var (
+	ErrSignedEncryptedZip        = errors.New("signed archives cannot be encrypted")
+	ErrDeterministicEncryptedZip = errors.New("deterministic archives cannot be encrypted")
+)
+
+// ZipOptions controls a project ZIP export
+type ZipOptions struct {
+	ArchiveOptions
+	SigningKey ed25519.PrivateKey // Adds a signed integrity manifest
+	Password   string             // Encrypts every file with WinZip AES-256
+}
+
+// CreateZipArchive writes a ZIP file of the project directory to zipPath.
+// progress, if set, is called after each file.
+func CreateZipArchive(zipPath, projectID, dataDir string, opts ZipOptions, progress ProgressFunc) error {
 	projectDir := filepath.Join(dataDir, projectID)
-	zipPath := filepath.Join(dataDir, projectID+".zip")
 
 	// Create ZIP file
 	zipFile, err := os.Create(zipPath)
 	if err != nil {
-		return "", fmt.Errorf("failed to create zip file: %w", err)
+		return fmt.Errorf("failed to create zip file: %w", err)
 	}
 	defer zipFile.Close()
 
-	// Create ZIP writer
-	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
+	if err := writeProjectZip(zipFile, projectDir, opts, progress); err != nil {
+		return fmt.Errorf("failed to archive project: %w", err)
+	}
 
-	// Walk project directory
-	err = filepath.Walk(projectDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+	return zipFile.Close()
+}
 
-		// Skip the project directory itself
-		if path == projectDir {
-			return nil
-		}
+// StreamZipToWriter streams ZIP archive directly to HTTP response
+func StreamZipToWriter(w io.Writer, projectID, dataDir string, opts ZipOptions) error {
+	return writeProjectZip(w, filepath.Join(dataDir, projectID), opts, nil)
+}
 
-		// Get relative path for ZIP entry
-		relPath, err := filepath.Rel(projectDir, path)
-		if err != nil {
-			return err
-		}
+// writeProjectZip archives a project directory, leaving out cached exports.
+// With a signing key every file is hashed and the signed manifest is
+// written after the files.
+func writeProjectZip(w io.Writer, projectDir string, opts ZipOptions, progress ProgressFunc) error {
+	if opts.Password != "" && opts.SigningKey != nil {
+		return ErrSignedEncryptedZip
+	}
+	if opts.Password != "" && opts.Deterministic {
+		return ErrDeterministicEncryptedZip
+	}
 
-		// Create ZIP entry header
-		header, err := zip.FileInfoHeader(info)
-		if err != nil {
+	var recorder *integrityRecorder
+	if opts.SigningKey != nil {
+		var err error
+		if recorder, err = newIntegrityRecorder(projectDir, opts.SigningKey); err != nil {
 			return err
 		}
+	}
+
+	files, err := projectFiles(projectDir, opts.ArchiveOptions)
+	if err != nil {
+		return err
+	}
+	total := countFiles(files)
 
-		// Use forward slashes for ZIP compatibility
-		header.Name = filepath.ToSlash(relPath)
+	// Create ZIP writer
+	zipWriter := zip.NewWriter(w)
 
-		// Handle directories
-		if info.IsDir() {
-			header.Name += "/"
-		} else {
-			// Set compression method
-			header.Method = zip.Deflate
+	done := 0
+	for _, file := range files {
+		// The manifest names are reserved in signed archives
+		if recorder != nil && isIntegrityFile(file.name) {
+			continue
 		}
 
-		// Create entry writer
-		writer, err := zipWriter.CreateHeader(header)
-		if err != nil {
+		if err := writeZipFile(zipWriter, file, opts, recorder); err != nil {
 			return err
 		}
-
-		// Write file content (if not directory)
-		if !info.IsDir() {
-			file, err := os.Open(path)
-			if err != nil {
-				return err
-			}
-			defer file.Close()
-
-			if _, err := io.Copy(writer, file); err != nil {
-				return err
-			}
+		if file.info.IsDir() {
+			continue
 		}
 
-		return nil
-	})
+		done++
+		if progress != nil {
+			progress(done, total)
+		}
+	}
 
-	if err != nil {
-		return "", fmt.Errorf("failed to archive project: %w", err)
+	if recorder != nil {
+		modified := time.Now().UTC()
+		if opts.Deterministic {
+			modified = recorder.lastCapture()
+		}
+		if err := recorder.write(zipWriter, modified); err != nil {
+			return err
+		}
 	}
 
-	return zipPath, nil
+	return zipWriter.Close()
 }
 
-// StreamZipToWriter streams ZIP archive directly to HTTP response
-func StreamZipToWriter(w io.Writer, projectID, dataDir string) error {
-	projectDir := filepath.Join(dataDir, projectID)
+// writeZipFile adds a file or directory of the project to a ZIP archive
+func writeZipFile(zipWriter *zip.Writer, file archiveFile, opts ZipOptions, recorder *integrityRecorder) error {
+	// Create ZIP entry header
+	header, err := zip.FileInfoHeader(file.info)
+	if err != nil {
+		return err
+	}
+	header.Name = file.name
+	if opts.Deterministic {
+		header.Modified = deterministicTime
+		header.SetMode(zipEntryMode(file.info))
+	}
 
-	// Create ZIP writer directly to response
-	zipWriter := zip.NewWriter(w)
-	defer zipWriter.Close()
+	// Directories carry no data and are never encrypted
+	if file.info.IsDir() {
+		_, err := zipWriter.CreateHeader(header)
+		return err
+	}
 
-	// Walk and stream
-	return filepath.Walk(projectDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+	// Set compression method
+	header.Method = zip.Deflate
 
-		if path == projectDir {
-			return nil
-		}
+	source, err := os.Open(file.path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
 
-		relPath, err := filepath.Rel(projectDir, path)
-		if err != nil {
+	var writer io.Writer
+	var entry *aesEntryWriter
+	if opts.Password != "" {
+		if entry, err = createAESEntry(zipWriter, header, opts.Password); err != nil {
 			return err
 		}
+		writer = entry
+	} else if writer, err = zipWriter.CreateHeader(header); err != nil {
+		return err
+	}
 
-		header, err := zip.FileInfoHeader(info)
-		if err != nil {
+	// Write file content, hashing it for the manifest of signed archives
+	if recorder == nil {
+		if _, err := io.Copy(writer, source); err != nil {
 			return err
 		}
-
-		header.Name = filepath.ToSlash(relPath)
-
-		if info.IsDir() {
-			header.Name += "/"
-		} else {
-			header.Method = zip.Deflate
-		}
-
-		writer, err := zipWriter.CreateHeader(header)
+	} else {
+		h := sha256.New()
+		size, err := io.Copy(io.MultiWriter(writer, h), source)
 		if err != nil {
 			return err
 		}
+		recorder.add(file.name, h, size)
+	}
 
-		if !info.IsDir() {
-			file, err := os.Open(path)
-			if err != nil {
-				return err
-			}
-			defer file.Close()
-
-			if _, err := io.Copy(writer, file); err != nil {
-				return err
-			}
-		}
+	if entry != nil {
+		return entry.Close()
+	}
+	return nil
+}
 
-		return nil
-	})
+// isExportsDir reports whether path is the cached exports directory of a project
+func isExportsDir(projectDir, path string) bool {
+	return path == filepath.Join(projectDir, ExportsDirName)
 }
 
 // CleanupZipFile removes temporary ZIP file
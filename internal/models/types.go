@@ -1,13 +1,23 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // ScrapeRequest represents incoming scraping request from API
 type ScrapeRequest struct {
-	URL       string       `json:"url"`
-	URLPrefix string       `json:"url_prefix,omitempty"`
-	Depth     int          `json:"depth"`
-	Filters   []FilterRule `json:"filters"`
+	URL              string             `json:"url"`
+	URLPrefix        string             `json:"url_prefix,omitempty"`
+	Depth            int                `json:"depth"`
+	Filters          []FilterRule       `json:"filters"`
+	LinkCheck        *LinkCheckOptions  `json:"link_check,omitempty"`
+	Strategy         CrawlStrategy      `json:"strategy,omitempty"`
+	PriorityRules    []PriorityRule     `json:"priority_rules,omitempty"`
+	MaxPages         int                `json:"max_pages,omitempty"`          // 0 = unlimited
+	TimeLimitSeconds int                `json:"time_limit_seconds,omitempty"` // 0 = unlimited
+	Extraction       []ExtractionSchema `json:"extraction_schemas,omitempty"`
+	Content          *ContentOptions    `json:"content,omitempty"`
 }
 
 // FilterRule defines HTML/JS filtering pattern
@@ -16,6 +26,100 @@ type FilterRule struct {
 	End   string `json:"end"`   // End pattern (e.g., "</script>")
 }
 
+// CrawlStrategy selects the order in which discovered URLs are fetched
+type CrawlStrategy string
+
+const (
+	StrategyBreadthFirst CrawlStrategy = "bfs"
+	StrategyDepthFirst   CrawlStrategy = "dfs"
+	StrategyPriority     CrawlStrategy = "priority"
+)
+
+// PriorityRule adds Score to every URL matching Pattern (regular expression).
+// Used by the priority strategy; higher scores are fetched first.
+type PriorityRule struct {
+	Pattern string `json:"pattern"`
+	Score   int    `json:"score"`
+}
+
+// Selector types for extraction rules
+const (
+	SelectorCSS   = "css"
+	SelectorXPath = "xpath"
+)
+
+// Extraction modes for extraction fields
+const (
+	ExtractText = "text"
+	ExtractHTML = "html"
+	ExtractAttr = "attr"
+)
+
+// ExtractionSchema describes structured data extracted from matching pages.
+// Without ItemSelector each matching page yields one record; with it, one
+// record per matched container, with field selectors relative to the container.
+type ExtractionSchema struct {
+	Name         string            `json:"name"`
+	URLPattern   string            `json:"url_pattern,omitempty"`   // Regex; empty matches every page
+	ItemSelector string            `json:"item_selector,omitempty"` // Repeated item container
+	SelectorType string            `json:"selector_type,omitempty"` // Item selector type: "css" (default) or "xpath"
+	Fields       []ExtractionField `json:"fields"`
+}
+
+// ExtractionField extracts a single named value
+type ExtractionField struct {
+	Name         string `json:"name"`
+	Selector     string `json:"selector"`
+	SelectorType string `json:"selector_type,omitempty"` // "css" (default) or "xpath"
+	Extract      string `json:"extract,omitempty"`       // "text" (default), "html" or "attr"
+	Attr         string `json:"attr,omitempty"`          // Attribute name for "attr"
+	Multiple     bool   `json:"multiple,omitempty"`      // Collect all matches as a list
+}
+
+// DatasetRecord is one extracted item, stored as a line of dataset.jsonl.
+// Field values are strings, or string lists for Multiple fields.
+type DatasetRecord struct {
+	Schema      string         `json:"schema"`
+	URL         string         `json:"url"`
+	Index       int            `json:"index"` // Item position on the page
+	ExtractedAt time.Time      `json:"extracted_at"`
+	Fields      map[string]any `json:"fields"`
+}
+
+// TableInfo describes an HTML table extracted from a page after the crawl.
+// Spanned cells are repeated in every row and column they cover.
+type TableInfo struct {
+	ID       string   `json:"id"` // "table-0001"; also the CSV and worksheet name
+	PageURL  string   `json:"page_url"`
+	Position int      `json:"position"` // 1-based index among the <table> elements of the page
+	Caption  string   `json:"caption,omitempty"`
+	Header   []string `json:"header,omitempty"` // Header rows merged per column with " / "
+	Rows     int      `json:"rows"`             // Data rows, without the header
+	Columns  int      `json:"columns"`
+	File     string   `json:"file"` // CSV path relative to the project directory
+}
+
+// LinkCheckOptions enables the broken link report
+type LinkCheckOptions struct {
+	Enabled             bool `json:"enabled"`
+	CheckExternal       bool `json:"check_external"`                 // HEAD-check links to other domains
+	ExternalParallelism int  `json:"external_parallelism,omitempty"` // Concurrent external checks (default 2)
+	ExternalDelayMs     int  `json:"external_delay_ms,omitempty"`    // Delay between requests to the same external host (default 500)
+	TimeoutSeconds      int  `json:"timeout_seconds,omitempty"`      // Per-request timeout (default 10)
+}
+
+// ContentOptions enables main content extraction into *.content.html files
+type ContentOptions struct {
+	Enabled   bool              `json:"enabled"`
+	Selectors []ContentSelector `json:"selectors,omitempty"` // Checked in order before the heuristics
+}
+
+// ContentSelector picks the content element with a CSS selector on matching pages
+type ContentSelector struct {
+	URLPattern string `json:"url_pattern,omitempty"` // Regex on the page URL; empty matches all
+	Selector   string `json:"selector"`
+}
+
 // ProjectStatus represents project execution state
 type ProjectStatus string
 
@@ -28,43 +132,245 @@ const (
 
 // Project represents a scraping project
 type Project struct {
-	ID         string        `json:"project_id"`
-	URL        string        `json:"url"`
-	URLPrefix  string        `json:"url_prefix,omitempty"`
-	Depth      int           `json:"depth"`
-	Status     ProjectStatus `json:"status"`
-	Filters    []FilterRule  `json:"filters"`
-	Progress   int           `json:"progress"`
-	Downloaded int           `json:"pages_downloaded"`
-	Total      int           `json:"total_pages"`
-	CurrentURL string        `json:"current_url"`
-	Errors     []string      `json:"errors"`
-	CreatedAt  time.Time     `json:"created_at"`
-	UpdatedAt  time.Time     `json:"updated_at"`
+	ID               string             `json:"project_id"`
+	URL              string             `json:"url"`
+	URLPrefix        string             `json:"url_prefix,omitempty"`
+	Depth            int                `json:"depth"`
+	Status           ProjectStatus      `json:"status"`
+	Filters          []FilterRule       `json:"filters"`
+	LinkCheck        *LinkCheckOptions  `json:"link_check,omitempty"`
+	LinkCheckSummary *LinkCheckSummary  `json:"link_check_summary,omitempty"`
+	Strategy         CrawlStrategy      `json:"strategy,omitempty"`
+	PriorityRules    []PriorityRule     `json:"priority_rules,omitempty"`
+	MaxPages         int                `json:"max_pages,omitempty"`
+	TimeLimitSeconds int                `json:"time_limit_seconds,omitempty"`
+	Extraction       []ExtractionSchema `json:"extraction_schemas,omitempty"`
+	Content          *ContentOptions    `json:"content,omitempty"`
+	ImportedFrom     string             `json:"imported_from,omitempty"` // WARC file the project was built from
+	Partial          bool               `json:"partial,omitempty"`       // Snapshot of a crawl that was still running
+	SnapshotAt       *time.Time         `json:"snapshot_at,omitempty"`
+	PendingAssets    int                `json:"pending_assets,omitempty"` // Assets of a snapshot not downloaded yet
+	Progress         int                `json:"progress"`
+	Downloaded       int                `json:"pages_downloaded"`
+	Total            int                `json:"total_pages"`
+	CurrentURL       string             `json:"current_url"`
+	Errors           []string           `json:"errors"`
+	CreatedAt        time.Time          `json:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at"`
 }
 
 // Asset represents a downloadable resource (image, CSS, JS, etc.)
 type Asset struct {
-	URL        string // Original URL
-	LocalPath  string // Path in project folder
-	Type       string // "image", "css", "js", "font", "other"
-	Downloaded bool
-	Error      string
+	URL         string // Original URL
+	LocalPath   string // Path in project folder
+	Type        string // "image", "css", "js", "font", "other"
+	Downloaded  bool
+	StatusCode  int
+	ContentType string
+	Size        int64
+	FetchedAt   time.Time
+	Error       string
 }
 
 // Page represents a scraped HTML page
 type Page struct {
-	URL        string
-	LocalPath  string // Relative path in project
-	Depth      int
-	ParentURL  string
-	HTML       string
-	Assets     []Asset
-	Links      []string // Extracted links
-	Downloaded bool
-	Processed  bool // Link transformation done
-	Filtered   bool // Filters applied
-	Error      string
+	URL         string
+	LocalPath   string // Relative path in project
+	Depth       int
+	ParentURL   string
+	HTML        string
+	Assets      []Asset
+	Links       []Link // Extracted outgoing links
+	Metadata    *PageMetadata
+	ContentPath string // Extracted main content, saved next to the page
+	Downloaded  bool
+	Processed   bool // Link transformation done
+	Filtered    bool // Filters applied
+	StatusCode  int
+	ContentType string
+	Size        int64
+	FetchedAt   time.Time
+	Error       string
+}
+
+// Link represents an outgoing <a href> found on a page
+type Link struct {
+	URL      string `json:"url"`
+	Text     string `json:"text,omitempty"` // Anchor text
+	Rel      string `json:"rel,omitempty"`
+	Internal bool   `json:"internal"` // Same domain as the project URL
+}
+
+// PageMetadata describes what a page is, extracted from its <head> and headings
+type PageMetadata struct {
+	Title       string            `json:"title,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Canonical   string            `json:"canonical,omitempty"` // Absolute canonical URL
+	Language    string            `json:"language,omitempty"`
+	Headings    []Heading         `json:"headings,omitempty"`   // h1-h3 outline in document order
+	OpenGraph   map[string]string `json:"open_graph,omitempty"` // og:* properties without prefix
+	Twitter     map[string]string `json:"twitter,omitempty"`    // twitter:* cards without prefix
+	JSONLD      []json.RawMessage `json:"json_ld,omitempty"`    // Valid JSON-LD blocks
+}
+
+// Heading is an entry of the page heading outline
+type Heading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	ID    string `json:"id,omitempty"` // Anchor id, if present
+}
+
+// Manifest entry kinds
+const (
+	ManifestKindPage  = "page"
+	ManifestKindAsset = "asset"
+)
+
+// ManifestEntry is a single line of the project manifest (manifest.jsonl)
+type ManifestEntry struct {
+	Kind        string        `json:"kind"` // "page" or "asset"
+	URL         string        `json:"url"`
+	LocalPath   string        `json:"local_path,omitempty"`   // Relative to project directory
+	ContentPath string        `json:"content_path,omitempty"` // Extracted main content (pages only)
+	Type        string        `json:"type,omitempty"`         // Asset type
+	Depth       int           `json:"depth,omitempty"`
+	ParentURL   string        `json:"parent_url,omitempty"`
+	StatusCode  int           `json:"status_code,omitempty"`
+	ContentType string        `json:"content_type,omitempty"`
+	Size        int64         `json:"size"`
+	FetchedAt   time.Time     `json:"fetched_at"`
+	Links       []Link        `json:"links,omitempty"`    // Outgoing links (pages only)
+	Metadata    *PageMetadata `json:"metadata,omitempty"` // Extracted page metadata (pages only)
+	Error       string        `json:"error,omitempty"`
+}
+
+// PageRecordSchemaVersion is the version of the PageRecord format. It is
+// raised whenever a field is removed or changes meaning; adding fields
+// keeps the version.
+const PageRecordSchemaVersion = 1
+
+// PageRecord is one page of the JSON Lines page export
+type PageRecord struct {
+	SchemaVersion int       `json:"schema_version"`
+	URL           string    `json:"url"`
+	CanonicalURL  string    `json:"canonical_url,omitempty"`
+	Title         string    `json:"title"`
+	Language      string    `json:"language,omitempty"`
+	Depth         int       `json:"depth"`
+	ParentURL     string    `json:"parent_url,omitempty"`
+	StatusCode    int       `json:"status_code"`
+	FetchedAt     time.Time `json:"fetched_at"`
+	Text          string    `json:"text"` // Main content as plain text
+	Headings      []Heading `json:"headings"`
+	Links         []Link    `json:"links"`
+	Assets        []string  `json:"assets"` // URLs of downloaded assets the page uses
+}
+
+// Single-file page export formats
+const (
+	SingleFileHTML  = "html"  // HTML with assets inlined as data URIs and <style> blocks
+	SingleFileMHTML = "mhtml" // MIME multipart/related web archive
+)
+
+// Chunk size units
+const (
+	ChunkUnitChars  = "chars"
+	ChunkUnitTokens = "tokens" // Whitespace-separated words
+)
+
+// ChunkOptions controls the chunked text export
+type ChunkOptions struct {
+	Unit        string   `json:"unit,omitempty"`         // "chars" (default) or "tokens"
+	Size        int      `json:"size,omitempty"`         // Maximum chunk size; default 1000 chars or 200 tokens
+	Overlap     int      `json:"overlap,omitempty"`      // Text repeated from the previous chunk of a section
+	KnownHashes []string `json:"known_hashes,omitempty"` // Hashes of chunks the caller already has; these are left out
+}
+
+// TextChunk is one line of the chunked text export
+type TextChunk struct {
+	ID       string   `json:"id"`  // Page URL and chunk position, unique within an export
+	URL      string   `json:"url"` // Page URL with the #fragment of the nearest heading anchor
+	PageURL  string   `json:"page_url"`
+	Title    string   `json:"title"`
+	Headings []string `json:"headings"` // Heading path from the top level down
+	Index    int      `json:"index"`    // Position of the chunk within the page
+	Text     string   `json:"text"`
+	Size     int      `json:"size"` // In the requested unit
+	Hash     string   `json:"hash"` // "sha256:" + hex digest of URL, headings and text
+}
+
+// IntegrityManifestVersion is the version of the IntegrityManifest format
+const IntegrityManifestVersion = 1
+
+// IntegrityManifest lists every file of a signed ZIP export
+type IntegrityManifest struct {
+	Version            int             `json:"version"`
+	ProjectID          string          `json:"project_id"`
+	CreatedAt          time.Time       `json:"created_at"`
+	HashAlgorithm      string          `json:"hash_algorithm"`      // "sha256"
+	SignatureAlgorithm string          `json:"signature_algorithm"` // "ed25519"
+	KeyID              string          `json:"key_id"`              // "sha256:" + hex digest of the public key
+	Files              []IntegrityFile `json:"files"`
+}
+
+// IntegrityFile is one file of an integrity manifest
+type IntegrityFile struct {
+	Path       string     `json:"path"` // Path in the archive
+	SHA256     string     `json:"sha256"`
+	Size       int64      `json:"size"`
+	SourceURL  string     `json:"source_url,omitempty"`  // URL the file was captured from, for pages and assets
+	CapturedAt *time.Time `json:"captured_at,omitempty"` // When the source URL was fetched
+}
+
+// Integrity problem kinds
+const (
+	IntegrityModified  = "modified"  // Content differs from the manifest
+	IntegrityMissing   = "missing"   // Listed in the manifest but not in the archive
+	IntegrityUnlisted  = "unlisted"  // In the archive but not in the manifest
+	IntegrityDuplicate = "duplicate" // Archive entry name used more than once
+	IntegritySignature = "signature" // Manifest signature does not match the key
+)
+
+// IntegrityProblem is one finding of an integrity check
+type IntegrityProblem struct {
+	Kind    string `json:"kind"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+// IntegrityReport is the result of verifying a ZIP export against its
+// manifest and signature
+type IntegrityReport struct {
+	Valid          bool               `json:"valid"` // Signature valid and no problems
+	SignatureValid bool               `json:"signature_valid"`
+	ProjectID      string             `json:"project_id"`
+	CreatedAt      time.Time          `json:"created_at"`
+	KeyID          string             `json:"key_id"` // Key the manifest claims to be signed with
+	Files          int                `json:"files"`  // Files listed in the manifest
+	Verified       int                `json:"verified"`
+	Problems       []IntegrityProblem `json:"problems"`
+}
+
+// ArchiveSelection limits a project archive to some pages, asset types
+// and files. With page filters, only assets the selected pages refer to
+// are included.
+type ArchiveSelection struct {
+	Include           []string `json:"include,omitempty"`             // URL globs of pages; "*" stays within a path segment, "**" does not
+	Exclude           []string `json:"exclude,omitempty"`             // URL globs of pages left out, applied after includes
+	IncludePatterns   []string `json:"include_patterns,omitempty"`    // Regex on page URL, matched like Include
+	ExcludePatterns   []string `json:"exclude_patterns,omitempty"`    // Regex on page URL, matched like Exclude
+	AssetTypes        []string `json:"asset_types,omitempty"`         // css, js, img, font or other; empty keeps every type
+	ExcludeAssetTypes []string `json:"exclude_asset_types,omitempty"` // Applied after AssetTypes
+	ExcludeMetadata   bool     `json:"exclude_metadata,omitempty"`    // Leave out project files that are not pages or assets
+	MaxSize           int64    `json:"max_size,omitempty"`            // Bytes of selected files before compression; 0 for no limit
+}
+
+// ManifestListResponse for paginated pages/assets endpoints
+type ManifestListResponse struct {
+	Total  int             `json:"total"`
+	Offset int             `json:"offset"`
+	Limit  int             `json:"limit"`
+	Items  []ManifestEntry `json:"items"`
 }
 
 // ScrapeResponse returned after starting scrape
@@ -75,10 +381,166 @@ type ScrapeResponse struct {
 
 // StatusResponse for status endpoint
 type StatusResponse struct {
-	Status     ProjectStatus `json:"status"`
-	Progress   int           `json:"progress"`
-	Downloaded int           `json:"pages_downloaded"`
-	Total      int           `json:"total_pages"`
-	CurrentURL string        `json:"current_url"`
-	Errors     []string      `json:"errors"`
+	Status     ProjectStatus     `json:"status"`
+	Progress   int               `json:"progress"`
+	Downloaded int               `json:"pages_downloaded"`
+	Total      int               `json:"total_pages"`
+	CurrentURL string            `json:"current_url"`
+	Errors     []string          `json:"errors"`
+	LinkCheck  *LinkCheckSummary `json:"link_check,omitempty"`
+}
+
+// LinkGraph is the site link graph built from the project manifest
+type LinkGraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode is a page (crawled or only linked to) in the link graph
+type GraphNode struct {
+	URL        string `json:"url"`
+	Internal   bool   `json:"internal"`
+	Crawled    bool   `json:"crawled"`
+	Depth      int    `json:"depth,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Inbound    int    `json:"inbound_links"`  // Distinct pages linking here
+	Outbound   int    `json:"outbound_links"` // Distinct targets linked from here
+	Orphan     bool   `json:"orphan"`         // Crawled page no other page links to
+}
+
+// GraphEdge is a link between two pages; repeated links are counted
+type GraphEdge struct {
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+	Text     string `json:"text,omitempty"`
+	Rel      string `json:"rel,omitempty"`
+	Internal bool   `json:"internal"`
+	Count    int    `json:"count"`
+}
+
+// LinkCheckSummary is the link check overview shown in project status
+type LinkCheckSummary struct {
+	Checked        int `json:"checked"`
+	Broken         int `json:"broken"`
+	Internal       int `json:"internal"`
+	External       int `json:"external"`
+	BrokenInternal int `json:"broken_internal"`
+	BrokenExternal int `json:"broken_external"`
+}
+
+// LinkReferrer is a page linking to a checked URL
+type LinkReferrer struct {
+	PageURL string `json:"page_url"`
+	Text    string `json:"text,omitempty"` // Anchor text
+}
+
+// LinkCheckResult is the HTTP result of a single link target
+type LinkCheckResult struct {
+	URL        string         `json:"url"`
+	StatusCode int            `json:"status_code,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	Internal   bool           `json:"internal"`
+	Broken     bool           `json:"broken"`
+	Method     string         `json:"method"` // "crawl", "HEAD" or "GET"
+	Referrers  []LinkReferrer `json:"referrers"`
+}
+
+// LinkCheckReport is persisted as linkcheck.json in the project directory
+type LinkCheckReport struct {
+	CheckedAt time.Time         `json:"checked_at"`
+	Summary   LinkCheckSummary  `json:"summary"`
+	Results   []LinkCheckResult `json:"results"`
+}
+
+// SearchResult is a ranked page matching a full-text query
+type SearchResult struct {
+	ProjectID string  `json:"project_id,omitempty"` // Set by cross-project search
+	URL       string  `json:"url"`
+	Title     string  `json:"title,omitempty"`
+	LocalPath string  `json:"local_path"`
+	Score     float64 `json:"score"`
+	Snippet   string  `json:"snippet"` // HTML-escaped, matches wrapped in <mark>
+}
+
+// SearchResponse for search endpoints
+type SearchResponse struct {
+	Query   string         `json:"query"`
+	Total   int            `json:"total"`
+	Results []SearchResult `json:"results"`
+}
+
+// PDF chapter orders
+const (
+	ChapterOrderCrawl = "crawl" // Crawl tree, parent before children
+	ChapterOrderPath  = "path"  // Sorted by URL path
+)
+
+// PDFOptions controls the consolidated PDF export
+type PDFOptions struct {
+	ChapterOrder    string           `json:"chapter_order,omitempty"`    // "crawl" (default) or "path"
+	PageSize        string           `json:"page_size,omitempty"`        // A3, A4 (default), A5, Letter or Legal
+	Orientation     string           `json:"orientation,omitempty"`      // "portrait" (default) or "landscape"
+	Margins         *PDFMargins      `json:"margins,omitempty"`          // Default 20mm on every side
+	FontSize        float64          `json:"font_size,omitempty"`        // Body text in pt (default 11)
+	ExcludeImages   bool             `json:"exclude_images,omitempty"`   // Show alt text instead of images
+	IncludePatterns []string         `json:"include_patterns,omitempty"` // Regex on page URL; empty includes every page
+	ExcludePatterns []string         `json:"exclude_patterns,omitempty"` // Regex on page URL, applied after includes
+	Cover           *PDFCover        `json:"cover,omitempty"`
+	Header          *PDFPageTemplate `json:"header,omitempty"`
+	Footer          *PDFPageTemplate `json:"footer,omitempty"`
+}
+
+// PDFMargins are page margins in mm
+type PDFMargins struct {
+	Top    float64 `json:"top"`
+	Right  float64 `json:"right"`
+	Bottom float64 `json:"bottom"`
+	Left   float64 `json:"left"`
+}
+
+// PDFCover adds a title page before the table of contents
+type PDFCover struct {
+	Title    string `json:"title,omitempty"` // Default: title of the start page
+	Subtitle string `json:"subtitle,omitempty"`
+	Logo     string `json:"logo,omitempty"` // Downloaded image: URL or path relative to the project
+}
+
+// PDFPageTemplate is the text of a header or footer line. Placeholders:
+// {url} and {title} of the chapter, {date} it was captured, {page}, {pages}.
+type PDFPageTemplate struct {
+	Left   string `json:"left,omitempty"`
+	Center string `json:"center,omitempty"`
+	Right  string `json:"right,omitempty"`
+}
+
+// PDFPresetsResponse lists saved PDF option presets of a project by name
+type PDFPresetsResponse struct {
+	Presets map[string]PDFOptions `json:"presets"`
+}
+
+// ExportJobStatus represents export job execution state
+type ExportJobStatus string
+
+const (
+	ExportQueued    ExportJobStatus = "queued"
+	ExportRunning   ExportJobStatus = "running"
+	ExportCompleted ExportJobStatus = "completed"
+	ExportFailed    ExportJobStatus = "failed"
+)
+
+// ExportJob is a background export and its cached artifact. The ID is derived
+// from the format and options, so equal requests share one artifact.
+type ExportJob struct {
+	ID          string          `json:"job_id"`
+	ProjectID   string          `json:"project_id"`
+	Format      string          `json:"format"`
+	Status      ExportJobStatus `json:"status"`
+	Progress    int             `json:"progress"`         // 0-100
+	Cached      bool            `json:"cached"`           // Artifact reused from an earlier job
+	Size        int64           `json:"size,omitempty"`   // Artifact bytes
+	SHA256      string          `json:"sha256,omitempty"` // Artifact checksum, also the download ETag
+	Error       string          `json:"error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+	DownloadURL string          `json:"download_url,omitempty"`
 }
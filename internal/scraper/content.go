@@ -0,0 +1,323 @@
+package scraper
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"github.com/user/scrapper/internal/models"
+	xhtml "golang.org/x/net/html"
+)
+
+// ContentSuffix replaces ".html" in the name of a page's extracted content file
+const ContentSuffix = ".content.html"
+
+// compiledContentSelector is a ContentSelector ready for matching
+type compiledContentSelector struct {
+	urlPattern *regexp.Regexp
+	selector   cascadia.Selector
+}
+
+// Readability-style class/id hints
+var (
+	unlikelyCandidate = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|consent|cookie|disqus|extra|foot|gdpr|header|legends|menu|modal|nav|newsletter|pager|pagination|popup|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|supplemental|ad-break|agegate`)
+	maybeCandidate    = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	positiveHint      = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|post|text|blog|story`)
+	negativeHint      = regexp.MustCompile(`(?i)-ad-|hidden|banner|combx|comment|com-|contact|cookie|foot|footnote|gdpr|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget`)
+)
+
+// boilerplateSelector matches elements never part of the main content
+const boilerplateSelector = "script, style, noscript, template, iframe, form, button, input, select, textarea, svg, " +
+	"[hidden], [aria-hidden=true], [role=navigation], [role=banner], [role=contentinfo], [role=dialog]"
+
+// ValidateContentOptions checks content extraction options from a scrape request
+func ValidateContentOptions(opts *models.ContentOptions) error {
+	if opts == nil {
+		return nil
+	}
+	_, err := compileContentSelectors(opts)
+	return err
+}
+
+// compileContentSelectors prepares per-URL content selectors
+func compileContentSelectors(opts *models.ContentOptions) ([]compiledContentSelector, error) {
+	if opts == nil || !opts.Enabled {
+		return nil, nil
+	}
+
+	compiled := make([]compiledContentSelector, 0, len(opts.Selectors))
+	for i, rule := range opts.Selectors {
+		c := compiledContentSelector{}
+		if rule.URLPattern != "" {
+			re, err := regexp.Compile(rule.URLPattern)
+			if err != nil {
+				return nil, fmt.Errorf("content selector %d: invalid url_pattern: %v", i, err)
+			}
+			c.urlPattern = re
+		}
+		sel, err := cascadia.Compile(rule.Selector)
+		if err != nil || rule.Selector == "" {
+			return nil, fmt.Errorf("content selector %d: invalid CSS selector %q", i, rule.Selector)
+		}
+		c.selector = sel
+		compiled = append(compiled, c)
+	}
+
+	return compiled, nil
+}
+
+// ExtractMainContent returns the HTML of the main content of a page: the first
+// matching selector, or else the best-scoring block found by the heuristics
+func ExtractMainContent(doc *goquery.Document, pageURL string, selectors []compiledContentSelector) string {
+	for _, rule := range selectors {
+		if rule.urlPattern != nil && !rule.urlPattern.MatchString(pageURL) {
+			continue
+		}
+		if match := doc.FindMatcher(rule.selector); match.Length() > 0 {
+			match.Find(boilerplateSelector).Remove()
+			return outerHTML(match.Nodes)
+		}
+	}
+
+	return readability(doc)
+}
+
+// readability scores paragraph containers by text length, commas, class hints
+// and link density, and keeps the top candidate with related siblings
+func readability(doc *goquery.Document) string {
+	body := doc.Find("body")
+	if body.Length() == 0 {
+		body = doc.Selection
+	}
+
+	body.Find(boilerplateSelector).Remove()
+
+	// Page chrome outside of article/main
+	body.Find("nav, aside, header, footer").Each(func(_ int, sel *goquery.Selection) {
+		if sel.Closest("article, main").Length() == 0 {
+			sel.Remove()
+		}
+	})
+
+	// Elements whose class/id look like boilerplate
+	body.Find("*").Each(func(_ int, sel *goquery.Selection) {
+		switch goquery.NodeName(sel) {
+		case "body", "article", "main", "a":
+			return
+		}
+		hints := sel.AttrOr("class", "") + " " + sel.AttrOr("id", "")
+		if unlikelyCandidate.MatchString(hints) && !maybeCandidate.MatchString(hints) {
+			sel.Remove()
+		}
+	})
+
+	scores := make(map[*xhtml.Node]float64)
+	var candidates []*xhtml.Node
+
+	body.Find("p, pre, td, blockquote").Each(func(_ int, sel *goquery.Selection) {
+		text := cleanText(sel.Text())
+		if len(text) < 25 {
+			return
+		}
+
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text)/100), 3)
+
+		// Parent gets the full score, grandparent half, great-grandparent a third
+		node := sel.Nodes[0].Parent
+		for level := 1; level <= 3 && node != nil && node.Type == xhtml.ElementNode; level++ {
+			if _, seen := scores[node]; !seen {
+				scores[node] = initialScore(node)
+				candidates = append(candidates, node)
+			}
+			scores[node] += score / float64(level)
+			node = node.Parent
+		}
+	})
+
+	var top *xhtml.Node
+	for _, node := range candidates {
+		sel := goquery.NewDocumentFromNode(node).Selection
+		scores[node] *= 1 - linkDensity(sel)
+		if top == nil || scores[node] > scores[top] {
+			top = node
+		}
+	}
+
+	if top == nil || top.Data == "body" || top.Data == "html" {
+		return outerHTML(body.Children().Nodes)
+	}
+
+	// Siblings that score close to the winner or read like prose
+	threshold := math.Max(10, scores[top]*0.2)
+	var parts []*xhtml.Node
+	for sibling := top.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+		if sibling.Type != xhtml.ElementNode {
+			continue
+		}
+		if sibling == top {
+			parts = append(parts, sibling)
+			continue
+		}
+		if score, ok := scores[sibling]; ok && score >= threshold {
+			parts = append(parts, sibling)
+			continue
+		}
+		if sibling.Data == "p" {
+			sel := goquery.NewDocumentFromNode(sibling).Selection
+			text := cleanText(sel.Text())
+			if len(text) > 80 && linkDensity(sel) < 0.25 {
+				parts = append(parts, sibling)
+			}
+		}
+	}
+
+	return outerHTML(parts)
+}
+
+// initialScore weights a candidate by tag and class/id hints
+func initialScore(node *xhtml.Node) float64 {
+	score := 0.0
+	switch node.Data {
+	case "article", "main":
+		score += 10
+	case "div":
+		score += 5
+	case "pre", "td", "blockquote":
+		score += 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		score -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score -= 5
+	}
+
+	for _, attr := range node.Attr {
+		if attr.Key != "class" && attr.Key != "id" {
+			continue
+		}
+		if negativeHint.MatchString(attr.Val) {
+			score -= 25
+		}
+		if positiveHint.MatchString(attr.Val) {
+			score += 25
+		}
+	}
+
+	return score
+}
+
+// linkDensity is the share of a selection's text inside links
+func linkDensity(sel *goquery.Selection) float64 {
+	textLength := len(cleanText(sel.Text()))
+	if textLength == 0 {
+		return 0
+	}
+
+	linkLength := 0
+	sel.Find("a").Each(func(_ int, a *goquery.Selection) {
+		linkLength += len(cleanText(a.Text()))
+	})
+
+	return float64(linkLength) / float64(textLength)
+}
+
+// outerHTML renders nodes without presentational attributes
+func outerHTML(nodes []*xhtml.Node) string {
+	var b strings.Builder
+	for _, node := range nodes {
+		sel := goquery.NewDocumentFromNode(node).Selection
+		sel.Find("[style], [class]").AddSelection(sel).RemoveAttr("style").RemoveAttr("class")
+		if err := xhtml.Render(&b, node); err == nil {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// contentPath returns where a page's extracted content is saved
+func contentPath(localPath string) string {
+	return strings.TrimSuffix(localPath, ".html") + ContentSuffix
+}
+
+// extractContent saves the main content of every saved page next to it
+func (s *Scraper) extractContent() error {
+	if s.Project.Content == nil || !s.Project.Content.Enabled {
+		return nil
+	}
+
+	s.mu.RLock()
+	pages := make([]*models.Page, 0, len(s.Pages))
+	for _, page := range s.Pages {
+		if page.Error == "" && page.LocalPath != "" {
+			pages = append(pages, page)
+		}
+	}
+	s.mu.RUnlock()
+
+	var failed int
+	for _, page := range pages {
+		if err := s.extractPageContent(page); err != nil {
+			failed++
+			s.mu.Lock()
+			s.Project.Errors = append(s.Project.Errors, fmt.Sprintf("Content extraction failed for %s: %v", page.URL, err))
+			s.mu.Unlock()
+			continue
+		}
+		s.recordPage(page)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d pages failed", failed)
+	}
+	return nil
+}
+
+// extractPageContent writes the clean content document of one page
+func (s *Scraper) extractPageContent(page *models.Page) error {
+	file, err := os.Open(page.LocalPath)
+	if err != nil {
+		return err
+	}
+	doc, err := goquery.NewDocumentFromReader(file)
+	file.Close()
+	if err != nil {
+		return err
+	}
+
+	title, lang := "", ""
+	if page.Metadata != nil {
+		title, lang = page.Metadata.Title, page.Metadata.Language
+	}
+
+	// Keep stylesheet-free head: charset, title and language only
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n")
+	if lang != "" {
+		fmt.Fprintf(&b, "<html lang=\"%s\">\n", html.EscapeString(lang))
+	} else {
+		b.WriteString("<html>\n")
+	}
+	fmt.Fprintf(&b, "<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n", html.EscapeString(title))
+	content := ExtractMainContent(doc, page.URL, s.contentSelectors)
+	if !strings.HasPrefix(content, "<article") {
+		content = "<article>\n" + content + "</article>\n"
+	}
+	b.WriteString("<body>\n")
+	b.WriteString(content)
+	b.WriteString("</body>\n</html>\n")
+
+	path := contentPath(page.LocalPath)
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	page.ContentPath = path
+	s.mu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,260 @@
+package scraper
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
+	"github.com/user/scrapper/internal/models"
+	"golang.org/x/net/html"
+)
+
+// DatasetFileName is the per-project extraction output
+const DatasetFileName = "dataset.jsonl"
+
+// compiledSchema is an ExtractionSchema with its URL pattern compiled
+type compiledSchema struct {
+	models.ExtractionSchema
+	urlPattern *regexp.Regexp
+}
+
+// ValidateExtractionSchemas checks extraction schemas from a scrape request
+func ValidateExtractionSchemas(schemas []models.ExtractionSchema) error {
+	names := make(map[string]bool)
+
+	for i, schema := range schemas {
+		if schema.Name == "" {
+			return fmt.Errorf("schema %d: name is empty", i)
+		}
+		if names[schema.Name] {
+			return fmt.Errorf("schema %d: duplicate name %q", i, schema.Name)
+		}
+		names[schema.Name] = true
+
+		if schema.URLPattern != "" {
+			if _, err := regexp.Compile(schema.URLPattern); err != nil {
+				return fmt.Errorf("schema %q: invalid url_pattern: %v", schema.Name, err)
+			}
+		}
+		if schema.ItemSelector != "" {
+			if err := validateSelector(schema.ItemSelector, schema.SelectorType); err != nil {
+				return fmt.Errorf("schema %q: item_selector: %v", schema.Name, err)
+			}
+		}
+		if len(schema.Fields) == 0 {
+			return fmt.Errorf("schema %q: no fields", schema.Name)
+		}
+
+		fieldNames := make(map[string]bool)
+		for j, field := range schema.Fields {
+			if field.Name == "" {
+				return fmt.Errorf("schema %q: field %d: name is empty", schema.Name, j)
+			}
+			if fieldNames[field.Name] {
+				return fmt.Errorf("schema %q: duplicate field %q", schema.Name, field.Name)
+			}
+			fieldNames[field.Name] = true
+
+			if err := validateSelector(field.Selector, field.SelectorType); err != nil {
+				return fmt.Errorf("schema %q: field %q: %v", schema.Name, field.Name, err)
+			}
+			switch field.Extract {
+			case "", models.ExtractText, models.ExtractHTML:
+			case models.ExtractAttr:
+				if field.Attr == "" {
+					return fmt.Errorf("schema %q: field %q: attr is required for extract=attr", schema.Name, field.Name)
+				}
+			default:
+				return fmt.Errorf("schema %q: field %q: extract must be text, html or attr", schema.Name, field.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateSelector checks that a CSS or XPath selector compiles
+func validateSelector(selector, selectorType string) error {
+	if selector == "" {
+		return fmt.Errorf("selector is empty")
+	}
+
+	switch selectorType {
+	case "", models.SelectorCSS:
+		if _, err := cascadia.Compile(selector); err != nil {
+			return fmt.Errorf("invalid CSS selector: %v", err)
+		}
+	case models.SelectorXPath:
+		if _, err := xpath.Compile(selector); err != nil {
+			return fmt.Errorf("invalid XPath: %v", err)
+		}
+	default:
+		return fmt.Errorf("selector_type must be css or xpath")
+	}
+
+	return nil
+}
+
+// compileSchemas prepares validated schemas for use during the crawl
+func compileSchemas(schemas []models.ExtractionSchema) ([]compiledSchema, error) {
+	compiled := make([]compiledSchema, 0, len(schemas))
+
+	for _, schema := range schemas {
+		c := compiledSchema{ExtractionSchema: schema}
+		if schema.URLPattern != "" {
+			re, err := regexp.Compile(schema.URLPattern)
+			if err != nil {
+				return nil, fmt.Errorf("schema %q: %w", schema.Name, err)
+			}
+			c.urlPattern = re
+		}
+		compiled = append(compiled, c)
+	}
+
+	return compiled, nil
+}
+
+// ExtractRecords applies a schema to a parsed page and returns its records
+func ExtractRecords(schema models.ExtractionSchema, doc *html.Node, pageURL string) []models.DatasetRecord {
+	containers := []*html.Node{doc}
+	if schema.ItemSelector != "" {
+		containers = selectNodes(doc, schema.ItemSelector, schema.SelectorType)
+	}
+
+	records := make([]models.DatasetRecord, 0, len(containers))
+	now := time.Now()
+
+	for i, container := range containers {
+		fields := make(map[string]any, len(schema.Fields))
+		found := false
+
+		for _, field := range schema.Fields {
+			nodes := selectNodes(container, field.Selector, field.SelectorType)
+			if len(nodes) > 0 {
+				found = true
+			}
+
+			if field.Multiple {
+				values := make([]string, 0, len(nodes))
+				for _, node := range nodes {
+					values = append(values, extractValue(node, field))
+				}
+				fields[field.Name] = values
+				continue
+			}
+
+			if len(nodes) == 0 {
+				fields[field.Name] = ""
+				continue
+			}
+			fields[field.Name] = extractValue(nodes[0], field)
+		}
+
+		// Skip pages/items where no field matched at all
+		if !found {
+			continue
+		}
+
+		records = append(records, models.DatasetRecord{
+			Schema:      schema.Name,
+			URL:         pageURL,
+			Index:       i,
+			ExtractedAt: now,
+			Fields:      fields,
+		})
+	}
+
+	return records
+}
+
+// selectNodes runs a CSS or XPath selector below root; invalid selectors match nothing
+func selectNodes(root *html.Node, selector, selectorType string) []*html.Node {
+	if selectorType == models.SelectorXPath {
+		nodes, err := htmlquery.QueryAll(root, selector)
+		if err != nil {
+			return nil
+		}
+		return nodes
+	}
+
+	return goquery.NewDocumentFromNode(root).Find(selector).Nodes
+}
+
+// extractValue returns the text, inner HTML or attribute of a node
+func extractValue(node *html.Node, field models.ExtractionField) string {
+	switch field.Extract {
+	case models.ExtractHTML:
+		inner, err := goquery.NewDocumentFromNode(node).Html()
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(inner)
+	case models.ExtractAttr:
+		return htmlquery.SelectAttr(node, field.Attr)
+	default:
+		return strings.Join(strings.Fields(htmlquery.InnerText(node)), " ")
+	}
+}
+
+// extractData runs all schemas matching the page URL and appends records to the dataset
+func (s *Scraper) extractData(doc *html.Node, pageURL string) {
+	if s.dataset == nil {
+		return
+	}
+
+	for _, schema := range s.schemas {
+		if schema.urlPattern != nil && !schema.urlPattern.MatchString(pageURL) {
+			continue
+		}
+
+		for _, record := range ExtractRecords(schema.ExtractionSchema, doc, pageURL) {
+			if err := s.dataset.append(record); err != nil {
+				s.mu.Lock()
+				s.Project.Errors = append(s.Project.Errors, fmt.Sprintf("Failed to write dataset record for %s: %v", pageURL, err))
+				s.mu.Unlock()
+				return
+			}
+		}
+	}
+}
+
+// LoadDataset reads dataset.jsonl, optionally limited to one schema.
+// A missing dataset yields an empty list.
+func LoadDataset(projectID, dataDir, schemaName string) ([]models.DatasetRecord, error) {
+	datasetPath := filepath.Join(dataDir, projectID, DatasetFileName)
+
+	file, err := os.Open(datasetPath)
+	if os.IsNotExist(err) {
+		return []models.DatasetRecord{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records := make([]models.DatasetRecord, 0)
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var record models.DatasetRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue // Partially written line
+		}
+		if schemaName != "" && record.Schema != schemaName {
+			continue
+		}
+		records = append(records, record)
+	}
+
+	return records, scanner.Err()
+}
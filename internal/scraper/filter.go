@@ -61,12 +61,16 @@ func (s *Scraper) ApplyFiltersToProject() error {
 	}
 
 	for _, page := range s.Pages {
-		if err := s.applyFiltersToPage(page); err != nil {
+		err := s.applyFiltersToPage(page)
+
+		s.mu.Lock()
+		if err != nil {
 			// Log error but continue
 			page.Error = fmt.Sprintf("Filter application failed: %v", err)
-			continue
+		} else {
+			page.Filtered = true
 		}
-		page.Filtered = true
+		s.mu.Unlock()
 	}
 
 	// Save filter configuration
@@ -0,0 +1,230 @@
+package scraper
+
+import (
+	"container/heap"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/user/scrapper/internal/models"
+)
+
+// frontierItem is a discovered URL waiting to be fetched
+type frontierItem struct {
+	URL    string
+	Depth  int
+	Parent string
+	Score  int
+	seq    int64 // Discovery order
+}
+
+// frontier is the crawl queue owned by the scraper. Items are handed out in
+// strategy order; it blocks workers until work is available or the crawl ends.
+type frontier struct {
+	items    []*frontierItem
+	strategy models.CrawlStrategy
+	rules    []compiledPriorityRule
+	seen     map[string]bool
+	seq      int64
+	inFlight int
+	started  int // Items handed out so far
+	maxItems int // 0 = unlimited
+	deadline time.Time
+	stopped  string // Reason the frontier stopped handing out items
+	mu       sync.Mutex
+	cond     *sync.Cond
+}
+
+// compiledPriorityRule is a PriorityRule with its pattern compiled
+type compiledPriorityRule struct {
+	re    *regexp.Regexp
+	score int
+}
+
+// newFrontier creates an empty frontier for the given project settings
+func newFrontier(project *models.Project) (*frontier, error) {
+	f := &frontier{
+		strategy: project.Strategy,
+		seen:     make(map[string]bool),
+		maxItems: project.MaxPages,
+	}
+	f.cond = sync.NewCond(&f.mu)
+
+	if f.strategy == "" {
+		f.strategy = models.StrategyBreadthFirst
+	}
+
+	for i, rule := range project.PriorityRules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("priority rule %d: %w", i, err)
+		}
+		f.rules = append(f.rules, compiledPriorityRule{re: re, score: rule.Score})
+	}
+
+	if project.TimeLimitSeconds > 0 {
+		f.deadline = time.Now().Add(time.Duration(project.TimeLimitSeconds) * time.Second)
+	}
+
+	return f, nil
+}
+
+// score sums the scores of all priority rules matching the URL
+func (f *frontier) score(rawURL string) int {
+	total := 0
+	for _, rule := range f.rules {
+		if rule.re.MatchString(rawURL) {
+			total += rule.score
+		}
+	}
+	return total
+}
+
+// push adds a URL unless it was already discovered; returns false for duplicates
+func (f *frontier) push(rawURL string, depth int, parent string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.seen[rawURL] {
+		return false
+	}
+	f.seen[rawURL] = true
+
+	f.seq++
+	heap.Push(f, &frontierItem{
+		URL:    rawURL,
+		Depth:  depth,
+		Parent: parent,
+		Score:  f.score(rawURL),
+		seq:    f.seq,
+	})
+	f.cond.Signal()
+
+	return true
+}
+
+// next blocks until an item is available. It returns nil once the queue is
+// drained with nothing in flight, or when the page budget or time limit is hit.
+func (f *frontier) next() *frontierItem {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for {
+		if f.stopped != "" {
+			return nil
+		}
+		if f.maxItems > 0 && f.started >= f.maxItems {
+			f.stop(fmt.Sprintf("page budget of %d reached", f.maxItems))
+			return nil
+		}
+		if !f.deadline.IsZero() && time.Now().After(f.deadline) {
+			f.stop("time limit reached")
+			return nil
+		}
+		if len(f.items) > 0 {
+			break
+		}
+		if f.inFlight == 0 {
+			return nil
+		}
+		f.cond.Wait()
+	}
+
+	item := heap.Pop(f).(*frontierItem)
+	f.inFlight++
+	f.started++
+
+	return item
+}
+
+// done marks an item handed out by next as finished
+func (f *frontier) done() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.inFlight--
+	f.cond.Broadcast()
+}
+
+// stop ends the crawl early; caller must hold f.mu
+func (f *frontier) stop(reason string) {
+	f.stopped = reason
+	f.cond.Broadcast()
+}
+
+// stats returns discovered URL count, pending queue length and stop reason
+func (f *frontier) stats() (discovered, pending int, stopped string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.seen), len(f.items), f.stopped
+}
+
+// heap.Interface implementation; callers must hold f.mu
+
+func (f *frontier) Len() int { return len(f.items) }
+
+func (f *frontier) Less(i, j int) bool {
+	a, b := f.items[i], f.items[j]
+
+	switch f.strategy {
+	case models.StrategyDepthFirst:
+		// Most recently discovered first (LIFO)
+		return a.seq > b.seq
+	case models.StrategyPriority:
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Depth != b.Depth {
+			return a.Depth < b.Depth
+		}
+		return a.seq < b.seq
+	default:
+		// Breadth-first: shallowest first, then discovery order
+		if a.Depth != b.Depth {
+			return a.Depth < b.Depth
+		}
+		return a.seq < b.seq
+	}
+}
+
+func (f *frontier) Swap(i, j int) { f.items[i], f.items[j] = f.items[j], f.items[i] }
+
+func (f *frontier) Push(x any) { f.items = append(f.items, x.(*frontierItem)) }
+
+func (f *frontier) Pop() any {
+	old := f.items
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	f.items = old[:n-1]
+	return item
+}
+
+// ValidateCrawlOptions checks strategy, priority rules and crawl budgets
+func ValidateCrawlOptions(strategy models.CrawlStrategy, rules []models.PriorityRule, maxPages, timeLimitSeconds int) error {
+	switch strategy {
+	case "", models.StrategyBreadthFirst, models.StrategyDepthFirst, models.StrategyPriority:
+	default:
+		return fmt.Errorf("strategy must be one of: bfs, dfs, priority")
+	}
+
+	for i, rule := range rules {
+		if rule.Pattern == "" {
+			return fmt.Errorf("priority rule %d: pattern is empty", i)
+		}
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("priority rule %d: invalid pattern: %v", i, err)
+		}
+	}
+
+	if maxPages < 0 {
+		return fmt.Errorf("max_pages cannot be negative")
+	}
+	if timeLimitSeconds < 0 {
+		return fmt.Errorf("time_limit_seconds cannot be negative")
+	}
+
+	return nil
+}
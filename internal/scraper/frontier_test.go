@@ -0,0 +1,216 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/scrapper/internal/models"
+)
+
+// testFrontier creates a frontier for the given project settings
+func testFrontier(t *testing.T, project *models.Project) *frontier {
+	t.Helper()
+	f, err := newFrontier(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// pushSite adds a small site in discovery order: the root, two sections
+// found on the root, then pages found in the first section
+func pushSite(f *frontier) {
+	f.push("https://example.com/", 0, "")
+	f.push("https://example.com/docs", 1, "https://example.com/")
+	f.push("https://example.com/blog", 1, "https://example.com/")
+	f.push("https://example.com/docs/install", 2, "https://example.com/docs")
+	f.push("https://example.com/docs/faq", 2, "https://example.com/docs")
+}
+
+// drain pops every item, finishing each before taking the next
+func drain(f *frontier) []string {
+	var urls []string
+	for item := f.next(); item != nil; item = f.next() {
+		urls = append(urls, item.URL)
+		f.done()
+	}
+	return urls
+}
+
+func TestFrontierOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		project models.Project
+		want    []string
+	}{
+		{
+			name:    "default is breadth-first",
+			project: models.Project{},
+			want:    []string{"/", "/docs", "/blog", "/docs/install", "/docs/faq"},
+		},
+		{
+			name:    "breadth-first",
+			project: models.Project{Strategy: models.StrategyBreadthFirst},
+			want:    []string{"/", "/docs", "/blog", "/docs/install", "/docs/faq"},
+		},
+		{
+			name:    "depth-first",
+			project: models.Project{Strategy: models.StrategyDepthFirst},
+			want:    []string{"/docs/faq", "/docs/install", "/blog", "/docs", "/"},
+		},
+		{
+			name: "priority",
+			project: models.Project{
+				Strategy: models.StrategyPriority,
+				PriorityRules: []models.PriorityRule{
+					{Pattern: `/docs`, Score: 5},
+					{Pattern: `/faq$`, Score: 10},
+					{Pattern: `/install$`, Score: -10},
+				},
+			},
+			// faq 15, docs 5, then ties by depth and discovery order
+			want: []string{"/docs/faq", "/docs", "/", "/blog", "/docs/install"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := testFrontier(t, &tt.project)
+			pushSite(f)
+
+			got := drain(f)
+			for i := range got {
+				got[i] = strings.TrimPrefix(got[i], "https://example.com")
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+			if _, _, stopped := f.stats(); stopped != "" {
+				t.Errorf("drained frontier stopped with %q", stopped)
+			}
+		})
+	}
+}
+
+func TestFrontierSkipsDuplicates(t *testing.T) {
+	f := testFrontier(t, &models.Project{})
+	if !f.push("https://example.com/", 0, "") {
+		t.Fatal("first push was rejected")
+	}
+	if f.push("https://example.com/", 1, "https://example.com/other") {
+		t.Error("duplicate URL was queued")
+	}
+
+	discovered, pending, _ := f.stats()
+	if discovered != 1 || pending != 1 {
+		t.Errorf("discovered %d, pending %d, want 1 and 1", discovered, pending)
+	}
+}
+
+func TestFrontierPageBudget(t *testing.T) {
+	f := testFrontier(t, &models.Project{MaxPages: 2})
+	pushSite(f)
+
+	if got := drain(f); len(got) != 2 {
+		t.Errorf("handed out %v, want 2 pages", got)
+	}
+	_, pending, stopped := f.stats()
+	if stopped != "page budget of 2 reached" {
+		t.Errorf("stop reason = %q", stopped)
+	}
+	if pending != 3 {
+		t.Errorf("pending = %d, want 3", pending)
+	}
+
+	// Once stopped, new URLs are never handed out
+	f.push("https://example.com/late", 1, "https://example.com/")
+	if item := f.next(); item != nil {
+		t.Errorf("stopped frontier handed out %s", item.URL)
+	}
+}
+
+func TestFrontierTimeLimit(t *testing.T) {
+	f := testFrontier(t, &models.Project{TimeLimitSeconds: 60})
+	if f.deadline.IsZero() {
+		t.Fatal("time limit did not set a deadline")
+	}
+	pushSite(f)
+
+	if item := f.next(); item == nil {
+		t.Fatal("frontier stopped before its deadline")
+	}
+	f.done()
+
+	f.mu.Lock()
+	f.deadline = time.Now().Add(-time.Second)
+	f.mu.Unlock()
+
+	if item := f.next(); item != nil {
+		t.Errorf("frontier handed out %s after its deadline", item.URL)
+	}
+	if _, _, stopped := f.stats(); stopped != "time limit reached" {
+		t.Errorf("stop reason = %q", stopped)
+	}
+}
+
+func TestFrontierWaitsForInFlightItems(t *testing.T) {
+	f := testFrontier(t, &models.Project{})
+	f.push("https://example.com/", 0, "")
+	root := f.next()
+
+	// The queue is empty but the root may still discover links
+	next := make(chan *frontierItem)
+	go func() { next <- f.next() }()
+
+	f.push("https://example.com/docs", 1, root.URL)
+	f.done()
+
+	select {
+	case item := <-next:
+		if item == nil || item.URL != "https://example.com/docs" {
+			t.Fatalf("waiting worker got %+v", item)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("waiting worker was not woken")
+	}
+	f.done()
+
+	if item := f.next(); item != nil {
+		t.Errorf("drained frontier handed out %s", item.URL)
+	}
+}
+
+func TestValidateCrawlOptions(t *testing.T) {
+	tests := []struct {
+		name      string
+		strategy  models.CrawlStrategy
+		rules     []models.PriorityRule
+		maxPages  int
+		timeLimit int
+		wantErr   string
+	}{
+		{name: "defaults"},
+		{name: "priority", strategy: models.StrategyPriority, rules: []models.PriorityRule{{Pattern: `^https://example\.com/docs`, Score: 3}}, maxPages: 100, timeLimit: 60},
+		{name: "unknown strategy", strategy: "random", wantErr: "strategy must be one of"},
+		{name: "empty pattern", rules: []models.PriorityRule{{Score: 1}}, wantErr: "priority rule 0: pattern is empty"},
+		{name: "invalid pattern", rules: []models.PriorityRule{{Pattern: "ok"}, {Pattern: "(", Score: 1}}, wantErr: "priority rule 1: invalid pattern"},
+		{name: "negative budget", maxPages: -1, wantErr: "max_pages cannot be negative"},
+		{name: "negative time limit", timeLimit: -1, wantErr: "time_limit_seconds cannot be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCrawlOptions(tt.strategy, tt.rules, tt.maxPages, tt.timeLimit)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/user/scrapper/internal/models"
+)
+
+// LinkCheckFileName is the per-project link check report
+const LinkCheckFileName = "linkcheck.json"
+
+// Link check defaults
+const (
+	defaultExternalParallelism = 2
+	defaultExternalDelay       = 500 * time.Millisecond
+	defaultLinkCheckTimeout    = 10 * time.Second
+	internalCheckParallelism   = 2
+)
+
+// ValidateLinkCheckOptions checks link check settings from a scrape request
+func ValidateLinkCheckOptions(opts *models.LinkCheckOptions) error {
+	if opts == nil {
+		return nil
+	}
+	if opts.ExternalParallelism < 0 || opts.ExternalParallelism > 10 {
+		return fmt.Errorf("external_parallelism must be between 1 and 10, or 0 for the default")
+	}
+	if opts.ExternalDelayMs < 0 || opts.ExternalDelayMs > 60000 {
+		return fmt.Errorf("external_delay_ms must be between 0 and 60000")
+	}
+	if opts.TimeoutSeconds < 0 || opts.TimeoutSeconds > 60 {
+		return fmt.Errorf("timeout_seconds must be between 1 and 60, or 0 for the default")
+	}
+	return nil
+}
+
+// CheckLinks builds the link check report from the manifest.
+// Internal links reuse the crawl result when the target was fetched and are
+// HEAD-checked otherwise; external links are HEAD-checked only if enabled.
+func (s *Scraper) CheckLinks() (*models.LinkCheckReport, error) {
+	opts := s.Project.LinkCheck
+	if opts == nil || !opts.Enabled {
+		return nil, nil
+	}
+
+	entries, err := LoadManifest(s.Project.ID, s.DataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	crawled := make(map[string]models.ManifestEntry)
+	for _, entry := range entries {
+		if entry.Kind == models.ManifestKindPage {
+			crawled[entry.URL] = entry
+		}
+	}
+
+	// Collect link targets with their referrers, in discovery order
+	results := make([]*models.LinkCheckResult, 0)
+	byURL := make(map[string]*models.LinkCheckResult)
+	for _, entry := range entries {
+		if entry.Kind != models.ManifestKindPage {
+			continue
+		}
+		for _, link := range entry.Links {
+			result, exists := byURL[link.URL]
+			if !exists {
+				result = &models.LinkCheckResult{URL: link.URL, Internal: link.Internal}
+				byURL[link.URL] = result
+				results = append(results, result)
+			}
+			result.Referrers = append(result.Referrers, models.LinkReferrer{PageURL: entry.URL, Text: link.Text})
+		}
+	}
+
+	var internalQueue, externalQueue []*models.LinkCheckResult
+	for _, result := range results {
+		if page, ok := crawled[result.URL]; ok {
+			result.Method = "crawl"
+			result.StatusCode = page.StatusCode
+			result.Error = page.Error
+			continue
+		}
+		if result.Internal {
+			internalQueue = append(internalQueue, result)
+		} else if opts.CheckExternal {
+			externalQueue = append(externalQueue, result)
+		}
+	}
+
+	timeout := defaultLinkCheckTimeout
+	if opts.TimeoutSeconds > 0 {
+		timeout = time.Duration(opts.TimeoutSeconds) * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+
+	s.checkURLs(client, internalQueue, internalCheckParallelism, 0)
+
+	parallelism := defaultExternalParallelism
+	if opts.ExternalParallelism > 0 {
+		parallelism = opts.ExternalParallelism
+	}
+	delay := defaultExternalDelay
+	if opts.ExternalDelayMs > 0 {
+		delay = time.Duration(opts.ExternalDelayMs) * time.Millisecond
+	}
+	s.checkURLs(client, externalQueue, parallelism, delay)
+
+	report := &models.LinkCheckReport{
+		CheckedAt: time.Now(),
+		Results:   make([]models.LinkCheckResult, 0, len(results)),
+	}
+	for _, result := range results {
+		if result.Method == "" {
+			continue // External link, not checked
+		}
+		result.Broken = result.Error != "" || result.StatusCode >= 400
+
+		report.Summary.Checked++
+		if result.Internal {
+			report.Summary.Internal++
+		} else {
+			report.Summary.External++
+		}
+		if result.Broken {
+			report.Summary.Broken++
+			if result.Internal {
+				report.Summary.BrokenInternal++
+			} else {
+				report.Summary.BrokenExternal++
+			}
+		}
+		report.Results = append(report.Results, *result)
+	}
+
+	// Broken links first, then by URL
+	sort.SliceStable(report.Results, func(i, j int) bool {
+		if report.Results[i].Broken != report.Results[j].Broken {
+			return report.Results[i].Broken
+		}
+		return report.Results[i].URL < report.Results[j].URL
+	})
+
+	return report, nil
+}
+
+// checkURLs checks queued links with a worker pool; delay is enforced per host
+func (s *Scraper) checkURLs(client *http.Client, queue []*models.LinkCheckResult, parallelism int, delay time.Duration) {
+	if len(queue) == 0 {
+		return
+	}
+
+	jobs := make(chan *models.LinkCheckResult)
+	var wg sync.WaitGroup
+
+	var hostMu sync.Mutex
+	nextSlot := make(map[string]time.Time) // host -> earliest next request
+
+	waitForHost := func(rawURL string) {
+		if delay <= 0 {
+			return
+		}
+		parsedURL, err := url.Parse(rawURL)
+		if err != nil {
+			return
+		}
+
+		hostMu.Lock()
+		now := time.Now()
+		slot := nextSlot[parsedURL.Host]
+		if slot.Before(now) {
+			slot = now
+		}
+		nextSlot[parsedURL.Host] = slot.Add(delay)
+		hostMu.Unlock()
+
+		time.Sleep(time.Until(slot))
+	}
+
+	for i := 0; i < parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for result := range jobs {
+				waitForHost(result.URL)
+				s.checkURL(client, result)
+			}
+		}()
+	}
+
+	for _, result := range queue {
+		jobs <- result
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// checkURL issues a HEAD request, falling back to GET for servers that reject HEAD
+func (s *Scraper) checkURL(client *http.Client, result *models.LinkCheckResult) {
+	statusCode, err := s.requestStatus(client, http.MethodHead, result.URL)
+	result.Method = http.MethodHead
+
+	if err == nil && (statusCode == http.StatusMethodNotAllowed || statusCode == http.StatusNotImplemented) {
+		statusCode, err = s.requestStatus(client, http.MethodGet, result.URL)
+		result.Method = http.MethodGet
+	}
+
+	result.StatusCode = statusCode
+	if err != nil {
+		result.Error = err.Error()
+	}
+}
+
+// requestStatus returns the HTTP status code of a request without reading the body
+func (s *Scraper) requestStatus(client *http.Client, method, rawURL string) (int, error) {
+	req, err := http.NewRequest(method, rawURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", s.Collector.UserAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
+
+// SaveLinkCheckReport writes linkcheck.json to the project directory
+func (s *Scraper) SaveLinkCheckReport(report *models.LinkCheckReport) error {
+	reportPath := filepath.Join(s.DataDir, s.Project.ID, LinkCheckFileName)
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(reportPath, data, 0644)
+}
+
+// LoadLinkCheckReport reads linkcheck.json from the project directory
+func LoadLinkCheckReport(projectID, dataDir string) (*models.LinkCheckReport, error) {
+	reportPath := filepath.Join(dataDir, projectID, LinkCheckFileName)
+
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var report models.LinkCheckReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
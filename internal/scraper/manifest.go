@@ -0,0 +1,178 @@
+package scraper
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/user/scrapper/internal/models"
+)
+
+// ManifestFileName is the per-project manifest written during the crawl
+const ManifestFileName = "manifest.jsonl"
+
+// jsonlWriter appends JSON values to a file, one per line, safe for concurrent use
+type jsonlWriter struct {
+	file *os.File
+	enc  *json.Encoder
+	mu   sync.Mutex
+}
+
+// openJSONL opens (or creates) a JSON Lines file for appending
+func openJSONL(path string) (*jsonlWriter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jsonlWriter{
+		file: file,
+		enc:  json.NewEncoder(file),
+	}, nil
+}
+
+// append writes a single value as one JSON line
+func (j *jsonlWriter) append(v any) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.enc.Encode(v)
+}
+
+// Close closes the underlying file
+func (j *jsonlWriter) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.file.Close()
+}
+
+// Manifest appends page and asset records to manifest.jsonl as they are fetched.
+// Records are never rewritten; a later record for the same URL supersedes earlier ones.
+type Manifest struct {
+	*jsonlWriter
+}
+
+// OpenManifest opens (or creates) the manifest file of a project for appending
+func OpenManifest(projectID, dataDir string) (*Manifest, error) {
+	writer, err := openJSONL(filepath.Join(dataDir, projectID, ManifestFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Manifest{writer}, nil
+}
+
+// Append writes a single entry as one JSON line
+func (m *Manifest) Append(entry models.ManifestEntry) error {
+	return m.append(entry)
+}
+
+// LoadManifest reads manifest.jsonl and returns the latest entry per kind and URL,
+// in the order URLs were first recorded. A missing manifest yields an empty list.
+func LoadManifest(projectID, dataDir string) ([]models.ManifestEntry, error) {
+	manifestPath := filepath.Join(dataDir, projectID, ManifestFileName)
+
+	file, err := os.Open(manifestPath)
+	if os.IsNotExist(err) {
+		return []models.ManifestEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := make([]models.ManifestEntry, 0)
+	index := make(map[string]int) // kind + URL -> position in entries
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry models.ManifestEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// Skip partially written line (crawl still running)
+			continue
+		}
+
+		key := entry.Kind + " " + entry.URL
+		if pos, exists := index[key]; exists {
+			entries[pos] = entry
+			continue
+		}
+		index[key] = len(entries)
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// recordPage appends the current state of a page to the manifest
+func (s *Scraper) recordPage(page *models.Page) {
+	if s.manifest == nil {
+		return
+	}
+
+	s.mu.RLock()
+	entry := models.ManifestEntry{
+		Kind:        models.ManifestKindPage,
+		URL:         page.URL,
+		Depth:       page.Depth,
+		ParentURL:   page.ParentURL,
+		StatusCode:  page.StatusCode,
+		ContentType: page.ContentType,
+		Size:        page.Size,
+		FetchedAt:   page.FetchedAt,
+		Links:       page.Links,
+		Metadata:    page.Metadata,
+		Error:       page.Error,
+	}
+	if page.LocalPath != "" {
+		entry.LocalPath = s.makeRelativePath(page.LocalPath)
+	}
+	if page.ContentPath != "" {
+		entry.ContentPath = s.makeRelativePath(page.ContentPath)
+	}
+	s.mu.RUnlock()
+
+	if err := s.manifest.Append(entry); err != nil {
+		s.mu.Lock()
+		s.Project.Errors = append(s.Project.Errors, fmt.Sprintf("Failed to write manifest entry for %s: %v", page.URL, err))
+		s.mu.Unlock()
+	}
+}
+
+// recordAsset appends the current state of an asset to the manifest
+func (s *Scraper) recordAsset(asset *models.Asset) {
+	if s.manifest == nil {
+		return
+	}
+
+	s.mu.RLock()
+	entry := models.ManifestEntry{
+		Kind:        models.ManifestKindAsset,
+		URL:         asset.URL,
+		Type:        asset.Type,
+		StatusCode:  asset.StatusCode,
+		ContentType: asset.ContentType,
+		Size:        asset.Size,
+		FetchedAt:   asset.FetchedAt,
+		Error:       asset.Error,
+	}
+	if asset.Downloaded {
+		entry.LocalPath = s.makeRelativePath(asset.LocalPath)
+	}
+	s.mu.RUnlock()
+
+	if err := s.manifest.Append(entry); err != nil {
+		s.mu.Lock()
+		s.Project.Errors = append(s.Project.Errors, fmt.Sprintf("Failed to write manifest entry for %s: %v", asset.URL, err))
+		s.mu.Unlock()
+	}
+}
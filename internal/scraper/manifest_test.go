@@ -0,0 +1,80 @@
+package scraper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/user/scrapper/internal/models"
+)
+
+func TestLoadManifestKeepsLatestEntry(t *testing.T) {
+	const projectID = "project"
+	dataDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dataDir, projectID), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := OpenManifest(projectID, dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := []models.ManifestEntry{
+		{Kind: models.ManifestKindPage, URL: "https://example.com/", Error: "timeout"},
+		{Kind: models.ManifestKindPage, URL: "https://example.com/docs", StatusCode: 200},
+		// Same URL as the first page, but an asset: not superseded
+		{Kind: models.ManifestKindAsset, URL: "https://example.com/", Type: "other"},
+		{Kind: models.ManifestKindPage, URL: "https://example.com/", StatusCode: 200, LocalPath: "index.html"},
+	}
+	for _, record := range records {
+		if err := manifest.Append(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := manifest.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A line cut short by a running crawl is skipped
+	path := filepath.Join(dataDir, projectID, ManifestFileName)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteString(`{"kind":"page","url":"https://example.com/do`); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	entries, err := LoadManifest(projectID, dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3: %+v", len(entries), entries)
+	}
+
+	root := entries[0]
+	if root.Kind != models.ManifestKindPage || root.URL != "https://example.com/" {
+		t.Fatalf("first entry = %s %s, want the root page", root.Kind, root.URL)
+	}
+	if root.StatusCode != 200 || root.LocalPath != "index.html" || root.Error != "" {
+		t.Errorf("root page = %+v, want its latest record", root)
+	}
+	if entries[1].URL != "https://example.com/docs" {
+		t.Errorf("second entry = %s, want the docs page", entries[1].URL)
+	}
+	if entries[2].Kind != models.ManifestKindAsset {
+		t.Errorf("third entry = %s %s, want the asset", entries[2].Kind, entries[2].URL)
+	}
+}
+
+func TestLoadManifestMissing(t *testing.T) {
+	entries, err := LoadManifest("missing", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries == nil || len(entries) != 0 {
+		t.Errorf("entries = %#v, want an empty list", entries)
+	}
+}
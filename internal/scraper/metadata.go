@@ -0,0 +1,109 @@
+package scraper
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/user/scrapper/internal/models"
+)
+
+// ExtractMetadata reads title, description, canonical URL, language, heading
+// outline, OpenGraph/Twitter cards and JSON-LD blocks from a parsed page
+func ExtractMetadata(doc *goquery.Selection, pageURL string) *models.PageMetadata {
+	meta := &models.PageMetadata{}
+
+	meta.Title = cleanText(doc.Find("title").First().Text())
+
+	// Language: <html lang>, then Content-Language meta
+	root := doc
+	if goquery.NodeName(doc) != "html" {
+		root = doc.Find("html").First()
+	}
+	meta.Language = strings.TrimSpace(root.AttrOr("lang", ""))
+	if meta.Language == "" {
+		doc.Find("meta[http-equiv]").EachWithBreak(func(_ int, sel *goquery.Selection) bool {
+			equiv, _ := sel.Attr("http-equiv")
+			if strings.EqualFold(equiv, "content-language") {
+				meta.Language = strings.TrimSpace(sel.AttrOr("content", ""))
+				return false
+			}
+			return true
+		})
+	}
+
+	// Canonical URL resolved against the page URL
+	if href, exists := doc.Find("link[rel=canonical]").First().Attr("href"); exists {
+		meta.Canonical = resolveURL(pageURL, href)
+	}
+
+	// Meta tags: description, og:*, twitter:*
+	doc.Find("meta").Each(func(_ int, sel *goquery.Selection) {
+		key := sel.AttrOr("property", "")
+		if key == "" {
+			key = sel.AttrOr("name", "")
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		content := strings.TrimSpace(sel.AttrOr("content", ""))
+		if key == "" || content == "" {
+			return
+		}
+
+		switch {
+		case key == "description":
+			meta.Description = content
+		case strings.HasPrefix(key, "og:"):
+			if meta.OpenGraph == nil {
+				meta.OpenGraph = make(map[string]string)
+			}
+			meta.OpenGraph[strings.TrimPrefix(key, "og:")] = content
+		case strings.HasPrefix(key, "twitter:"):
+			if meta.Twitter == nil {
+				meta.Twitter = make(map[string]string)
+			}
+			meta.Twitter[strings.TrimPrefix(key, "twitter:")] = content
+		}
+	})
+
+	// Heading outline
+	doc.Find("h1, h2, h3").Each(func(_ int, sel *goquery.Selection) {
+		text := cleanText(sel.Text())
+		if text == "" {
+			return
+		}
+		meta.Headings = append(meta.Headings, models.Heading{
+			Level: int(goquery.NodeName(sel)[1] - '0'),
+			Text:  text,
+			ID:    sel.AttrOr("id", ""),
+		})
+	})
+
+	// JSON-LD blocks; invalid JSON is skipped
+	doc.Find(`script[type="application/ld+json"]`).Each(func(_ int, sel *goquery.Selection) {
+		raw := strings.TrimSpace(sel.Text())
+		if raw != "" && json.Valid([]byte(raw)) {
+			meta.JSONLD = append(meta.JSONLD, json.RawMessage(raw))
+		}
+	})
+
+	return meta
+}
+
+// cleanText collapses whitespace
+func cleanText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// resolveURL resolves href against base; returns href unchanged on parse errors
+func resolveURL(base, href string) string {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return href
+	}
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return href
+	}
+	return baseURL.ResolveReference(ref).String()
+}
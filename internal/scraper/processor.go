@@ -14,11 +14,16 @@ import (
 // ProcessLinks transforms absolute URLs to relative paths in all HTML files
 func (s *Scraper) ProcessLinks() error {
 	for _, page := range s.Pages {
-		if err := s.processPageLinks(page); err != nil {
+		err := s.processPageLinks(page)
+
+		// Snapshots read pages while links are processed
+		s.mu.Lock()
+		if err != nil {
 			page.Error = fmt.Sprintf("Link processing failed: %v", err)
-			continue
+		} else {
+			page.Processed = true
 		}
-		page.Processed = true
+		s.mu.Unlock()
 	}
 	return nil
 }
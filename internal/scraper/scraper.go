@@ -9,12 +9,15 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/gocolly/colly/v2"
 	"github.com/user/scrapper/internal/models"
+	"github.com/user/scrapper/internal/search"
+	"github.com/user/scrapper/internal/warc"
 )
 
 // Scraper manages web scraping operations
@@ -29,8 +32,28 @@ type Scraper struct {
 	mu          sync.RWMutex
 	DataDir     string
 	MaxDepth    int
+	frontier    *frontier
+	manifest    *Manifest
+	recorder    *warcRecorder
+	client      *http.Client // Fetches assets through the recorder
+	schemas     []compiledSchema
+	dataset     *jsonlWriter
+
+	contentSelectors []compiledContentSelector
 }
 
+// crawlParallelism is the number of concurrent page fetches
+const crawlParallelism = 2
+
+// userAgent identifies the crawler in requests
+const userAgent = "WebScraper/1.0 (+https://github.com/user/scrapper)"
+
+// Colly context keys carrying frontier data into callbacks
+const (
+	ctxDepth  = "depth"
+	ctxParent = "parent"
+)
+
 // NewScraper creates a configured scraper instance
 func NewScraper(project *models.Project, dataDir string) (*Scraper, error) {
 	baseURL, err := url.Parse(project.URL)
@@ -55,20 +78,39 @@ func NewScraper(project *models.Project, dataDir string) (*Scraper, error) {
 		MaxDepth:    project.Depth,
 	}
 
-	// Configure Colly
+	s.frontier, err = newFrontier(project)
+	if err != nil {
+		return nil, err
+	}
+
+	s.schemas, err = compileSchemas(project.Extraction)
+	if err != nil {
+		return nil, err
+	}
+
+	s.contentSelectors, err = compileContentSelectors(project.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	// Configure Colly; depth and fetch order are handled by the frontier,
+	// so the collector runs synchronously inside frontier workers
 	s.Collector = colly.NewCollector(
-		colly.MaxDepth(project.Depth),
 		colly.AllowedDomains(s.BaseDomain),
-		colly.Async(true),
 	)
 
 	// Set custom User-Agent
-	s.Collector.UserAgent = "WebScraper/1.0 (+https://github.com/user/scrapper)"
+	s.Collector.UserAgent = userAgent
+
+	// Record HTTP exchanges of pages and assets to the project WARC
+	s.recorder = &warcRecorder{next: newFetchTransport(), onError: s.recordWARCError}
+	s.Collector.WithTransport(s.recorder)
+	s.client = &http.Client{Transport: s.recorder}
 
 	// Limit parallelism
 	s.Collector.Limit(&colly.LimitRule{
 		DomainGlob:  "*",
-		Parallelism: 2,
+		Parallelism: crawlParallelism,
 		Delay:       0, // No artificial delay
 	})
 
@@ -82,7 +124,7 @@ func (s *Scraper) setupCallbacks() {
 	// On HTML page
 	s.Collector.OnHTML("html", func(e *colly.HTMLElement) {
 		pageURL := e.Request.URL.String()
-		depth := e.Request.Depth
+		depth, _ := e.Request.Ctx.GetAny(ctxDepth).(int)
 
 		s.mu.Lock()
 		// Initialize page if not exists (could be pre-created)
@@ -96,18 +138,51 @@ func (s *Scraper) setupCallbacks() {
 		page := s.Pages[pageURL]
 		page.HTML = string(e.Response.Body)
 		page.Downloaded = true
+		page.ParentURL = e.Request.Ctx.Get(ctxParent)
+		page.LocalPath = s.pageLocalPath(pageURL)
+		page.StatusCode = e.Response.StatusCode
+		page.ContentType = e.Response.Headers.Get("Content-Type")
+		page.Size = int64(len(e.Response.Body))
+		page.FetchedAt = time.Now()
 		s.mu.Unlock()
 
 		// Extract and follow links
+		var links []models.Link
 		e.ForEach("a[href]", func(_ int, el *colly.HTMLElement) {
 			link := el.Request.AbsoluteURL(el.Attr("href"))
+			if outLink, ok := s.newLink(link, el); ok {
+				links = append(links, outLink)
+			}
 			if s.shouldVisit(link) {
-				el.Request.Visit(link)
+				s.enqueue(link, depth+1, pageURL)
 			}
 		})
 
+		s.mu.Lock()
+		page.Links = links
+		s.mu.Unlock()
+
 		// Extract assets
 		s.extractAssets(e)
+
+		// Extract page metadata
+		metadata := ExtractMetadata(e.DOM, pageURL)
+		s.mu.Lock()
+		page.Metadata = metadata
+		s.mu.Unlock()
+
+		outlinks := make([]string, len(links))
+		for i, link := range links {
+			outlinks[i] = link.URL
+		}
+		s.recorder.recordMetadata(pageURL, pageMetadataFields(depth, page.ParentURL, metadata.Title, outlinks))
+
+		// Extract structured data
+		if len(e.DOM.Nodes) > 0 {
+			s.extractData(e.DOM.Nodes[0], pageURL)
+		}
+
+		s.recordPage(page)
 	})
 
 	// On request
@@ -131,10 +206,27 @@ func (s *Scraper) setupCallbacks() {
 
 	// On error
 	s.Collector.OnError(func(r *colly.Response, err error) {
+		pageURL := r.Request.URL.String()
+
 		s.mu.Lock()
 		errMsg := fmt.Sprintf("Failed to scrape %s: %v", r.Request.URL, err)
 		s.Project.Errors = append(s.Project.Errors, errMsg)
+		depth, _ := r.Request.Ctx.GetAny(ctxDepth).(int)
+		failed := &models.Page{
+			URL:        pageURL,
+			Depth:      depth,
+			ParentURL:  r.Request.Ctx.Get(ctxParent),
+			StatusCode: r.StatusCode,
+			Size:       int64(len(r.Body)),
+			FetchedAt:  time.Now(),
+			Error:      err.Error(),
+		}
+		if r.Headers != nil {
+			failed.ContentType = r.Headers.Get("Content-Type")
+		}
 		s.mu.Unlock()
+
+		s.recordPage(failed)
 	})
 }
 
@@ -189,6 +281,83 @@ func (s *Scraper) addAsset(assetURL, assetType string) {
 	}
 }
 
+// enqueue adds a discovered URL to the frontier, respecting the depth limit
+func (s *Scraper) enqueue(link string, depth int, parent string) {
+	if s.MaxDepth > 0 && depth > s.MaxDepth {
+		return
+	}
+	if !s.frontier.push(link, depth, parent) {
+		return
+	}
+
+	discovered, _, _ := s.frontier.stats()
+	s.mu.Lock()
+	s.Project.Total = discovered
+	s.mu.Unlock()
+}
+
+// crawl fetches URLs from the frontier with a fixed worker pool until the
+// queue is drained or a budget stops it. Only a failure to fetch the start
+// URL is returned as an error.
+func (s *Scraper) crawl() error {
+	s.enqueue(s.Project.URL, 1, "")
+
+	var wg sync.WaitGroup
+	var startErr error
+
+	for i := 0; i < crawlParallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				item := s.frontier.next()
+				if item == nil {
+					return
+				}
+
+				ctx := colly.NewContext()
+				ctx.Put(ctxDepth, item.Depth)
+				ctx.Put(ctxParent, item.Parent)
+
+				err := s.Collector.Request("GET", item.URL, nil, ctx, nil)
+				if err != nil && item.Parent == "" {
+					s.mu.Lock()
+					startErr = err
+					s.mu.Unlock()
+				}
+
+				s.frontier.done()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if _, pending, stopped := s.frontier.stats(); stopped != "" {
+		s.mu.Lock()
+		s.Project.Errors = append(s.Project.Errors, fmt.Sprintf("Crawl stopped early: %s (%d URLs not fetched)", stopped, pending))
+		s.mu.Unlock()
+	}
+
+	return startErr
+}
+
+// newLink builds a link record for an anchor; non-HTTP links (mailto:, javascript:) are skipped
+func (s *Scraper) newLink(absURL string, el *colly.HTMLElement) (models.Link, bool) {
+	parsedURL, err := url.Parse(absURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+		return models.Link{}, false
+	}
+	parsedURL.Fragment = ""
+
+	return models.Link{
+		URL:      parsedURL.String(),
+		Text:     strings.Join(strings.Fields(el.Text), " "),
+		Rel:      el.Attr("rel"),
+		Internal: parsedURL.Hostname() == s.BaseDomain,
+	}, true
+}
+
 // shouldVisit checks if URL should be scraped
 func (s *Scraper) shouldVisit(urlStr string) bool {
 	parsedURL, err := url.Parse(urlStr)
@@ -281,8 +450,48 @@ func (s *Scraper) Run() error {
 		return fmt.Errorf("failed to initialize project: %w", err)
 	}
 
-	// Start from base URL
-	if err := s.Collector.Visit(s.Project.URL); err != nil {
+	// Open manifest for incremental page/asset records
+	manifest, err := OpenManifest(s.Project.ID, s.DataDir)
+	if err != nil {
+		s.mu.Lock()
+		s.Project.Status = models.StatusFailed
+		s.Project.Errors = append(s.Project.Errors, fmt.Sprintf("Failed to open manifest: %v", err))
+		s.mu.Unlock()
+		return fmt.Errorf("failed to open manifest: %w", err)
+	}
+	s.manifest = manifest
+	defer manifest.Close()
+
+	// Record raw HTTP exchanges
+	description := "Crawl of " + s.Project.URL
+	if s.Project.ImportedFrom != "" {
+		description = "Import of " + s.Project.ImportedFrom + " from " + s.Project.URL
+	}
+	if err := s.recorder.open(filepath.Join(s.DataDir, s.Project.ID, WARCFileName), description); err != nil {
+		s.mu.Lock()
+		s.Project.Status = models.StatusFailed
+		s.Project.Errors = append(s.Project.Errors, fmt.Sprintf("Failed to open WARC file: %v", err))
+		s.mu.Unlock()
+		return fmt.Errorf("failed to open WARC file: %w", err)
+	}
+	defer s.recorder.close()
+
+	// Open dataset for extraction results
+	if len(s.schemas) > 0 {
+		dataset, err := openJSONL(filepath.Join(s.DataDir, s.Project.ID, DatasetFileName))
+		if err != nil {
+			s.mu.Lock()
+			s.Project.Status = models.StatusFailed
+			s.Project.Errors = append(s.Project.Errors, fmt.Sprintf("Failed to open dataset: %v", err))
+			s.mu.Unlock()
+			return fmt.Errorf("failed to open dataset: %w", err)
+		}
+		s.dataset = dataset
+		defer dataset.Close()
+	}
+
+	// Crawl from base URL in frontier order
+	if err := s.crawl(); err != nil {
 		s.mu.Lock()
 		s.Project.Status = models.StatusFailed
 		s.Project.Errors = append(s.Project.Errors, fmt.Sprintf("Failed to start scraping: %v", err))
@@ -291,9 +500,6 @@ func (s *Scraper) Run() error {
 		return fmt.Errorf("failed to start scraping: %w", err)
 	}
 
-	// Wait for completion
-	s.Collector.Wait()
-
 	// Download assets
 	if err := s.downloadAssets(); err != nil {
 		s.mu.Lock()
@@ -301,6 +507,23 @@ func (s *Scraper) Run() error {
 		s.mu.Unlock()
 	}
 
+	// Check links (optional)
+	report, err := s.CheckLinks()
+	if err != nil {
+		s.mu.Lock()
+		s.Project.Errors = append(s.Project.Errors, fmt.Sprintf("Link check failed: %v", err))
+		s.mu.Unlock()
+	} else if report != nil {
+		if err := s.SaveLinkCheckReport(report); err != nil {
+			s.mu.Lock()
+			s.Project.Errors = append(s.Project.Errors, fmt.Sprintf("Failed to save link check report: %v", err))
+			s.mu.Unlock()
+		}
+		s.mu.Lock()
+		s.Project.LinkCheckSummary = &report.Summary
+		s.mu.Unlock()
+	}
+
 	// Save pages to disk
 	if err := s.savePages(); err != nil {
 		s.mu.Lock()
@@ -325,6 +548,30 @@ func (s *Scraper) Run() error {
 		s.mu.Unlock()
 	}
 
+	// Save main content next to each page
+	if err := s.extractContent(); err != nil {
+		s.mu.Lock()
+		s.Project.Errors = append(s.Project.Errors, fmt.Sprintf("Content extraction errors: %v", err))
+		s.mu.Unlock()
+	}
+
+	// Extract tables of filtered pages to CSV
+	if err := s.extractTables(); err != nil {
+		s.mu.Lock()
+		s.Project.Errors = append(s.Project.Errors, fmt.Sprintf("Table extraction errors: %v", err))
+		s.mu.Unlock()
+	}
+
+	// Build the full-text search index over filtered pages
+	if err := s.indexPages(); err != nil {
+		s.mu.Lock()
+		s.Project.Errors = append(s.Project.Errors, fmt.Sprintf("Search index error: %v", err))
+		s.mu.Unlock()
+	}
+
+	// Record pages that failed while saving or post-processing
+	s.recordPageErrors()
+
 	s.mu.Lock()
 	s.Project.Status = models.StatusCompleted
 	s.Project.Total = len(s.Pages)
@@ -340,6 +587,21 @@ func (s *Scraper) Run() error {
 	return nil
 }
 
+// UseArchive makes the scraper fetch pages and assets from a WARC archive
+// instead of the network. Replayed exchanges are recorded with their
+// original capture date; URLs missing from the archive are not recorded.
+func (s *Scraper) UseArchive(archive *warc.Archive) {
+	s.recorder.next = archive
+	s.recorder.captured = archive.CaptureDate
+}
+
+// recordWARCError notes a record that could not be written to the WARC file
+func (s *Scraper) recordWARCError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Project.Errors = append(s.Project.Errors, fmt.Sprintf("WARC recording error: %v", err))
+}
+
 // downloadAssets downloads all tracked assets
 func (s *Scraper) downloadAssets() error {
 	projectDir := filepath.Join(s.DataDir, s.Project.ID)
@@ -354,30 +616,43 @@ func (s *Scraper) downloadAssets() error {
 	s.mu.RUnlock()
 
 	for _, asset := range assetsToDownload {
-		localPath, err := s.downloadAsset(asset.URL, assetsDir, asset.Type)
+		localPath, err := s.downloadAsset(asset, assetsDir)
 		if err != nil {
 			s.mu.Lock()
 			asset.Error = err.Error()
 			s.mu.Unlock()
+			s.recordAsset(asset)
 			continue
 		}
 		s.mu.Lock()
 		asset.LocalPath = localPath
 		asset.Downloaded = true
 		s.mu.Unlock()
+		s.recordAsset(asset)
 	}
 
 	return nil
 }
 
-// downloadAsset downloads single asset to local path
-func (s *Scraper) downloadAsset(assetURL, assetsDir, assetType string) (string, error) {
-	resp, err := http.Get(assetURL)
+// downloadAsset downloads single asset to local path and records its HTTP metadata
+func (s *Scraper) downloadAsset(asset *models.Asset, assetsDir string) (string, error) {
+	assetURL, assetType := asset.URL, asset.Type
+
+	s.mu.Lock()
+	asset.FetchedAt = time.Now()
+	s.mu.Unlock()
+
+	resp, err := s.client.Get(assetURL)
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
 
+	s.mu.Lock()
+	asset.StatusCode = resp.StatusCode
+	asset.ContentType = resp.Header.Get("Content-Type")
+	s.mu.Unlock()
+
 	if resp.StatusCode != http.StatusOK {
 		return "", fmt.Errorf("status %d", resp.StatusCode)
 	}
@@ -415,10 +690,15 @@ func (s *Scraper) downloadAsset(assetURL, assetsDir, assetType string) (string,
 	}
 	defer file.Close()
 
-	if _, err := io.Copy(file, resp.Body); err != nil {
+	written, err := io.Copy(file, resp.Body)
+	if err != nil {
 		return "", err
 	}
 
+	s.mu.Lock()
+	asset.Size = written
+	s.mu.Unlock()
+
 	// Return absolute path so we can calculate relative later or just return full path
 	return localPath, nil
 }
@@ -439,21 +719,7 @@ func (s *Scraper) savePages() error {
 	defer s.mu.Unlock()
 
 	for pageURL, page := range s.Pages {
-		// Determine save path
-		var savePath string
-		// Normalize URLs for comparison
-		normalizedPageURL := strings.TrimRight(pageURL, "/")
-		normalizedProjectURL := strings.TrimRight(s.Project.URL, "/")
-
-		if normalizedPageURL == normalizedProjectURL {
-			// Main page
-			savePath = filepath.Join(projectDir, "index.html")
-		} else {
-			// Subpage
-			filename := generateFilename(pageURL) + ".html"
-			savePath = filepath.Join(pagesDir, filename)
-		}
-
+		savePath := s.pageLocalPath(pageURL)
 		page.LocalPath = savePath // Store absolute path
 
 		// Write HTML
@@ -466,6 +732,70 @@ func (s *Scraper) savePages() error {
 	return nil
 }
 
+// pageLocalPath returns the absolute path a page is saved to
+func (s *Scraper) pageLocalPath(pageURL string) string {
+	projectDir := filepath.Join(s.DataDir, s.Project.ID)
+
+	// Normalize URLs for comparison
+	normalizedPageURL := strings.TrimRight(pageURL, "/")
+	normalizedProjectURL := strings.TrimRight(s.Project.URL, "/")
+
+	if normalizedPageURL == normalizedProjectURL {
+		// Main page
+		return filepath.Join(projectDir, "index.html")
+	}
+
+	// Subpage
+	filename := generateFilename(pageURL) + ".html"
+	return filepath.Join(projectDir, "pages", filename)
+}
+
+// indexPages builds the project search index from saved pages
+func (s *Scraper) indexPages() error {
+	projectDir := filepath.Join(s.DataDir, s.Project.ID)
+
+	s.mu.RLock()
+	docs := make([]search.Document, 0, len(s.Pages))
+	for pageURL, page := range s.Pages {
+		if page.Error != "" || page.LocalPath == "" {
+			continue
+		}
+		relPath, err := filepath.Rel(projectDir, page.LocalPath)
+		if err != nil {
+			continue
+		}
+		doc := search.Document{URL: pageURL, LocalPath: filepath.ToSlash(relPath)}
+		if page.Metadata != nil {
+			doc.Title = page.Metadata.Title
+			doc.Language = page.Metadata.Language
+		}
+		docs = append(docs, doc)
+	}
+	s.mu.RUnlock()
+
+	// Stable document order
+	sort.Slice(docs, func(i, j int) bool { return docs[i].URL < docs[j].URL })
+
+	_, err := search.BuildIndex(s.Project.ID, s.DataDir, docs)
+	return err
+}
+
+// recordPageErrors re-records pages that picked up an error after they were fetched
+func (s *Scraper) recordPageErrors() {
+	var failed []*models.Page
+	s.mu.RLock()
+	for _, page := range s.Pages {
+		if page.Error != "" {
+			failed = append(failed, page)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, page := range failed {
+		s.recordPage(page)
+	}
+}
+
 // generateFilename creates a safe filename from URL
 func generateFilename(urlStr string) string {
 	hash := md5.Sum([]byte(urlStr))
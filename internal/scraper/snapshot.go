@@ -0,0 +1,162 @@
+package scraper
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/user/scrapper/internal/models"
+)
+
+// Snapshot freezes the pages fetched and assets downloaded so far into a
+// separate project under dataDir, then rewrites links, applies filters and
+// extracts content there as a finished crawl would. The running scraper
+// and its project directory are left untouched. The snapshot project is
+// marked partial, keeps the ID of the original and counts the assets still
+// waiting for download; assets are only downloaded once every page has
+// been fetched, so mid-crawl snapshots have none.
+func (s *Scraper) Snapshot(dataDir string) (*models.Project, error) {
+	projectDir := filepath.Join(s.DataDir, s.Project.ID)
+	snapshotAt := time.Now()
+
+	// One read lock gives a consistent view of pages, assets and counters
+	s.mu.RLock()
+	project := *s.Project
+	project.Errors = append([]string(nil), s.Project.Errors...)
+	pages := make(map[string]*models.Page, len(s.Pages))
+	for pageURL, page := range s.Pages {
+		if !page.Downloaded || page.HTML == "" {
+			continue
+		}
+		copied := *page
+		copied.LocalPath, copied.ContentPath = "", ""
+		copied.Processed, copied.Filtered = false, false
+		copied.Error = ""
+		pages[pageURL] = &copied
+	}
+	assets := make(map[string]*models.Asset, len(s.Assets))
+	sources := make(map[string]string) // Asset URL -> file in the running project
+	pending := 0
+	for assetURL, asset := range s.Assets {
+		copied := *asset
+		if asset.Downloaded {
+			sources[assetURL] = asset.LocalPath
+		} else if asset.Error == "" {
+			pending++
+		}
+		assets[assetURL] = &copied
+	}
+	s.mu.RUnlock()
+
+	project.Partial = true
+	project.SnapshotAt = &snapshotAt
+	project.PendingAssets = pending
+	project.Total = len(pages)
+
+	snap := &Scraper{
+		Project:          &project,
+		BaseURL:          s.BaseURL,
+		BaseDomain:       s.BaseDomain,
+		ScopePrefix:      s.ScopePrefix,
+		Pages:            pages,
+		Assets:           assets,
+		DataDir:          dataDir,
+		MaxDepth:         s.MaxDepth,
+		contentSelectors: s.contentSelectors,
+	}
+
+	if err := InitializeProjectDirectory(project.ID, dataDir); err != nil {
+		return nil, fmt.Errorf("failed to initialize snapshot: %w", err)
+	}
+	manifest, err := OpenManifest(project.ID, dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot manifest: %w", err)
+	}
+	snap.manifest = manifest
+	defer manifest.Close()
+
+	// Downloaded asset files are never rewritten, so copying them outside
+	// the lock still matches the view above
+	snapshotDir := filepath.Join(dataDir, project.ID)
+	for assetURL, asset := range assets {
+		source, ok := sources[assetURL]
+		if !ok {
+			continue
+		}
+		relPath, err := filepath.Rel(projectDir, source)
+		if err == nil {
+			asset.LocalPath = filepath.Join(snapshotDir, relPath)
+			err = copyFile(source, asset.LocalPath)
+		}
+		if err != nil {
+			asset.Downloaded = false
+			asset.LocalPath = ""
+			asset.Error = err.Error()
+		}
+	}
+
+	if err := snap.savePages(); err != nil {
+		return nil, fmt.Errorf("failed to save snapshot pages: %w", err)
+	}
+
+	// Record pages in fetch order, the crawl order of exports
+	ordered := make([]*models.Page, 0, len(pages))
+	for _, page := range pages {
+		ordered = append(ordered, page)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if !ordered[i].FetchedAt.Equal(ordered[j].FetchedAt) {
+			return ordered[i].FetchedAt.Before(ordered[j].FetchedAt)
+		}
+		return ordered[i].URL < ordered[j].URL
+	})
+	for _, page := range ordered {
+		snap.recordPage(page)
+	}
+	for _, asset := range assets {
+		snap.recordAsset(asset)
+	}
+
+	if err := snap.ProcessLinks(); err != nil {
+		project.Errors = append(project.Errors, fmt.Sprintf("Link processing errors: %v", err))
+	}
+	if err := snap.ApplyFiltersToProject(); err != nil {
+		project.Errors = append(project.Errors, fmt.Sprintf("Filter errors: %v", err))
+	}
+	if err := snap.extractContent(); err != nil {
+		project.Errors = append(project.Errors, fmt.Sprintf("Content extraction errors: %v", err))
+	}
+	snap.recordPageErrors()
+
+	if err := snap.SaveProject(); err != nil {
+		return nil, fmt.Errorf("failed to save snapshot metadata: %w", err)
+	}
+	return &project, nil
+}
+
+// copyFile copies a file, creating the directory of dst
+func copyFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	return out.Close()
+}
@@ -15,9 +15,10 @@ func (s *Scraper) SaveProject() error {
 	metadataPath := filepath.Join(projectDir, "project.json")
 
 	// Update timestamp
+	s.mu.Lock()
 	s.Project.UpdatedAt = time.Now()
-
 	data, err := json.MarshalIndent(s.Project, "", "  ")
+	s.mu.Unlock()
 	if err != nil {
 		return err
 	}
@@ -25,6 +26,34 @@ func (s *Scraper) SaveProject() error {
 	return os.WriteFile(metadataPath, data, 0644)
 }
 
+// MarkFailed records an error that ended the crawl
+func (s *Scraper) MarkFailed(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Project.Status = models.StatusFailed
+	s.Project.Errors = append(s.Project.Errors, err.Error())
+}
+
+// Status returns the live status of the crawl
+func (s *Scraper) Status() models.StatusResponse {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	progress := 0
+	if s.Project.Total > 0 {
+		progress = (s.Project.Downloaded * 100) / s.Project.Total
+	}
+	return models.StatusResponse{
+		Status:     s.Project.Status,
+		Progress:   progress,
+		Downloaded: s.Project.Downloaded,
+		Total:      s.Project.Total,
+		CurrentURL: s.Project.CurrentURL,
+		Errors:     append([]string(nil), s.Project.Errors...),
+		LinkCheck:  s.Project.LinkCheckSummary,
+	}
+}
+
 // LoadProject loads project metadata from JSON file
 func LoadProject(projectID, dataDir string) (*models.Project, error) {
 	metadataPath := filepath.Join(dataDir, projectID, "project.json")
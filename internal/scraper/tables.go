@@ -0,0 +1,340 @@
+package scraper
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/user/scrapper/internal/models"
+)
+
+// Extracted tables live in TablesDirName: one CSV per table and the
+// TablesIndexFileName list describing them
+const (
+	TablesDirName       = "tables"
+	TablesIndexFileName = "tables.json"
+)
+
+// Span limits from the HTML table model
+const (
+	maxColspan = 1000
+	maxRowspan = 65534
+)
+
+// Size limits of an expanded table; spans make a small page expand to a
+// huge grid, and XLSX sheets have at most 16384 columns
+const (
+	maxTableColumns = 16384
+	maxTableCells   = 1 << 20
+)
+
+// errTableTooLarge is returned for tables whose expanded grid exceeds the
+// size limits
+var errTableTooLarge = fmt.Errorf("table expands to more than %d columns or %d cells", maxTableColumns, maxTableCells)
+
+// extractedTable is a table with spans expanded into a rectangular grid
+type extractedTable struct {
+	caption    string
+	grid       [][]string
+	headerRows int // Leading rows that are headers
+}
+
+// extractTables writes every table of the saved pages to CSV and lists them
+// in the tables index. Pages are processed in URL order.
+func (s *Scraper) extractTables() error {
+	tablesDir := filepath.Join(s.DataDir, s.Project.ID, TablesDirName)
+
+	s.mu.RLock()
+	pages := make([]*models.Page, 0, len(s.Pages))
+	for _, page := range s.Pages {
+		if page.Error == "" && page.LocalPath != "" {
+			pages = append(pages, page)
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(pages, func(i, j int) bool { return pages[i].URL < pages[j].URL })
+
+	if err := os.RemoveAll(tablesDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(tablesDir, 0755); err != nil {
+		return err
+	}
+
+	tables := make([]models.TableInfo, 0)
+	var failed int
+	for _, page := range pages {
+		found, skipped, err := pageTables(page.LocalPath)
+		if err != nil {
+			failed++
+			s.mu.Lock()
+			s.Project.Errors = append(s.Project.Errors, fmt.Sprintf("Table extraction failed for %s: %v", page.URL, err))
+			s.mu.Unlock()
+			continue
+		}
+		for _, err := range skipped {
+			s.mu.Lock()
+			s.Project.Errors = append(s.Project.Errors, fmt.Sprintf("Table skipped on %s: %v", page.URL, err))
+			s.mu.Unlock()
+		}
+
+		for position, table := range found {
+			if table == nil {
+				continue
+			}
+			info := table.info(fmt.Sprintf("table-%04d", len(tables)+1), page.URL, position+1)
+			if err := table.writeCSV(filepath.Join(s.DataDir, s.Project.ID, filepath.FromSlash(info.File))); err != nil {
+				return err
+			}
+			tables = append(tables, info)
+		}
+	}
+
+	data, err := json.MarshalIndent(tables, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(tablesDir, TablesIndexFileName), data, 0644); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d pages failed", failed)
+	}
+	return nil
+}
+
+// LoadTables reads the tables index of a project. Projects crawled before
+// table extraction existed yield an empty list.
+func LoadTables(projectID, dataDir string) ([]models.TableInfo, error) {
+	data, err := os.ReadFile(filepath.Join(dataDir, projectID, TablesDirName, TablesIndexFileName))
+	if os.IsNotExist(err) {
+		return []models.TableInfo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []models.TableInfo
+	if err := json.Unmarshal(data, &tables); err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+// pageTables extracts the tables of a saved page in document order; layout
+// tables, tables without cells and tables over the size limits are nil so
+// positions stay those of the page. Tables over the limits are reported in
+// skipped.
+func pageTables(path string) (tables []*extractedTable, skipped []error, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	doc, err := goquery.NewDocumentFromReader(file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	doc.Find("table").Each(func(i int, sel *goquery.Selection) {
+		role, _ := sel.Attr("role")
+		if role == "presentation" || role == "none" {
+			tables = append(tables, nil)
+			return
+		}
+		table, err := normalizeTable(sel)
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("table %d: %w", i+1, err))
+		}
+		tables = append(tables, table)
+	})
+	return tables, skipped, nil
+}
+
+// tableRow is a <tr> with the cells it contains
+type tableRow struct {
+	cells   *goquery.Selection
+	header  bool // In <thead> or only <th> cells
+	section int  // Row group, which limits rowspan
+}
+
+// normalizeTable expands colspan and rowspan into a grid padded to equal
+// row lengths; leading header rows are counted. Tables without cells are
+// nil and grids over the size limits return errTableTooLarge before they
+// are allocated.
+func normalizeTable(table *goquery.Selection) (*extractedTable, error) {
+	var rows []tableRow
+	section := 0
+	addRows := func(trs *goquery.Selection, inHead bool) {
+		trs.Each(func(_ int, tr *goquery.Selection) {
+			cells := tr.ChildrenFiltered("td, th")
+			header := inHead || (cells.Length() > 0 && cells.Length() == cells.Filter("th").Length())
+			rows = append(rows, tableRow{cells: cells, header: header, section: section})
+		})
+		section++
+	}
+	table.ChildrenFiltered("thead, tbody, tfoot, tr").Each(func(_ int, child *goquery.Selection) {
+		if goquery.NodeName(child) == "tr" {
+			addRows(child, false)
+			return
+		}
+		addRows(child.ChildrenFiltered("tr"), goquery.NodeName(child) == "thead")
+	})
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	// Rows a rowspan may reach: the end of its row group
+	sectionEnd := make([]int, len(rows))
+	for i := len(rows) - 1; i >= 0; i-- {
+		if i == len(rows)-1 || rows[i+1].section != rows[i].section {
+			sectionEnd[i] = i + 1
+		} else {
+			sectionEnd[i] = sectionEnd[i+1]
+		}
+	}
+
+	grid := make([][]string, len(rows))
+	filled := make([][]bool, len(rows))
+	set := func(r, c int, text string) {
+		for len(grid[r]) <= c {
+			grid[r] = append(grid[r], "")
+			filled[r] = append(filled[r], false)
+		}
+		grid[r][c] = text
+		filled[r][c] = true
+	}
+
+	cells := 0
+	area := 0 // Grid cells the spans cover so far, an upper bound of the filled ones
+	width := 0
+	for r, row := range rows {
+		col := 0
+		tooLarge := false
+		row.cells.EachWithBreak(func(_ int, cell *goquery.Selection) bool {
+			for col < len(filled[r]) && filled[r][col] {
+				col++
+			}
+
+			colspan := spanAttr(cell, "colspan", 1, maxColspan)
+			rowspan := spanAttr(cell, "rowspan", 1, maxRowspan)
+			if value, _ := cell.Attr("rowspan"); strings.TrimSpace(value) == "0" {
+				rowspan = sectionEnd[r] - r
+			}
+			rowspan = min(rowspan, sectionEnd[r]-r)
+
+			area += colspan * rowspan
+			width = max(width, col+colspan)
+			if width > maxTableColumns || area > maxTableCells || width*len(rows) > maxTableCells {
+				tooLarge = true
+				return false
+			}
+
+			text := strings.Join(strings.Fields(cell.Text()), " ")
+			for dr := 0; dr < rowspan; dr++ {
+				for dc := 0; dc < colspan; dc++ {
+					set(r+dr, col+dc, text)
+				}
+			}
+			col += colspan
+			cells++
+			return true
+		})
+		if tooLarge {
+			return nil, errTableTooLarge
+		}
+	}
+	if cells == 0 {
+		return nil, nil
+	}
+
+	for r := range grid {
+		for len(grid[r]) < width {
+			grid[r] = append(grid[r], "")
+		}
+	}
+
+	headerRows := 0
+	for headerRows < len(rows)-1 && rows[headerRows].header {
+		headerRows++
+	}
+
+	caption := strings.Join(strings.Fields(table.ChildrenFiltered("caption").First().Text()), " ")
+	return &extractedTable{caption: caption, grid: grid, headerRows: headerRows}, nil
+}
+
+// spanAttr reads a colspan or rowspan, falling back to def for invalid values
+func spanAttr(cell *goquery.Selection, name string, def, limit int) int {
+	value, ok := cell.Attr(name)
+	if !ok {
+		return def
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 1 {
+		return def
+	}
+	return min(n, limit)
+}
+
+// header merges the header rows per column; values repeated by a rowspan
+// appear once
+func (t *extractedTable) header() []string {
+	if t.headerRows == 0 {
+		return nil
+	}
+
+	header := make([]string, len(t.grid[0]))
+	for c := range header {
+		var parts []string
+		for r := 0; r < t.headerRows; r++ {
+			value := t.grid[r][c]
+			if value != "" && (len(parts) == 0 || parts[len(parts)-1] != value) {
+				parts = append(parts, value)
+			}
+		}
+		header[c] = strings.Join(parts, " / ")
+	}
+	return header
+}
+
+// info describes the table for the index
+func (t *extractedTable) info(id, pageURL string, position int) models.TableInfo {
+	return models.TableInfo{
+		ID:       id,
+		PageURL:  pageURL,
+		Position: position,
+		Caption:  t.caption,
+		Header:   t.header(),
+		Rows:     len(t.grid) - t.headerRows,
+		Columns:  len(t.grid[0]),
+		File:     TablesDirName + "/" + id + ".csv",
+	}
+}
+
+// writeCSV writes the merged header and the data rows
+func (t *extractedTable) writeCSV(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if header := t.header(); header != nil {
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+	}
+	if err := writer.WriteAll(t.grid[t.headerRows:]); err != nil {
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,78 @@
+package scraper
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// parseTable returns the first table of an HTML fragment
+func parseTable(t *testing.T, html string) *goquery.Selection {
+	t.Helper()
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc.Find("table").First()
+}
+
+func TestNormalizeTableSpans(t *testing.T) {
+	table, err := normalizeTable(parseTable(t, `<table>
+		<tr><th colspan="2">A</th><th>B</th></tr>
+		<tr><td rowspan="2">x</td><td>1</td><td>2</td></tr>
+		<tr><td>3</td><td>4</td></tr>
+	</table>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{{"A", "A", "B"}, {"x", "1", "2"}, {"x", "3", "4"}}
+	if len(table.grid) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(table.grid), len(want))
+	}
+	for r := range want {
+		if strings.Join(table.grid[r], "|") != strings.Join(want[r], "|") {
+			t.Errorf("row %d = %q, want %q", r, table.grid[r], want[r])
+		}
+	}
+	if table.headerRows != 1 {
+		t.Errorf("headerRows = %d, want 1", table.headerRows)
+	}
+}
+
+func TestNormalizeTableTooLarge(t *testing.T) {
+	// 300 rows of 50 cells spanning 1000 columns each: 368 KB of HTML that
+	// would expand to a 300 × 50,000 grid
+	row := "<tr>" + strings.Repeat(`<td colspan="1000">x</td>`, 50) + "</tr>"
+	wide := "<table>" + strings.Repeat(row, 300) + "</table>"
+
+	tests := map[string]string{
+		"columns": wide,
+		"cells":   "<table>" + strings.Repeat(`<tr><td colspan="1000">x</td></tr>`, 2000) + "</table>",
+		"rowspan": `<table><tr><td colspan="1000" rowspan="0">x</td></tr>` + strings.Repeat("<tr></tr>", 2000) + "</table>",
+	}
+	for name, html := range tests {
+		t.Run(name, func(t *testing.T) {
+			table, err := normalizeTable(parseTable(t, html))
+			if !errors.Is(err, errTableTooLarge) {
+				t.Fatalf("err = %v, want errTableTooLarge", err)
+			}
+			if table != nil {
+				t.Fatal("table over the limits was returned")
+			}
+		})
+	}
+}
+
+func TestNormalizeTableAtColumnLimit(t *testing.T) {
+	row := "<tr>" + strings.Repeat(`<td colspan="1000">x</td>`, 16) + `<td colspan="384">y</td></tr>`
+	table, err := normalizeTable(parseTable(t, "<table>"+row+"</table>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(table.grid[0]); got != maxTableColumns {
+		t.Fatalf("width = %d, want %d", got, maxTableColumns)
+	}
+}
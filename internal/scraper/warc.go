@@ -0,0 +1,259 @@
+package scraper
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/user/scrapper/internal/warc"
+)
+
+// WARCFileName is the WARC file a crawl records its HTTP exchanges to
+const WARCFileName = "crawl.warc.gz"
+
+// maxWARCPayload limits the bytes recorded per response; longer bodies are
+// marked truncated
+const maxWARCPayload = 100 << 20
+
+// warcRecorder is an http.RoundTripper writing every exchange it carries
+// to a WARC file as request and response records
+type warcRecorder struct {
+	next    http.RoundTripper
+	onError func(error) // Reports records that could not be written
+
+	// captured returns the original capture date of replayed exchanges, or
+	// false for exchanges that must not be recorded; nil for live crawls
+	captured func(rawURL string) (time.Time, bool)
+
+	mu        sync.Mutex
+	file      *os.File
+	writer    *warc.Writer
+	responses map[string]string // URL -> ID of its latest response record
+}
+
+// newFetchTransport returns the transport crawls fetch with; compression
+// is left to servers so recorded responses keep their original bytes
+func newFetchTransport() http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableCompression = true
+	return transport
+}
+
+// open starts recording to the project WARC file, beginning with a
+// warcinfo record describing the crawl
+func (r *warcRecorder) open(path, description string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	writer := warc.NewWriter(file, true)
+	header := warc.Header{
+		{Name: "WARC-Type", Value: warc.TypeWarcinfo},
+		{Name: "WARC-Filename", Value: filepath.Base(path)},
+		{Name: "Content-Type", Value: warc.ContentTypeFields},
+	}
+	info := warc.Fields(
+		warc.Field{Name: "software", Value: userAgent},
+		warc.Field{Name: "format", Value: "WARC File Format 1.1"},
+		warc.Field{Name: "conformsTo", Value: "https://iipc.github.io/warc-specifications/specifications/warc-format/warc-1.1/"},
+		warc.Field{Name: "description", Value: description},
+	)
+	if _, err := writer.WriteRecord(header, info); err != nil {
+		file.Close()
+		return err
+	}
+
+	r.mu.Lock()
+	r.file = file
+	r.writer = writer
+	r.responses = make(map[string]string)
+	r.mu.Unlock()
+	return nil
+}
+
+// close stops recording; later exchanges pass through unrecorded
+func (r *warcRecorder) close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	r.writer = nil
+	return err
+}
+
+// RoundTrip performs a request and records it once the body is consumed
+func (r *warcRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	date := time.Now()
+	recording := true
+	if r.captured != nil {
+		date, recording = r.captured(req.URL.String())
+	}
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	r.mu.Lock()
+	recording = recording && r.writer != nil
+	r.mu.Unlock()
+	if !recording {
+		return resp, nil
+	}
+
+	resp.Body = &recordedBody{
+		ReadCloser: resp.Body,
+		onClose: func(payload []byte, complete bool) {
+			if err := r.record(req, resp, date, payload, complete); err != nil {
+				r.onError(err)
+			}
+		},
+	}
+	return resp, nil
+}
+
+// record writes the response and request records of one exchange
+func (r *warcRecorder) record(req *http.Request, resp *http.Response, date time.Time, payload []byte, complete bool) error {
+	r.mu.Lock()
+	writer := r.writer
+	r.mu.Unlock()
+	if writer == nil {
+		return nil
+	}
+
+	targetURI := req.URL.String()
+	warcDate := warc.FormatDate(date)
+
+	// The transport has already removed chunked transfer coding
+	var block bytes.Buffer
+	fmt.Fprintf(&block, "HTTP/%d.%d %s\r\n", resp.ProtoMajor, resp.ProtoMinor, resp.Status)
+	header := resp.Header.Clone()
+	header.Del("Transfer-Encoding")
+	if complete {
+		header.Set("Content-Length", strconv.Itoa(len(payload)))
+	}
+	header.Write(&block)
+	block.WriteString("\r\n")
+	block.Write(payload)
+
+	responseHeader := warc.Header{
+		{Name: "WARC-Type", Value: warc.TypeResponse},
+		{Name: "WARC-Date", Value: warcDate},
+		{Name: "WARC-Target-URI", Value: targetURI},
+		{Name: "Content-Type", Value: warc.ContentTypeHTTPResponse},
+		{Name: "WARC-Payload-Digest", Value: warc.Digest(payload)},
+	}
+	if !complete {
+		responseHeader.Add("WARC-Truncated", "length")
+	}
+	responseID, err := writer.WriteRecord(responseHeader, block.Bytes())
+	if err != nil {
+		return err
+	}
+
+	var request bytes.Buffer
+	fmt.Fprintf(&request, "%s %s HTTP/1.1\r\nHost: %s\r\n", req.Method, req.URL.RequestURI(), req.URL.Host)
+	req.Header.Write(&request)
+	request.WriteString("\r\n")
+
+	requestHeader := warc.Header{
+		{Name: "WARC-Type", Value: warc.TypeRequest},
+		{Name: "WARC-Date", Value: warcDate},
+		{Name: "WARC-Target-URI", Value: targetURI},
+		{Name: "WARC-Concurrent-To", Value: responseID},
+		{Name: "Content-Type", Value: warc.ContentTypeHTTPRequest},
+	}
+	if _, err := writer.WriteRecord(requestHeader, request.Bytes()); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	if r.responses != nil {
+		r.responses[targetURI] = responseID
+	}
+	r.mu.Unlock()
+	return nil
+}
+
+// recordMetadata writes a metadata record about a fetched page, referring
+// to its response record
+func (r *warcRecorder) recordMetadata(pageURL string, fields []warc.Field) {
+	r.mu.Lock()
+	writer := r.writer
+	responseID := r.responses[pageURL]
+	r.mu.Unlock()
+	if writer == nil {
+		return
+	}
+
+	header := warc.Header{
+		{Name: "WARC-Type", Value: warc.TypeMetadata},
+		{Name: "WARC-Target-URI", Value: pageURL},
+		{Name: "Content-Type", Value: warc.ContentTypeFields},
+	}
+	if responseID != "" {
+		header.Add("WARC-Refers-To", responseID)
+	}
+	if _, err := writer.WriteRecord(header, warc.Fields(fields...)); err != nil {
+		r.onError(err)
+	}
+}
+
+// recordedBody keeps a copy of the bytes read from a response body and
+// hands it over when the body is closed
+type recordedBody struct {
+	io.ReadCloser
+	buf     bytes.Buffer
+	eof     bool
+	over    bool
+	once    sync.Once
+	onClose func(payload []byte, complete bool)
+}
+
+func (b *recordedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && !b.over {
+		if b.buf.Len()+n > maxWARCPayload {
+			b.over = true
+		} else {
+			b.buf.Write(p[:n])
+		}
+	}
+	if err == io.EOF {
+		b.eof = true
+	}
+	return n, err
+}
+
+func (b *recordedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.onClose(b.buf.Bytes(), b.eof && !b.over)
+	})
+	return err
+}
+
+// pageMetadataFields describes a fetched page for its metadata record
+func pageMetadataFields(depth int, parent, title string, outlinks []string) []warc.Field {
+	fields := []warc.Field{{Name: "hopsFromSeed", Value: strconv.Itoa(depth - 1)}}
+	if parent != "" {
+		fields = append(fields, warc.Field{Name: "via", Value: parent})
+	}
+	if title != "" {
+		fields = append(fields, warc.Field{Name: "title", Value: title})
+	}
+	for _, link := range outlinks {
+		fields = append(fields, warc.Field{Name: "outlink", Value: link})
+	}
+	return fields
+}
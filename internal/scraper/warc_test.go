@@ -0,0 +1,100 @@
+package scraper
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/user/scrapper/internal/warc"
+)
+
+// testArchive indexes a WARC with one page captured at capturedAt
+func testArchive(t *testing.T, capturedAt string) *warc.Archive {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := warc.NewWriter(&buf, true)
+	block := "HTTP/1.1 200 OK\r\nContent-Type: text/html\r\nContent-Length: 13\r\n\r\n<p>saved</p>\n"
+	header := warc.Header{
+		{Name: "WARC-Type", Value: warc.TypeResponse},
+		{Name: "WARC-Date", Value: capturedAt},
+		{Name: "WARC-Target-URI", Value: "https://example.com/"},
+		{Name: "Content-Type", Value: warc.ContentTypeHTTPResponse},
+	}
+	if _, err := writer.WriteRecord(header, []byte(block)); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := warc.NewArchive(&buf, t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { archive.Close() })
+	return archive
+}
+
+func TestRecorderKeepsCaptureDatesOfImports(t *testing.T) {
+	const capturedAt = "2020-05-06T07:08:09Z"
+	archive := testArchive(t, capturedAt)
+
+	recorder := &warcRecorder{
+		next:     archive,
+		captured: archive.CaptureDate,
+		onError:  func(err error) { t.Error(err) },
+	}
+	path := filepath.Join(t.TempDir(), WARCFileName)
+	if err := recorder.open(path, "Import"); err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{Transport: recorder}
+	for _, target := range []string{"https://example.com/", "https://example.com/missing"} {
+		resp, err := client.Get(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	if err := recorder.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	reader, err := warc.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorded := make(map[string]int)
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record.Type() == warc.TypeWarcinfo {
+			continue
+		}
+		uri := warc.TargetURI(record.Header)
+		recorded[uri]++
+		if date := record.Header.Get("WARC-Date"); date != capturedAt {
+			t.Errorf("%s record of %s dated %s, want the capture date %s", record.Type(), uri, date, capturedAt)
+		}
+	}
+
+	if recorded["https://example.com/"] != 2 {
+		t.Errorf("archived page has %d records, want a response and a request", recorded["https://example.com/"])
+	}
+	if recorded["https://example.com/missing"] != 0 {
+		t.Error("the 404 of a URL missing from the archive was recorded")
+	}
+}
@@ -0,0 +1,57 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// IndexCache keeps loaded project indexes in memory and reads an index
+// file again only after it changed
+type IndexCache struct {
+	mu      sync.Mutex
+	indexes map[string]cachedIndex // Keyed by index file path
+}
+
+// cachedIndex is an index with the state of the file it was read from
+type cachedIndex struct {
+	index   *Index
+	modTime time.Time
+	size    int64
+}
+
+// NewIndexCache creates an empty cache
+func NewIndexCache() *IndexCache {
+	return &IndexCache{indexes: make(map[string]cachedIndex)}
+}
+
+// Load returns the project index like LoadIndex. The returned index is
+// shared and must not be modified.
+func (c *IndexCache) Load(projectID, dataDir string) (*Index, error) {
+	path := filepath.Join(dataDir, projectID, IndexFileName)
+	info, err := os.Stat(path)
+	if err != nil {
+		c.mu.Lock()
+		delete(c.indexes, path)
+		c.mu.Unlock()
+		return nil, err
+	}
+
+	c.mu.Lock()
+	cached, ok := c.indexes[path]
+	c.mu.Unlock()
+	if ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.index, nil
+	}
+
+	index, err := LoadIndex(projectID, dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.indexes[path] = cachedIndex{index: index, modTime: info.ModTime(), size: info.Size()}
+	c.mu.Unlock()
+	return index, nil
+}
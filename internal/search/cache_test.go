@@ -0,0 +1,50 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIndexCache(t *testing.T) {
+	const projectID = "project"
+	index, dataDir := buildTestIndex(t)
+	if err := SaveIndex(projectID, dataDir, index); err != nil {
+		t.Fatal(err)
+	}
+	cache := NewIndexCache()
+
+	first, err := cache.Load(projectID, dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := cache.Load(projectID, dataDir); err != nil || again != first {
+		t.Errorf("unchanged index was read again")
+	}
+
+	// A rebuilt index replaces the cached one
+	index.Docs = index.Docs[:1]
+	if err := SaveIndex(projectID, dataDir, index); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dataDir, projectID, IndexFileName)
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	rebuilt, err := cache.Load(projectID, dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rebuilt == first || len(rebuilt.Docs) != 1 {
+		t.Errorf("rebuilt index not reloaded: %d pages", len(rebuilt.Docs))
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.Load(projectID, dataDir); !os.IsNotExist(err) {
+		t.Errorf("err = %v for a removed index, want not exist", err)
+	}
+}
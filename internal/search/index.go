@@ -0,0 +1,184 @@
+package search
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// IndexFileName is the per-project search index
+const IndexFileName = "search_index.json"
+
+// titleBoost is how many times title terms are counted
+const titleBoost = 3
+
+// Document is a saved page handed to the indexer
+type Document struct {
+	URL       string
+	LocalPath string // Relative to the project directory
+	Title     string
+	Language  string
+}
+
+// Index is an inverted index over the visible text of a project's pages
+type Index struct {
+	BuiltAt   time.Time            `json:"built_at"`
+	Docs      []IndexedDoc         `json:"docs"`
+	Terms     map[string][]Posting `json:"terms"`
+	AvgLength float64              `json:"avg_length"`
+}
+
+// IndexedDoc is a page in the index; Text is kept for snippets
+type IndexedDoc struct {
+	URL       string `json:"url"`
+	LocalPath string `json:"local_path"`
+	Title     string `json:"title"`
+	Language  string `json:"language"`
+	Length    int    `json:"length"`
+	Text      string `json:"text"`
+}
+
+// Posting is the frequency of a term in one document
+type Posting struct {
+	Doc  int `json:"d"`
+	Freq int `json:"f"`
+}
+
+// BuildIndex extracts visible text from saved pages and writes the project index
+func BuildIndex(projectID, dataDir string, docs []Document) (*Index, error) {
+	projectDir := filepath.Join(dataDir, projectID)
+
+	index := &Index{
+		BuiltAt: time.Now(),
+		Docs:    make([]IndexedDoc, 0, len(docs)),
+		Terms:   make(map[string][]Posting),
+	}
+
+	totalLength := 0
+	for _, doc := range docs {
+		text, err := visibleText(filepath.Join(projectDir, doc.LocalPath))
+		if err != nil {
+			continue // Page missing on disk
+		}
+
+		lang := DetectLanguage(doc.Language, text)
+		freqs := make(map[string]int)
+		length := 0
+		for _, tok := range tokenize(text) {
+			freqs[Stem(tok.word, lang)]++
+			length++
+		}
+		for _, tok := range tokenize(doc.Title) {
+			freqs[Stem(tok.word, lang)] += titleBoost
+			length += titleBoost
+		}
+		if length == 0 {
+			continue
+		}
+
+		docID := len(index.Docs)
+		index.Docs = append(index.Docs, IndexedDoc{
+			URL:       doc.URL,
+			LocalPath: doc.LocalPath,
+			Title:     doc.Title,
+			Language:  lang,
+			Length:    length,
+			Text:      text,
+		})
+		for term, freq := range freqs {
+			index.Terms[term] = append(index.Terms[term], Posting{Doc: docID, Freq: freq})
+		}
+		totalLength += length
+	}
+
+	if len(index.Docs) > 0 {
+		index.AvgLength = float64(totalLength) / float64(len(index.Docs))
+	}
+
+	return index, SaveIndex(projectID, dataDir, index)
+}
+
+// visibleText returns the whitespace-normalized text of an HTML file
+func visibleText(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	doc, err := goquery.NewDocumentFromReader(file)
+	if err != nil {
+		return "", err
+	}
+
+	body := doc.Find("body")
+	if body.Length() == 0 {
+		body = doc.Selection
+	}
+	body.Find("script, style, noscript, template, svg").Remove()
+
+	var b strings.Builder
+	for _, node := range body.Nodes {
+		writeText(&b, node)
+	}
+
+	return strings.Join(strings.Fields(b.String()), " "), nil
+}
+
+// blockElements get whitespace around their text so adjacent blocks don't merge
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "br": true,
+	"dd": true, "div": true, "dl": true, "dt": true, "figcaption": true, "figure": true,
+	"footer": true, "form": true, "h1": true, "h2": true, "h3": true, "h4": true,
+	"h5": true, "h6": true, "header": true, "hr": true, "li": true, "main": true,
+	"nav": true, "ol": true, "p": true, "pre": true, "section": true, "table": true,
+	"td": true, "th": true, "tr": true, "ul": true,
+}
+
+// writeText appends the text below node, separating block elements
+func writeText(b *strings.Builder, node *html.Node) {
+	if node.Type == html.TextNode {
+		b.WriteString(node.Data)
+		return
+	}
+
+	block := node.Type == html.ElementNode && blockElements[node.Data]
+	if block {
+		b.WriteByte(' ')
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		writeText(b, child)
+	}
+	if block {
+		b.WriteByte(' ')
+	}
+}
+
+// SaveIndex writes the index to the project directory
+func SaveIndex(projectID, dataDir string, index *Index) error {
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dataDir, projectID, IndexFileName), data, 0644)
+}
+
+// LoadIndex reads the project index; os.IsNotExist(err) reports a missing index
+func LoadIndex(projectID, dataDir string) (*Index, error) {
+	data, err := os.ReadFile(filepath.Join(dataDir, projectID, IndexFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	var index Index
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, err
+	}
+
+	return &index, nil
+}
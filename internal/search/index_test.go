@@ -0,0 +1,79 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writePages saves HTML files under a new project directory
+func writePages(t *testing.T, dataDir, projectID string, pages map[string]string) {
+	t.Helper()
+	for name, content := range pages {
+		path := filepath.Join(dataDir, projectID, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// buildTestIndex indexes a small site with English and Polish pages
+func buildTestIndex(t *testing.T) (*Index, string) {
+	t.Helper()
+	const projectID = "project"
+	dataDir := t.TempDir()
+	writePages(t, dataDir, projectID, map[string]string{
+		"pages/once.html":   `<html><body><p>A crawler visits pages.</p><p>It follows links, reads sitemaps and respects robots rules on every site it meets.</p></body></html>`,
+		"pages/often.html":  `<html><body><h1>Crawler guide</h1><p>The crawler queue feeds crawler workers.</p></body></html>`,
+		"pages/title.html":  `<html><body><p>Settings for the scheduler.</p></body></html>`,
+		"pages/other.html":  `<html><body><p>Nothing to see here.</p><script>var crawler = 1</script></body></html>`,
+		"pages/polish.html": `<html><body><p>Zamów książkę w naszej księgarni.</p></body></html>`,
+	})
+
+	index, err := BuildIndex(projectID, dataDir, []Document{
+		{URL: "https://example.com/once", LocalPath: "pages/once.html"},
+		{URL: "https://example.com/often", LocalPath: "pages/often.html"},
+		{URL: "https://example.com/title", LocalPath: "pages/title.html", Title: "Crawler"},
+		{URL: "https://example.com/other", LocalPath: "pages/other.html"},
+		{URL: "https://example.com/polish", LocalPath: "pages/polish.html", Language: "pl-PL"},
+		{URL: "https://example.com/missing", LocalPath: "pages/missing.html"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return index, dataDir
+}
+
+func TestBuildIndex(t *testing.T) {
+	index, dataDir := buildTestIndex(t)
+
+	if len(index.Docs) != 5 {
+		t.Fatalf("indexed %d pages, want 5 (missing page skipped)", len(index.Docs))
+	}
+	for _, doc := range index.Docs {
+		if doc.URL == "https://example.com/other" && doc.Text != "Nothing to see here." {
+			t.Errorf("text = %q, want script content left out", doc.Text)
+		}
+		if doc.URL == "https://example.com/often" && doc.Text != "Crawler guide The crawler queue feeds crawler workers." {
+			t.Errorf("text = %q, want blocks separated by spaces", doc.Text)
+		}
+		if doc.URL == "https://example.com/polish" && doc.Language != LangPolish {
+			t.Errorf("polish page language = %q", doc.Language)
+		}
+	}
+
+	loaded, err := LoadIndex("project", dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Docs) != len(index.Docs) || len(loaded.Terms) != len(index.Terms) || loaded.AvgLength != index.AvgLength {
+		t.Errorf("loaded index differs from the built one")
+	}
+
+	if _, err := LoadIndex("missing", dataDir); !os.IsNotExist(err) {
+		t.Errorf("missing index: err = %v, want not exist", err)
+	}
+}
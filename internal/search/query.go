@@ -0,0 +1,224 @@
+package search
+
+import (
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/user/scrapper/internal/models"
+)
+
+// BM25 parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Snippet window around the first match, in bytes
+const (
+	snippetBefore = 80
+	snippetAfter  = 160
+)
+
+// Search ranks indexed pages against a query with BM25.
+// Query words are stemmed with each document's own language stemmer.
+func (idx *Index) Search(query string) []models.SearchResult {
+	return idx.search(uniqueWords(query), newCorpus(idx))
+}
+
+// SearchAll ranks the pages of several project indexes, keyed by project
+// ID, as one collection, so scores of different projects are comparable
+func SearchAll(indexes map[string]*Index, query string) []models.SearchResult {
+	projectIDs := make([]string, 0, len(indexes))
+	all := make([]*Index, 0, len(indexes))
+	for projectID, idx := range indexes {
+		projectIDs = append(projectIDs, projectID)
+		all = append(all, idx)
+	}
+	sort.Strings(projectIDs)
+
+	words := uniqueWords(query)
+	collection := newCorpus(all...)
+	results := []models.SearchResult{}
+	for _, projectID := range projectIDs {
+		for _, result := range indexes[projectID].search(words, collection) {
+			result.ProjectID = projectID
+			results = append(results, result)
+		}
+	}
+
+	SortResults(results)
+	return results
+}
+
+// corpus holds the collection statistics of BM25 scores
+type corpus struct {
+	indexes   []*Index // Document frequencies are summed over these
+	docs      int
+	avgLength float64
+}
+
+// newCorpus returns the statistics of a collection of indexes
+func newCorpus(indexes ...*Index) corpus {
+	c := corpus{indexes: indexes}
+	totalLength := 0.0
+	for _, idx := range indexes {
+		c.docs += len(idx.Docs)
+		totalLength += idx.AvgLength * float64(len(idx.Docs))
+	}
+	if c.docs > 0 {
+		c.avgLength = totalLength / float64(c.docs)
+	}
+	return c
+}
+
+// docFreq returns the number of documents containing term
+func (c corpus) docFreq(term string) int {
+	total := 0
+	for _, idx := range c.indexes {
+		total += len(idx.Terms[term])
+	}
+	return total
+}
+
+// search scores the documents of the index with the statistics of c
+func (idx *Index) search(words []string, c corpus) []models.SearchResult {
+	if len(words) == 0 || len(idx.Docs) == 0 {
+		return []models.SearchResult{}
+	}
+
+	scores := make(map[int]float64)
+	for _, lang := range []string{LangEnglish, LangPolish} {
+		for _, word := range words {
+			term := Stem(word, lang)
+			postings := idx.Terms[term]
+			if len(postings) == 0 {
+				continue
+			}
+
+			docFreq := float64(c.docFreq(term))
+			idf := math.Log(1 + (float64(c.docs)-docFreq+0.5)/(docFreq+0.5))
+			for _, posting := range postings {
+				doc := idx.Docs[posting.Doc]
+				if doc.Language != lang {
+					continue
+				}
+				freq := float64(posting.Freq)
+				norm := bm25K1 * (1 - bm25B + bm25B*float64(doc.Length)/c.avgLength)
+				scores[posting.Doc] += idf * freq * (bm25K1 + 1) / (freq + norm)
+			}
+		}
+	}
+
+	results := make([]models.SearchResult, 0, len(scores))
+	for docID, score := range scores {
+		doc := idx.Docs[docID]
+		results = append(results, models.SearchResult{
+			URL:       doc.URL,
+			Title:     doc.Title,
+			LocalPath: doc.LocalPath,
+			Score:     math.Round(score*1000) / 1000,
+			Snippet:   snippet(doc, words),
+		})
+	}
+
+	SortResults(results)
+	return results
+}
+
+// SortResults orders results by score, then URL
+func SortResults(results []models.SearchResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].URL < results[j].URL
+	})
+}
+
+// uniqueWords tokenizes a query, dropping duplicates
+func uniqueWords(query string) []string {
+	var words []string
+	seen := make(map[string]bool)
+	for _, tok := range tokenize(query) {
+		if !seen[tok.word] {
+			seen[tok.word] = true
+			words = append(words, tok.word)
+		}
+	}
+	return words
+}
+
+// snippet cuts a window of doc text around the first match and marks every match in it
+func snippet(doc IndexedDoc, words []string) string {
+	stems := make(map[string]bool, len(words))
+	for _, word := range words {
+		stems[Stem(word, doc.Language)] = true
+	}
+
+	tokens := tokenize(doc.Text)
+	first := -1
+	for i, tok := range tokens {
+		if stems[Stem(tok.word, doc.Language)] {
+			first = i
+			break
+		}
+	}
+
+	// Title-only match: start of the page
+	start, end := 0, len(doc.Text)
+	if first >= 0 {
+		start = tokens[first].start - snippetBefore
+	}
+	if start < 0 {
+		start = 0
+	}
+	if start+snippetBefore+snippetAfter < end {
+		end = start + snippetBefore + snippetAfter
+	}
+
+	// Widen to whole words
+	for _, tok := range tokens {
+		if tok.start < start && tok.end > start {
+			start = tok.start
+		}
+		if tok.start < end && tok.end > end {
+			end = tok.end
+		}
+	}
+
+	// Never split a character outside words, such as "—" or "…"
+	for start > 0 && !utf8.RuneStart(doc.Text[start]) {
+		start--
+	}
+	for end < len(doc.Text) && !utf8.RuneStart(doc.Text[end]) {
+		end++
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, tok := range tokens {
+		if tok.start < start || tok.end > end {
+			continue
+		}
+		if !stems[Stem(tok.word, doc.Language)] {
+			continue
+		}
+		b.WriteString(html.EscapeString(doc.Text[pos:tok.start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(doc.Text[tok.start:tok.end]))
+		b.WriteString("</mark>")
+		pos = tok.end
+	}
+	b.WriteString(html.EscapeString(doc.Text[pos:end]))
+	if end < len(doc.Text) {
+		b.WriteString("…")
+	}
+
+	return strings.TrimSpace(b.String())
+}
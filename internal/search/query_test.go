@@ -0,0 +1,176 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSearchRanking(t *testing.T) {
+	index, _ := buildTestIndex(t)
+
+	results := index.Search("crawlers")
+	rank := make(map[string]int)
+	for i, result := range results {
+		rank[result.URL] = i + 1
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3: %+v", len(results), results)
+	}
+	if rank["https://example.com/other"] != 0 {
+		t.Error("page matching only inside a script was found")
+	}
+	if rank["https://example.com/title"] == 0 {
+		t.Error("page matching only in its title was not found")
+	}
+	if rank["https://example.com/often"] > rank["https://example.com/once"] {
+		t.Errorf("page with three matches ranked below one match: %+v", results)
+	}
+	for i := 1; i < len(results); i++ {
+		if results[i].Score > results[i-1].Score {
+			t.Errorf("results not sorted by score: %+v", results)
+		}
+	}
+
+	if got := index.Search("  ,;  "); len(got) != 0 {
+		t.Errorf("query without words returned %+v", got)
+	}
+}
+
+func TestSearchStemsPolish(t *testing.T) {
+	index, _ := buildTestIndex(t)
+
+	results := index.Search("Książki")
+	if len(results) != 1 || results[0].URL != "https://example.com/polish" {
+		t.Fatalf("results = %+v, want the Polish page", results)
+	}
+	if !strings.Contains(results[0].Snippet, "<mark>książkę</mark>") {
+		t.Errorf("snippet = %q, want the inflected word marked", results[0].Snippet)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		words    []string
+		want     string // Substring of the snippet
+		ellipsis [2]bool
+	}{
+		{
+			name:  "match marked and escaped",
+			text:  "Use <b> tags & crawl the site",
+			words: []string{"crawl"},
+			want:  "Use &lt;b&gt; tags &amp; <mark>crawl</mark> the site",
+		},
+		{
+			name:  "title only match starts at the page start",
+			text:  strings.Repeat("word ", 100),
+			words: []string{"missing"},
+			want:  "word word",
+			// Cut after the window
+			ellipsis: [2]bool{false, true},
+		},
+		{
+			// The window start falls inside a three-byte dash
+			name:     "multi-byte start",
+			text:     strings.Repeat("—", 60) + " crawl",
+			words:    []string{"crawl"},
+			want:     "— <mark>crawl</mark>",
+			ellipsis: [2]bool{true, false},
+		},
+		{
+			// The window end falls inside a three-byte dash
+			name:     "multi-byte end",
+			text:     "crawl  " + strings.Repeat("—", 200),
+			words:    []string{"crawl"},
+			want:     "<mark>crawl</mark>  —",
+			ellipsis: [2]bool{false, true},
+		},
+		{
+			// Window bounds inside words are widened to whole words
+			name:     "multi-byte words",
+			text:     strings.Repeat("zażółć gęślą jaźń ", 10) + "pająk " + strings.Repeat("źdźbło ", 40),
+			words:    []string{"pająk"},
+			want:     "<mark>pająk</mark> źdźbło",
+			ellipsis: [2]bool{true, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lang := DetectLanguage("", tt.text)
+			got := snippet(IndexedDoc{Text: tt.text, Language: lang}, tt.words)
+
+			if !utf8.ValidString(got) {
+				t.Fatalf("snippet is not valid UTF-8: %q", got)
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("snippet = %q, want it to contain %q", got, tt.want)
+			}
+			if strings.HasPrefix(got, "…") != tt.ellipsis[0] || strings.HasSuffix(got, "…") != tt.ellipsis[1] {
+				t.Errorf("snippet = %q, want leading/trailing ellipsis %v", got, tt.ellipsis)
+			}
+
+			// Every cut is at a whole word: no partial word next to an ellipsis
+			trimmed := strings.TrimSuffix(strings.TrimPrefix(got, "…"), "…")
+			for _, word := range strings.Fields(trimmed) {
+				word = strings.TrimSuffix(strings.TrimPrefix(word, "<mark>"), "</mark>")
+				if word = html.UnescapeString(word); !strings.Contains(tt.text, word) {
+					t.Errorf("snippet word %q is not in the text", word)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchAllComparesProjects(t *testing.T) {
+	dataDir := t.TempDir()
+	page := `<html><body><p>Notes about the crawler.</p></body></html>`
+	other := `<html><body><p>Unrelated text about gardens.</p></body></html>`
+	writePages(t, dataDir, "small", map[string]string{"pages/a.html": page})
+	writePages(t, dataDir, "large", map[string]string{
+		"pages/a.html": page,
+		"pages/b.html": other,
+		"pages/c.html": other,
+		"pages/d.html": other,
+	})
+
+	small, err := BuildIndex("small", dataDir, []Document{{URL: "https://small.example/", LocalPath: "pages/a.html"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	large, err := BuildIndex("large", dataDir, []Document{
+		{URL: "https://large.example/", LocalPath: "pages/a.html"},
+		{URL: "https://large.example/b", LocalPath: "pages/b.html"},
+		{URL: "https://large.example/c", LocalPath: "pages/c.html"},
+		{URL: "https://large.example/d", LocalPath: "pages/d.html"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Scored alone, the same page gets a different score in each project
+	if small.Search("crawler")[0].Score == large.Search("crawler")[0].Score {
+		t.Fatal("test projects do not have different statistics")
+	}
+
+	results := SearchAll(map[string]*Index{"small": small, "large": large}, "crawler")
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2: %+v", len(results), results)
+	}
+	if results[0].Score != results[1].Score {
+		t.Errorf("same page scored %v and %v in different projects", results[0].Score, results[1].Score)
+	}
+	projects := map[string]string{results[0].ProjectID: results[0].URL, results[1].ProjectID: results[1].URL}
+	if projects["small"] != "https://small.example/" || projects["large"] != "https://large.example/" {
+		t.Errorf("results not tagged with their projects: %+v", results)
+	}
+
+	alone := SearchAll(map[string]*Index{"large": large}, "crawler")
+	if len(alone) != 1 || alone[0].Score != large.Search("crawler")[0].Score {
+		t.Errorf("single project scored %+v, want the same as Search", alone)
+	}
+}
@@ -0,0 +1,113 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Supported stemmer languages
+const (
+	LangEnglish = "en"
+	LangPolish  = "pl"
+)
+
+// polishLetters are characters that only occur in Polish text
+const polishLetters = "ąćęłńóśźż"
+
+// englishSuffixes are stripped longest-first; each keeps a stem of at least 3 letters
+var englishSuffixes = []string{
+	"ational", "ization", "fulness", "iveness", "ousness",
+	"ations", "ation", "ments", "ment", "nesses", "ness",
+	"ingly", "ings", "ing", "edly", "ies", "ied", "ed",
+	"ers", "er", "ly", "es", "s",
+}
+
+// polishSuffixes are common inflectional endings, longest-first
+var polishSuffixes = []string{
+	"owaniami", "owaniach", "owanie", "owania", "owaniu", "owaniem",
+	"iejszy", "ejszy", "ościami", "ościach", "ością", "ości", "ość",
+	"ami", "ach", "ich", "ych", "imi", "ymi", "ego", "emu", "owi", "owa", "owe", "owy",
+	"iem", "ie", "em", "om", "ów", "ej", "ą", "ę",
+	"a", "e", "i", "o", "u", "y",
+}
+
+// Stem reduces a lower-case word to its stem for the given language
+func Stem(word, lang string) string {
+	if lang == LangPolish {
+		return stemSuffixes(word, polishSuffixes, 3)
+	}
+	return stemSuffixes(word, englishSuffixes, 3)
+}
+
+// stemSuffixes strips the first matching suffix that leaves at least minStem runes
+func stemSuffixes(word string, suffixes []string, minStem int) string {
+	for _, suffix := range suffixes {
+		if !strings.HasSuffix(word, suffix) {
+			continue
+		}
+		stem := strings.TrimSuffix(word, suffix)
+		if utf8.RuneCountInString(stem) >= minStem {
+			return stem
+		}
+	}
+	return word
+}
+
+// DetectLanguage picks a stemmer from the page language or, if unknown,
+// from the presence of Polish diacritics in the text
+func DetectLanguage(declared, text string) string {
+	declared = strings.ToLower(declared)
+	switch {
+	case strings.HasPrefix(declared, LangPolish):
+		return LangPolish
+	case strings.HasPrefix(declared, LangEnglish):
+		return LangEnglish
+	}
+
+	letters, polish := 0, 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if strings.ContainsRune(polishLetters, unicode.ToLower(r)) {
+			polish++
+		}
+	}
+
+	// Polish prose has roughly 3-7% diacritics
+	if letters > 0 && polish*100 >= letters {
+		return LangPolish
+	}
+	return LangEnglish
+}
+
+// token is a normalized word with its byte span in the source text
+type token struct {
+	word  string
+	start int
+	end   int
+}
+
+// tokenize splits text into lower-case letter/digit runs
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWord && start < 0 {
+			start = i
+		}
+		if !isWord && start >= 0 {
+			tokens = append(tokens, token{word: strings.ToLower(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{word: strings.ToLower(text[start:]), start: start, end: len(text)})
+	}
+
+	return tokens
+}
@@ -0,0 +1,246 @@
+package warc
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// archivedResponse locates the record answering one URL
+type archivedResponse struct {
+	offset int64
+	kind   string    // TypeResponse or TypeResource
+	date   time.Time // WARC-Date of the capture; of the revisit for revisits
+}
+
+// Archive serves HTTP responses stored in a WARC file. It implements
+// http.RoundTripper, so a client using it never reaches the network;
+// URLs missing from the archive get a 404.
+type Archive struct {
+	file      *os.File // Uncompressed copy of the WARC
+	size      int64
+	responses map[string]archivedResponse
+	firstPage string
+}
+
+// ErrArchiveTooLarge is returned for WARC streams that decompress to more
+// than the size limit
+var ErrArchiveTooLarge = errors.New("WARC file exceeds the size limit")
+
+// NewArchive copies a WARC stream, compressed or not, to a temporary file
+// in tmpDir and indexes its responses. The uncompressed copy may hold at
+// most maxSize bytes. Close removes the copy.
+func NewArchive(src io.Reader, tmpDir string, maxSize int64) (*Archive, error) {
+	stream, err := Decompress(src)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.CreateTemp(tmpDir, "import-*.warc")
+	if err != nil {
+		return nil, err
+	}
+	a := &Archive{file: file, responses: make(map[string]archivedResponse)}
+
+	if a.size, err = io.Copy(file, io.LimitReader(stream, maxSize+1)); err != nil {
+		a.Close()
+		return nil, fmt.Errorf("failed to read WARC: %w", err)
+	}
+	if a.size > maxSize {
+		a.Close()
+		return nil, fmt.Errorf("%w of %d bytes", ErrArchiveTooLarge, maxSize)
+	}
+	if err := a.index(); err != nil {
+		a.Close()
+		return nil, err
+	}
+	if len(a.responses) == 0 {
+		a.Close()
+		return nil, fmt.Errorf("%w: no response records", ErrInvalidRecord)
+	}
+
+	return a, nil
+}
+
+// index records the offset of the last response or resource record of
+// every URL; revisits point to the record they refer to
+func (a *Archive) index() error {
+	reader, err := NewReader(io.NewSectionReader(a.file, 0, a.size))
+	if err != nil {
+		return err
+	}
+
+	type revisit struct {
+		target string // URL of the original capture
+		date   time.Time
+	}
+	revisits := make(map[string]revisit)
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		uri := TargetURI(record.Header)
+		if uri == "" {
+			continue
+		}
+
+		date, _ := time.Parse(time.RFC3339Nano, record.Header.Get("WARC-Date"))
+		switch record.Type() {
+		case TypeResponse:
+			if !strings.HasPrefix(record.Header.Get("Content-Type"), "application/http") {
+				continue
+			}
+			a.responses[uri] = archivedResponse{offset: record.Offset, kind: TypeResponse, date: date}
+			if a.firstPage == "" && isHTMLResponse(record.Content) {
+				a.firstPage = uri
+			}
+		case TypeResource:
+			a.responses[uri] = archivedResponse{offset: record.Offset, kind: TypeResource, date: date}
+		case TypeRevisit:
+			if target := record.Header.Get("WARC-Refers-To-Target-URI"); target != "" {
+				revisits[uri] = revisit{target: strings.Trim(target, "<>"), date: date}
+			}
+		}
+	}
+
+	for uri, visit := range revisits {
+		if _, ok := a.responses[uri]; !ok {
+			if original, ok := a.responses[visit.target]; ok {
+				original.date = visit.date
+				a.responses[uri] = original
+			}
+		}
+	}
+
+	return nil
+}
+
+// isHTMLResponse reports whether an archived HTTP response is a 200 HTML page
+func isHTMLResponse(block io.Reader) bool {
+	resp, err := http.ReadResponse(bufio.NewReader(block), nil)
+	if err != nil {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return resp.StatusCode == http.StatusOK && mediaType == "text/html"
+}
+
+// FirstPage returns the URL of the first HTML page in the archive
+func (a *Archive) FirstPage() string {
+	return a.firstPage
+}
+
+// Len returns the number of archived URLs
+func (a *Archive) Len() int {
+	return len(a.responses)
+}
+
+// CaptureDate returns the WARC-Date of the record answering a URL. It
+// returns false for URLs missing from the archive, which RoundTrip answers
+// with a made-up 404.
+func (a *Archive) CaptureDate(rawURL string) (time.Time, bool) {
+	found, ok := a.lookup(rawURL)
+	return found.date, ok
+}
+
+// RoundTrip answers a request from the archive
+func (a *Archive) RoundTrip(req *http.Request) (*http.Response, error) {
+	found, ok := a.lookup(req.URL.String())
+	if !ok {
+		return notArchived(req), nil
+	}
+
+	reader, err := NewReader(io.NewSectionReader(a.file, found.offset, a.size-found.offset))
+	if err != nil {
+		return nil, err
+	}
+	record, err := reader.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	if found.kind == TypeResource {
+		header := http.Header{}
+		if contentType := record.Header.Get("Content-Type"); contentType != "" {
+			header.Set("Content-Type", contentType)
+		}
+		return newResponse(req, http.StatusOK, header, record.Content, record.Length), nil
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(record.Content), req)
+	if err != nil {
+		return nil, fmt.Errorf("archived response for %s: %w", req.URL, err)
+	}
+
+	// Decode like http.Transport does for compressed live responses
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(resp.Body)
+		if err == nil {
+			resp.Body = io.NopCloser(gz)
+			resp.Header.Del("Content-Encoding")
+			resp.Header.Del("Content-Length")
+			resp.ContentLength = -1
+			resp.Uncompressed = true
+		}
+	}
+
+	return resp, nil
+}
+
+// lookup finds a URL, ignoring its fragment and a trailing slash
+func (a *Archive) lookup(rawURL string) (archivedResponse, bool) {
+	rawURL, _, _ = strings.Cut(rawURL, "#")
+	if found, ok := a.responses[rawURL]; ok {
+		return found, true
+	}
+	if strings.HasSuffix(rawURL, "/") {
+		found, ok := a.responses[strings.TrimSuffix(rawURL, "/")]
+		return found, ok
+	}
+	found, ok := a.responses[rawURL+"/"]
+	return found, ok
+}
+
+// Close removes the temporary copy of the WARC
+func (a *Archive) Close() error {
+	name := a.file.Name()
+	a.file.Close()
+	return os.Remove(name)
+}
+
+// notArchived is the response for URLs missing from the archive
+func notArchived(req *http.Request) *http.Response {
+	body := []byte("Not in archive\n")
+	header := http.Header{"Content-Type": {"text/plain; charset=utf-8"}}
+	return newResponse(req, http.StatusNotFound, header, bytes.NewReader(body), int64(len(body)))
+}
+
+// newResponse builds an HTTP/1.1 response
+func newResponse(req *http.Request, status int, header http.Header, body io.Reader, length int64) *http.Response {
+	header.Set("Content-Length", strconv.FormatInt(length, 10))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(body),
+		ContentLength: length,
+		Request:       req,
+	}
+}
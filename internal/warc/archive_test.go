@@ -0,0 +1,185 @@
+package warc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const (
+	capturedAt  = "2020-05-06T07:08:09Z"
+	revisitedAt = "2021-01-02T03:04:05Z"
+)
+
+// httpResponse formats an archived HTTP response
+func httpResponse(contentType, extra string, body []byte) []byte {
+	head := "HTTP/1.1 200 OK\r\nContent-Type: " + contentType + "\r\n" + extra + "\r\n"
+	return append([]byte(head), body...)
+}
+
+// testArchive indexes a compressed WARC with responses, a resource and a
+// revisit
+func testArchive(t *testing.T) *Archive {
+	t.Helper()
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	gz.Write([]byte("compressed page"))
+	gz.Close()
+
+	records := []struct {
+		kind, uri string
+		extra     Header
+		block     []byte
+	}{
+		{TypeRequest, "https://example.com/", nil, []byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n")},
+		{TypeResponse, "https://example.com/style.css", nil, httpResponse("text/css", "", []byte("body {}"))},
+		{TypeResponse, "https://example.com/", nil, httpResponse("text/html; charset=utf-8", "", []byte("<p>home</p>"))},
+		{TypeResponse, "https://example.com/docs/", nil, httpResponse("text/html", "", []byte("<p>docs</p>"))},
+		{TypeResponse, "https://example.com/gz", nil, httpResponse("text/plain", "Content-Encoding: gzip\r\n", gzipped.Bytes())},
+		{TypeResource, "https://example.com/data.json", Header{{Name: "Content-Type", Value: "application/json"}}, []byte(`{"a":1}`)},
+		{TypeRevisit, "https://example.com/again", Header{
+			{Name: "WARC-Date", Value: revisitedAt},
+			{Name: "WARC-Refers-To-Target-URI", Value: "<https://example.com/>"},
+		}, nil},
+		{TypeRevisit, "https://example.com/lost", Header{{Name: "WARC-Refers-To-Target-URI", Value: "https://example.com/never"}}, nil},
+	}
+
+	var buf bytes.Buffer
+	writer := NewWriter(&buf, true)
+	for _, record := range records {
+		header := Header{
+			{Name: "WARC-Type", Value: record.kind},
+			{Name: "WARC-Date", Value: capturedAt},
+			{Name: "WARC-Target-URI", Value: record.uri},
+		}
+		switch record.kind {
+		case TypeRequest:
+			header.Add("Content-Type", ContentTypeHTTPRequest)
+		case TypeResponse:
+			header.Add("Content-Type", ContentTypeHTTPResponse)
+		}
+		for _, field := range record.extra {
+			header.Set(field.Name, field.Value)
+		}
+		if _, err := writer.WriteRecord(header, record.block); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	archive, err := NewArchive(&buf, t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { archive.Close() })
+	return archive
+}
+
+func TestArchive(t *testing.T) {
+	archive := testArchive(t)
+
+	if got := archive.FirstPage(); got != "https://example.com/" {
+		t.Errorf("first page = %q, want the first HTML response", got)
+	}
+	if got := archive.Len(); got != 6 {
+		t.Errorf("archive has %d URLs, want 6", got)
+	}
+
+	tests := []struct {
+		name        string
+		url         string
+		status      int
+		contentType string
+		body        string
+		date        string // Empty for URLs missing from the archive
+	}{
+		{"response", "https://example.com/", 200, "text/html; charset=utf-8", "<p>home</p>", capturedAt},
+		{"fragment", "https://example.com/#top", 200, "text/html; charset=utf-8", "<p>home</p>", capturedAt},
+		{"missing trailing slash", "https://example.com/docs", 200, "text/html", "<p>docs</p>", capturedAt},
+		{"added trailing slash", "https://example.com/style.css/", 200, "text/css", "body {}", capturedAt},
+		{"host without slash", "https://example.com", 200, "text/html; charset=utf-8", "<p>home</p>", capturedAt},
+		{"gzip content encoding", "https://example.com/gz", 200, "text/plain", "compressed page", capturedAt},
+		{"resource", "https://example.com/data.json", 200, "application/json", `{"a":1}`, capturedAt},
+		{"revisit", "https://example.com/again", 200, "text/html; charset=utf-8", "<p>home</p>", revisitedAt},
+		{"revisit of a missing capture", "https://example.com/lost", 404, "text/plain; charset=utf-8", "Not in archive\n", ""},
+		{"missing", "https://example.com/other", 404, "text/plain; charset=utf-8", "Not in archive\n", ""},
+	}
+
+	client := &http.Client{Transport: archive}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Get(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if got := resp.Header.Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if string(body) != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+
+			date, ok := archive.CaptureDate(tt.url)
+			if tt.date == "" {
+				if ok {
+					t.Errorf("capture date %v for a URL missing from the archive", date)
+				}
+				return
+			}
+			if want, _ := time.Parse(time.RFC3339, tt.date); !ok || !date.Equal(want) {
+				t.Errorf("capture date = %v, %v, want %s", date, ok, tt.date)
+			}
+		})
+	}
+}
+
+func TestNewArchiveErrors(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewWriter(&buf, false)
+	header := Header{
+		{Name: "WARC-Type", Value: TypeResponse},
+		{Name: "WARC-Target-URI", Value: "https://example.com/"},
+		{Name: "Content-Type", Value: ContentTypeHTTPResponse},
+	}
+	if _, err := writer.WriteRecord(header, httpResponse("text/html", "", []byte("<p>home</p>"))); err != nil {
+		t.Fatal(err)
+	}
+	data := bytes.Clone(buf.Bytes())
+
+	if _, err := NewArchive(bytes.NewReader(data), t.TempDir(), int64(len(data))-1); !errors.Is(err, ErrArchiveTooLarge) {
+		t.Errorf("oversized archive: err = %v, want %v", err, ErrArchiveTooLarge)
+	}
+
+	buf.Reset()
+	if _, err := writer.WriteRecord(Header{{Name: "WARC-Type", Value: TypeWarcinfo}}, Fields(Field{"software", "test"})); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewArchive(&buf, t.TempDir(), 1<<20); !errors.Is(err, ErrInvalidRecord) {
+		t.Errorf("archive without responses: err = %v, want %v", err, ErrInvalidRecord)
+	}
+
+	tmpDir := t.TempDir()
+	archive, err := NewArchive(bytes.NewReader(data), tmpDir, int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if copies, _ := filepath.Glob(filepath.Join(tmpDir, "*")); len(copies) != 0 {
+		t.Errorf("Close left %v behind", copies)
+	}
+}
@@ -0,0 +1,148 @@
+package warc
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidRecord is returned for data that is not a WARC record
+var ErrInvalidRecord = errors.New("invalid WARC record")
+
+// Record is a WARC record read from a file
+type Record struct {
+	Header  Header
+	Content io.Reader // Block; valid until the next call to Next
+	Length  int64     // Block length
+	Offset  int64     // Position of the record in the uncompressed stream
+}
+
+// Type returns the WARC-Type of the record
+func (r *Record) Type() string {
+	return r.Header.Get("WARC-Type")
+}
+
+// Reader reads records from a WARC file, compressed or not
+type Reader struct {
+	br      *bufio.Reader
+	offset  int64
+	content *io.LimitedReader // Unread block of the current record
+}
+
+// NewReader creates a reader; gzip input (.warc.gz) is detected and
+// decompressed
+func NewReader(r io.Reader) (*Reader, error) {
+	stream, err := Decompress(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{br: bufio.NewReader(stream)}, nil
+}
+
+// Decompress returns the uncompressed WARC stream of r
+func Decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(br) // Reads all members
+	}
+	return br, nil
+}
+
+// Next returns the next record, or io.EOF after the last one
+func (r *Reader) Next() (*Record, error) {
+	// Skip the rest of the previous block and the separator
+	if r.content != nil {
+		n, err := io.Copy(io.Discard, r.content)
+		r.offset += n
+		if err != nil {
+			return nil, err
+		}
+		r.content = nil
+	}
+
+	var line string
+	var start int64
+	for {
+		l, err := r.readLine()
+		if err == io.EOF && l == "" {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line = strings.TrimRight(l, "\r\n"); line != "" {
+			start = r.offset - int64(len(l))
+			break
+		}
+	}
+	if !strings.HasPrefix(line, "WARC/1.") {
+		return nil, fmt.Errorf("%w: unexpected line %q", ErrInvalidRecord, truncate(line, 40))
+	}
+
+	var header Header
+	for {
+		l, err := r.readLine()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRecord, err)
+		}
+		l = strings.TrimRight(l, "\r\n")
+		if l == "" {
+			break
+		}
+		// Continuation lines extend the previous field
+		if (l[0] == ' ' || l[0] == '\t') && len(header) > 0 {
+			header[len(header)-1].Value += " " + strings.TrimSpace(l)
+			continue
+		}
+		name, value, ok := strings.Cut(l, ":")
+		if !ok {
+			return nil, fmt.Errorf("%w: malformed field %q", ErrInvalidRecord, truncate(l, 40))
+		}
+		header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("%w: missing Content-Length", ErrInvalidRecord)
+	}
+
+	r.content = &io.LimitedReader{R: r.br, N: length}
+	return &Record{
+		Header:  header,
+		Content: &countingReader{r: r.content, n: &r.offset},
+		Length:  length,
+		Offset:  start,
+	}, nil
+}
+
+// readLine reads one line and advances the offset
+func (r *Reader) readLine() (string, error) {
+	line, err := r.br.ReadString('\n')
+	r.offset += int64(len(line))
+	return line, err
+}
+
+// countingReader adds the bytes read to n
+type countingReader struct {
+	r io.Reader
+	n *int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	*c.n += int64(n)
+	return n, err
+}
+
+// truncate shortens s for error messages
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max] + "..."
+	}
+	return s
+}
@@ -0,0 +1,108 @@
+// Package warc reads and writes WARC 1.1 files (ISO 28500) and replays
+// archived HTTP responses.
+package warc
+
+import (
+	"crypto/sha1"
+	"encoding/base32"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Version is written in the first line of every record
+const Version = "WARC/1.1"
+
+// Record types
+const (
+	TypeWarcinfo = "warcinfo"
+	TypeRequest  = "request"
+	TypeResponse = "response"
+	TypeResource = "resource"
+	TypeMetadata = "metadata"
+	TypeRevisit  = "revisit"
+)
+
+// Content types of record blocks
+const (
+	ContentTypeHTTPRequest  = "application/http;msgtype=request"
+	ContentTypeHTTPResponse = "application/http;msgtype=response"
+	ContentTypeFields       = "application/warc-fields"
+)
+
+// DateFormat is the WARC-Date layout
+const DateFormat = "2006-01-02T15:04:05Z"
+
+// Field is a named header value
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Header holds WARC record fields in order
+type Header []Field
+
+// Get returns the first value of a field; names are case-insensitive
+func (h Header) Get(name string) string {
+	for _, field := range h {
+		if strings.EqualFold(field.Name, name) {
+			return field.Value
+		}
+	}
+	return ""
+}
+
+// Set replaces the value of a field or appends it
+func (h *Header) Set(name, value string) {
+	for i, field := range *h {
+		if strings.EqualFold(field.Name, name) {
+			(*h)[i].Value = value
+			return
+		}
+	}
+	h.Add(name, value)
+}
+
+// Add appends a field
+func (h *Header) Add(name, value string) {
+	*h = append(*h, Field{Name: name, Value: value})
+}
+
+// NewRecordID returns a new WARC-Record-ID
+func NewRecordID() string {
+	return "<urn:uuid:" + uuid.New().String() + ">"
+}
+
+// FormatDate formats a time as a WARC-Date
+func FormatDate(t time.Time) string {
+	return t.UTC().Format(DateFormat)
+}
+
+// Digest returns the SHA-1 digest of data in the form used by
+// WARC-Block-Digest and WARC-Payload-Digest
+func Digest(data []byte) string {
+	sum := sha1.Sum(data)
+	return "sha1:" + base32.StdEncoding.EncodeToString(sum[:])
+}
+
+// TargetURI returns WARC-Target-URI without the angle brackets some WARC
+// 1.0 writers put around it
+func TargetURI(h Header) string {
+	return strings.TrimSuffix(strings.TrimPrefix(h.Get("WARC-Target-URI"), "<"), ">")
+}
+
+// Fields formats application/warc-fields content
+func Fields(fields ...Field) []byte {
+	var b strings.Builder
+	for _, field := range fields {
+		fmt.Fprintf(&b, "%s: %s\r\n", field.Name, singleLine(field.Value))
+	}
+	return []byte(b.String())
+}
+
+// singleLine keeps a field value on one line
+func singleLine(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
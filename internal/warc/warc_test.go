@@ -0,0 +1,219 @@
+package warc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testRecord is a record written in the round trip tests
+type testRecord struct {
+	header Header
+	block  string
+}
+
+var roundTripRecords = []testRecord{
+	{
+		header: Header{
+			{Name: "WARC-Type", Value: TypeWarcinfo},
+			{Name: "Content-Type", Value: ContentTypeFields},
+		},
+		block: string(Fields(Field{"software", "scrapper"}, Field{"description", "two\nlines"})),
+	},
+	{
+		header: Header{
+			{Name: "WARC-Type", Value: TypeResponse},
+			{Name: "WARC-Date", Value: "2020-05-06T07:08:09Z"},
+			{Name: "WARC-Target-URI", Value: "https://example.com/"},
+			{Name: "Content-Type", Value: ContentTypeHTTPResponse},
+			{Name: "Content-Length", Value: "1"},      // Replaced by the real length
+			{Name: "WARC-Block-Digest", Value: "bad"}, // Replaced by the real digest
+		},
+		block: "HTTP/1.1 200 OK\r\nContent-Type: text/html\r\n\r\n<p>zażółć</p>\r\n\r\n",
+	},
+	{
+		header: Header{
+			{Name: "WARC-Type", Value: TypeResource},
+			{Name: "WARC-Target-URI", Value: "https://example.com/empty"},
+		},
+		block: "",
+	},
+}
+
+// writeRecords writes records and returns the WARC and the record IDs
+func writeRecords(t *testing.T, compress bool, records []testRecord) ([]byte, []string) {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := NewWriter(&buf, compress)
+	var ids []string
+	for _, record := range records {
+		id, err := writer.WriteRecord(record.header, []byte(record.block))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	return buf.Bytes(), ids
+}
+
+// gzipMembers counts the gzip members of data
+func gzipMembers(t *testing.T, data []byte) int {
+	t.Helper()
+	br := bytes.NewReader(data)
+	gz, err := gzip.NewReader(br)
+	if err != nil {
+		t.Fatal(err)
+	}
+	members := 0
+	for {
+		gz.Multistream(false)
+		if _, err := io.Copy(io.Discard, gz); err != nil {
+			t.Fatal(err)
+		}
+		members++
+		if err := gz.Reset(br); err == io.EOF {
+			return members
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWriterReaderRoundTrip(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		t.Run("compress="+strconv.FormatBool(compress), func(t *testing.T) {
+			data, ids := writeRecords(t, compress, roundTripRecords)
+			if compress {
+				if members := gzipMembers(t, data); members != len(roundTripRecords) {
+					t.Errorf("%d gzip members, want one per record (%d)", members, len(roundTripRecords))
+				}
+			}
+
+			reader, err := NewReader(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var offset int64
+			for i, want := range roundTripRecords {
+				record, err := reader.Next()
+				if err != nil {
+					t.Fatalf("record %d: %v", i, err)
+				}
+				block, err := io.ReadAll(record.Content)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if string(block) != want.block {
+					t.Errorf("record %d: block = %q, want %q", i, block, want.block)
+				}
+				if record.Type() != want.header.Get("WARC-Type") {
+					t.Errorf("record %d: type = %q", i, record.Type())
+				}
+				if got := record.Header.Get("WARC-Record-ID"); got != ids[i] || !strings.HasPrefix(got, "<urn:uuid:") {
+					t.Errorf("record %d: ID = %q, want %q", i, got, ids[i])
+				}
+				if date := want.header.Get("WARC-Date"); date != "" && record.Header.Get("WARC-Date") != date {
+					t.Errorf("record %d: date = %q, want %q", i, record.Header.Get("WARC-Date"), date)
+				}
+				if record.Header.Get("WARC-Date") == "" {
+					t.Errorf("record %d has no WARC-Date", i)
+				}
+				if got := record.Header.Get("Content-Length"); got != strconv.Itoa(len(want.block)) || record.Length != int64(len(want.block)) {
+					t.Errorf("record %d: Content-Length = %q (%d), want %d", i, got, record.Length, len(want.block))
+				}
+				if got := record.Header.Get("WARC-Block-Digest"); got != Digest(block) {
+					t.Errorf("record %d: WARC-Block-Digest = %q, want %q", i, got, Digest(block))
+				}
+				if record.Offset != offset {
+					t.Errorf("record %d: offset = %d, want %d", i, record.Offset, offset)
+				}
+				offset = record.Offset + int64(len(recordBytes(record.Header, block)))
+			}
+			if _, err := reader.Next(); err != io.EOF {
+				t.Errorf("after the last record: err = %v, want EOF", err)
+			}
+		})
+	}
+}
+
+// recordBytes formats a record the way the writer does
+func recordBytes(header Header, block []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString(Version + "\r\n")
+	for _, field := range header {
+		b.WriteString(field.Name + ": " + field.Value + "\r\n")
+	}
+	b.WriteString("\r\n")
+	b.Write(block)
+	b.WriteString("\r\n\r\n")
+	return b.Bytes()
+}
+
+func TestWriterRequiresType(t *testing.T) {
+	writer := NewWriter(io.Discard, false)
+	if _, err := writer.WriteRecord(Header{{Name: "WARC-Target-URI", Value: "https://example.com/"}}, nil); err == nil {
+		t.Error("record without WARC-Type was written")
+	}
+}
+
+func TestReaderFields(t *testing.T) {
+	const warc = "WARC/1.0\r\n" +
+		"WARC-Type: resource\r\n" +
+		"WARC-Target-URI: <https://example.com/a>\r\n" +
+		"X-Note: first\r\n" +
+		"  second\r\n" +
+		"\tthird\r\n" +
+		"Content-Length: 2\r\n" +
+		"\r\n" +
+		"ok\r\n\r\n"
+
+	reader, err := NewReader(strings.NewReader(warc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err := reader.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := record.Header.Get("x-note"); got != "first second third" {
+		t.Errorf("continued field = %q, want %q", got, "first second third")
+	}
+	if got := TargetURI(record.Header); got != "https://example.com/a" {
+		t.Errorf("target URI = %q, want the brackets removed", got)
+	}
+	if block, _ := io.ReadAll(record.Content); string(block) != "ok" {
+		t.Errorf("block = %q, want %q", block, "ok")
+	}
+	if _, err := reader.Next(); err != io.EOF {
+		t.Errorf("err = %v, want EOF", err)
+	}
+}
+
+func TestReaderRejectsInvalidRecords(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"not a WARC", "<html></html>\r\n"},
+		{"malformed field", "WARC/1.1\r\nWARC-Type resource\r\n\r\n"},
+		{"no Content-Length", "WARC/1.1\r\nWARC-Type: resource\r\n\r\n"},
+		{"negative Content-Length", "WARC/1.1\r\nWARC-Type: resource\r\nContent-Length: -1\r\n\r\n"},
+		{"truncated header", "WARC/1.1\r\nWARC-Type: resource\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := NewReader(strings.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := reader.Next(); !errors.Is(err, ErrInvalidRecord) {
+				t.Errorf("err = %v, want %v", err, ErrInvalidRecord)
+			}
+		})
+	}
+}
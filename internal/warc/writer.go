@@ -0,0 +1,85 @@
+package warc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Writer appends records to a WARC file. With compression every record
+// is a separate gzip member, as in .warc.gz files. It is safe for
+// concurrent use.
+type Writer struct {
+	mu       sync.Mutex
+	w        io.Writer
+	compress bool
+}
+
+// NewWriter creates a writer; compress selects .warc.gz output
+func NewWriter(w io.Writer, compress bool) *Writer {
+	return &Writer{w: w, compress: compress}
+}
+
+// WriteRecord writes a record with the given block. WARC-Record-ID and
+// WARC-Date are added when missing; Content-Length and WARC-Block-Digest
+// are always set. It returns the record ID.
+func (w *Writer) WriteRecord(header Header, block []byte) (string, error) {
+	if header.Get("WARC-Type") == "" {
+		return "", fmt.Errorf("record has no WARC-Type")
+	}
+	id := header.Get("WARC-Record-ID")
+	if id == "" {
+		id = NewRecordID()
+	}
+	date := header.Get("WARC-Date")
+	if date == "" {
+		date = FormatDate(time.Now())
+	}
+
+	// Mandatory fields first, then the rest in the given order
+	fields := Header{
+		{Name: "WARC-Type", Value: header.Get("WARC-Type")},
+		{Name: "WARC-Record-ID", Value: id},
+		{Name: "WARC-Date", Value: date},
+	}
+	for _, field := range header {
+		switch strings.ToLower(field.Name) {
+		case "warc-type", "warc-record-id", "warc-date", "warc-block-digest", "content-length":
+			continue
+		}
+		fields = append(fields, field)
+	}
+	fields.Add("WARC-Block-Digest", Digest(block))
+	fields.Add("Content-Length", strconv.Itoa(len(block)))
+
+	var record bytes.Buffer
+	record.WriteString(Version + "\r\n")
+	for _, field := range fields {
+		fmt.Fprintf(&record, "%s: %s\r\n", field.Name, singleLine(field.Value))
+	}
+	record.WriteString("\r\n")
+	record.Write(block)
+	record.WriteString("\r\n\r\n")
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.compress {
+		_, err := w.w.Write(record.Bytes())
+		return id, err
+	}
+
+	gz := gzip.NewWriter(w.w)
+	if _, err := gz.Write(record.Bytes()); err != nil {
+		return "", err
+	}
+	if err := gz.Close(); err != nil {
+		return "", err
+	}
+	return id, nil
+}